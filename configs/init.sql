@@ -1,21 +1,33 @@
 create database service;
 
 drop table if exists super_users;
+drop table if exists totp_secrets;
+drop table if exists recovery_codes;
+drop table if exists oauth_consents;
+drop table if exists oauth_clients;
+drop table if exists api_keys;
+drop table if exists sessions;
+drop table if exists password_history;
+drop table if exists identities;
 drop table if exists users;
 create table users
 (
-    id            serial primary key,
-    eid           varchar(32) unique       not null,
-    phone         char(11) unique          not null,
+    id                  serial primary key,
+    eid                 varchar(32) unique       not null,
+    phone               char(11) unique          not null,
+    email               varchar(255) unique      null,
+    email_verified      boolean                  not null default false,
 
-    password_hash char(60)                 not null,
-    nickname      varchar(32)              not null,
-    avatar        char(55)                 null,
-    state         smallint                 not null default 0,
+    password_hash       varchar(128)             not null,
+    nickname            varchar(32)              not null,
+    avatar              char(55)                 null,
+    state               smallint                 not null default 0,
+    credential_version  integer                  not null default 0,
+    password_changed_at timestamp with time zone not null default now(),
 
-    created_at    timestamp with time zone not null default now(),
-    updated_at    timestamp with time zone not null default ('now'::text)::timestamp(0) with time zone,
-    deleted_at    timestamp with time zone null
+    created_at          timestamp with time zone not null default now(),
+    updated_at          timestamp with time zone not null default ('now'::text)::timestamp(0) with time zone,
+    deleted_at          timestamp with time zone null
 );
 
 create index users_deleted_at_key on users (deleted_at);
@@ -35,3 +47,184 @@ create table super_users
 
 INSERT INTO public.super_users (eid)
 VALUES ('Eachin');
+
+
+drop table if exists totp_secrets;
+create table totp_secrets
+(
+    id         serial primary key,
+    eid        varchar(32) unique       not null,
+    secret     varchar(64)              not null,
+    enabled_at timestamp with time zone null,
+    created_at timestamp with time zone not null default now(),
+    foreign key (eid) references users (eid) on delete cascade on update cascade
+);
+
+
+drop table if exists recovery_codes;
+create table recovery_codes
+(
+    id         serial primary key,
+    eid        varchar(32)              not null,
+    code_hash  char(64)                 not null,
+    used_at    timestamp with time zone null,
+    created_at timestamp with time zone not null default now(),
+    foreign key (eid) references users (eid) on delete cascade on update cascade
+);
+
+create index recovery_codes_eid_key on recovery_codes (eid);
+
+
+drop table if exists oauth_clients;
+create table oauth_clients
+(
+    id            serial primary key,
+    client_id     varchar(32) unique       not null,
+    secret_hash   varchar(64)              not null default '',
+    name          varchar(64)              not null,
+    redirect_uris text                     not null,
+    scopes        varchar(255)             not null default '',
+    owner_eid     varchar(32)              not null,
+
+    created_at    timestamp with time zone not null default now(),
+    updated_at    timestamp with time zone not null default ('now'::text)::timestamp(0) with time zone,
+    deleted_at    timestamp with time zone null,
+    foreign key (owner_eid) references users (eid) on delete cascade on update cascade
+);
+
+create index oauth_clients_deleted_at_key on oauth_clients (deleted_at);
+
+
+drop table if exists oauth_consents;
+create table oauth_consents
+(
+    id         serial primary key,
+    eid        varchar(32)              not null,
+    client_id  varchar(32)              not null,
+    scopes     varchar(255)             not null default '',
+    created_at timestamp with time zone not null default now(),
+    updated_at timestamp with time zone not null default ('now'::text)::timestamp(0) with time zone,
+    unique (eid, client_id),
+    foreign key (eid) references users (eid) on delete cascade on update cascade,
+    foreign key (client_id) references oauth_clients (client_id) on delete cascade on update cascade
+);
+
+
+drop table if exists api_keys;
+create table api_keys
+(
+    id           serial primary key,
+    key_id       varchar(32) unique       not null,
+    secret_hash  char(64)                 not null,
+    eid          varchar(32)              not null,
+    name         varchar(64)              not null,
+    scopes       text                     not null default '',
+    expires_at   timestamp with time zone null,
+    last_used_at timestamp with time zone null,
+    revoked_at   timestamp with time zone null,
+    created_at   timestamp with time zone not null default now(),
+    foreign key (eid) references users (eid) on delete cascade on update cascade
+);
+
+create index api_keys_eid_key on api_keys (eid);
+
+
+drop table if exists sessions;
+create table sessions
+(
+    id           serial primary key,
+    sid          varchar(32) unique       not null,
+    eid          varchar(32)              not null,
+    client_id    varchar(32)              not null default '',
+    device       varchar(64)              not null default '',
+    user_agent   varchar(255)             not null default '',
+    ip           varchar(45)              not null default '',
+    created_at   timestamp with time zone not null default now(),
+    last_seen_at timestamp with time zone not null default now(),
+    ended_at     timestamp with time zone null,
+    foreign key (eid) references users (eid) on delete cascade on update cascade
+);
+
+create index sessions_eid_key on sessions (eid);
+
+
+drop table if exists password_history;
+create table password_history
+(
+    id            serial primary key,
+    eid           varchar(32)              not null,
+    password_hash varchar(128)             not null,
+    created_at    timestamp with time zone not null default now(),
+    foreign key (eid) references users (eid) on delete cascade on update cascade
+);
+
+create index password_history_eid_key on password_history (eid);
+
+
+drop table if exists identities;
+create table identities
+(
+    id         serial primary key,
+    eid        varchar(32)              not null,
+    provider   varchar(32)              not null,
+    subject    varchar(128)             not null,
+    nickname   varchar(64)              not null default '',
+    created_at timestamp with time zone not null default now(),
+    unique (provider, subject),
+    unique (eid, provider),
+    foreign key (eid) references users (eid) on delete cascade on update cascade
+);
+
+
+drop table if exists impersonation_logs;
+create table impersonation_logs
+(
+    id           serial primary key,
+    operator_eid varchar(32)              not null,
+    eid          varchar(32)              not null,
+    jti          varchar(64)              not null,
+    method       varchar(16)              not null,
+    path         varchar(255)             not null,
+    status       smallint                 not null,
+    ip           varchar(45)              not null default '',
+    reason       varchar(255)             not null default '',
+    created_at   timestamp with time zone not null default now()
+);
+
+create index impersonation_logs_operator_eid_key on impersonation_logs (operator_eid);
+create index impersonation_logs_eid_key on impersonation_logs (eid);
+
+
+drop table if exists service_accounts;
+create table service_accounts
+(
+    id          serial primary key,
+    client_id   varchar(32) unique       not null,
+    name        varchar(64)              not null,
+    secret_hash varchar(64)              not null default '',
+    public_key  text                     not null default '',
+    owner_eid   varchar(32)              not null,
+
+    created_at  timestamp with time zone not null default now(),
+    updated_at  timestamp with time zone not null default ('now'::text)::timestamp(0) with time zone,
+    deleted_at  timestamp with time zone null
+);
+
+create index service_accounts_deleted_at_key on service_accounts (deleted_at);
+
+
+drop table if exists security_events;
+create table security_events
+(
+    id         serial primary key,
+    eid        varchar(32)              not null,
+    type       varchar(32)              not null,
+    detail     varchar(255)             not null default '',
+    ip         varchar(45)              not null default '',
+    user_agent varchar(255)             not null default '',
+    request_id varchar(64)              not null default '',
+    created_at timestamp with time zone not null default now()
+);
+
+create index security_events_eid_created_at_key on security_events (eid, created_at);
+create index security_events_created_at_key on security_events (created_at);
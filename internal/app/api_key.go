@@ -0,0 +1,52 @@
+package app
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/eachinchung/component-base/middleware/auth"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/app/service"
+	"github.com/eachinchung/e-service/internal/app/storage"
+	"github.com/eachinchung/e-service/internal/app/store"
+	"github.com/eachinchung/e-service/internal/pkg/code"
+)
+
+// apiKeyScheme 是使用 API key 时 Authorization 请求头的认证方案。
+const apiKeyScheme = "ApiKey"
+
+// userAuthentication 校验 jwt 或 API key。
+// 使用 API key 时 claims 中只有 sub 与 api_key 字段，API key 允许访问的接口由 casbin.RBACMiddleWare 校验。
+func userAuthentication(mw *jwtAuth) gin.HandlerFunc {
+	jwtMiddleware := mw.MiddlewareFunc()
+	revocation := tokenRevocation()
+
+	return func(c *gin.Context) {
+		scheme, rawKey, found := strings.Cut(c.GetHeader("Authorization"), " ")
+		if !found || scheme != apiKeyScheme {
+			if jwtMiddleware(c); !c.IsAborted() {
+				revocation(c)
+			}
+			return
+		}
+
+		srv := service.NewService(store.Client(), storage.Client())
+		key, err := srv.APIKeys().Authenticate(c, rawKey)
+		if err != nil {
+			if !errors.IsCode(err, code.ErrAPIKeyInvalid) {
+				log.L(c).Errorf("authenticate api key failed: %+v", err)
+			}
+
+			mw.unauthorized(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		c.Set("JWT_PAYLOAD", auth.MapClaims{"sub": key.EID, "api_key": key.KeyID})
+		c.Set(log.KeyEID, key.EID)
+		key.SaveToContext(c)
+	}
+}
@@ -1,22 +1,31 @@
 package app
 
 import (
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 
 	"github.com/eachinchung/component-base/core"
 	"github.com/eachinchung/component-base/db/options"
 	"github.com/eachinchung/component-base/middleware/auth"
+	"github.com/eachinchung/component-base/utils/idutil"
 	"github.com/eachinchung/component-base/verification"
 	"github.com/eachinchung/errors"
 	"github.com/eachinchung/log"
 
 	"github.com/eachinchung/e-service/internal/app/config"
+	"github.com/eachinchung/e-service/internal/app/service"
+	"github.com/eachinchung/e-service/internal/app/storage"
 	"github.com/eachinchung/e-service/internal/app/store"
 	"github.com/eachinchung/e-service/internal/app/store/model"
 	"github.com/eachinchung/e-service/internal/pkg/code"
+	"github.com/eachinchung/e-service/internal/pkg/hasher"
+	"github.com/eachinchung/e-service/internal/pkg/keyset"
+	"github.com/eachinchung/e-service/internal/pkg/validator"
 )
 
 const (
@@ -27,30 +36,45 @@ const (
 	APIServerIssuer = "e-service"
 )
 
+// 受限 token 的用途，受限 token 只能访问对应的接口。
+const (
+	restrictMFAEnroll      = "mfa_enroll"
+	restrictPasswordChange = "password_change"
+)
+
+// deviceHeader 是客户端上报设备名称的请求头，设备名称会记录在登录会话中。
+const deviceHeader = "X-Device-Name"
+
+var errTokenRevoked = errors.New("token has been revoked")
+
+// restrictionCodes 受限 token 访问其它接口时返回的错误码。
+var restrictionCodes = map[string]int{
+	restrictMFAEnroll:      code.ErrMFAEnrollRequired,
+	restrictPasswordChange: code.ErrPasswordExpired,
+}
+
 type loginInfo struct {
-	Username string `form:"username" json:"username" binding:"required,max=64"`
-	Password string `form:"password" json:"password" binding:"required,min=6,password"`
+	Username string `form:"username" json:"username" binding:"required,max=255"`
+	Password string `form:"password" json:"password" binding:"required,max=255"`
 }
 
-func newJWTAuth() *auth.GinJWTMiddleware {
-	cfg := config.GetConfigIns(nil)
+type smsLoginInfo struct {
+	Phone string `form:"phone" json:"phone" binding:"required,len=11,phone"`
+	Code  string `form:"code"  json:"code"  binding:"required,len=6,numeric"`
+}
 
-	jwtMiddleware, _ := auth.New(&auth.GinJWTMiddleware{
-		Realm:            cfg.JWTOptions.Realm,
-		SigningAlgorithm: "HS512",
-		Key:              []byte(cfg.JWTOptions.Key),
-		Timeout:          cfg.JWTOptions.Timeout,
-		MaxRefresh:       cfg.JWTOptions.MaxRefresh,
-		Authenticator:    authenticator(),
-		PayloadFunc:      payloadFunc(),
-		Unauthorized:     unauthorized(),
-		LoginResponse:    loginResponse(),
-		RefreshResponse:  refreshResponse(),
-		TokenLookup:      "header: Authorization, query: token",
-		TimeFunc:         time.Now,
-	})
+func newJWTAuth() *jwtAuth {
+	cfg := config.GetConfigIns(nil)
 
-	return jwtMiddleware
+	return &jwtAuth{
+		Realm:         cfg.JWTOptions.Realm,
+		Timeout:       cfg.JWTOptions.Timeout,
+		Authenticator: authenticator(),
+		PayloadFunc:   payloadFunc(),
+		Unauthorized:  unauthorized(),
+		TimeFunc:      time.Now,
+		keys:          keyset.Client(),
+	}
 }
 
 func authenticator() func(ctx *gin.Context) (any, error) {
@@ -69,23 +93,147 @@ func authenticator() func(ctx *gin.Context) (any, error) {
 		switch {
 		case verification.Phone(login.Username):
 			user, err = userStore.Get(c, db, login.Username, options.WithQuery("phone = ?"))
+		case verification.Email(login.Username):
+			// 只有已验证的邮箱可以用于登录
+			user, err = userStore.Get(c, db, strings.ToLower(login.Username), options.WithQuery("email = ? AND email_verified"))
 		default:
 			user, err = userStore.Get(c, db, login.Username, options.WithQuery("eid = ?"))
 		}
 
+		// 账号存在时按 eid 统计失败次数，使用手机号、邮箱或用户名登录共享同一个计数
+		username := login.Username
+		if err == nil {
+			username = user.EID
+		}
+
+		srv := service.NewService(store.Client(), storage.Client())
+		if limitErr := checkLoginLimit(c, srv, username); limitErr != nil {
+			return "", limitErr
+		}
+
 		if err != nil {
 			log.Errorf("get user information failed: %s", err.Error())
+			loginFailed(c, srv, username, "", amrPassword)
 
 			return "", auth.ErrFailedAuthentication
 		}
 
 		if err := user.ComparePasswordHash(login.Password); err != nil {
+			loginFailed(c, srv, username, user.EID, amrPassword)
+			return "", auth.ErrFailedAuthentication
+		}
+
+		// 密码哈希使用了过时的算法或参数时重新计算，失败不影响本次登录
+		if err := srv.Passwords().Rehash(c, user, login.Password); err != nil {
+			log.L(c).Errorf("rehash password failed: %+v", err)
+		}
+
+		// 已有的密码可能不符合当前的密码策略，登录不受影响，但只能签发修改密码的受限 token
+		if err := srv.Passwords().CheckCompliance(c, user, login.Password); err != nil {
+			log.L(c).Errorf("check password compliance failed: %+v", err)
 			return "", auth.ErrFailedAuthentication
 		}
+
 		return user, nil
 	}
 }
 
+// checkLoginLimit 检查账号与客户端 IP 是否已被锁定，并按照失败次数延迟本次登录。
+func checkLoginLimit(c *gin.Context, srv service.Service, username string) error {
+	// 使用请求的 context，客户端断开连接时停止等待
+	err := srv.LoginLimits().Wait(c.Request.Context(), username, c.ClientIP())
+	if err != nil && !errors.IsCode(err, code.ErrLoginLocked) {
+		log.L(c).Errorf("check login limit failed: %+v", err)
+	}
+	return err
+}
+
+// loginFailed 记录一次登录失败，method 是验证失败的认证方式。
+// 账号存在时 eid 不为空，同时记录用户的登录失败事件，账号因本次失败被锁定时记录锁定事件。
+func loginFailed(c *gin.Context, srv service.Service, username, eid, method string) {
+	var event *model.SecurityEvents
+	if eid != "" {
+		event = model.NewSecurityEvent(c, eid, model.SecurityEventLoginFailed, method)
+	}
+	srv.LoginLimits().Failed(c, username, c.ClientIP(), event)
+}
+
+// loginSucceeded 在用户完成全部认证步骤后清空账号的登录失败次数。
+func loginSucceeded(c *gin.Context, srv service.Service, eid string) {
+	if err := srv.LoginLimits().Succeed(c, eid); err != nil {
+		log.L(c).Errorf("reset login failures failed: %+v", err)
+	}
+}
+
+// smsLoginFailed 短信验证码错误时，若手机号已注册，记录用户的登录失败事件。
+func smsLoginFailed(c *gin.Context, srv service.Service, phone string) {
+	user, err := store.Client().User().Get(c, store.Client().DB(), phone, options.WithQuery("phone = ?"))
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.L(c).Errorf("get user information failed: %+v", err)
+		}
+		return
+	}
+
+	srv.SecurityEvents().Record(c, model.NewSecurityEvent(c, user.EID, model.SecurityEventLoginFailed, amrSMS))
+}
+
+// smsAuthenticator 使用短信验证码登录，手机号尚未注册时自动创建一个无密码的账号。
+func smsAuthenticator() func(ctx *gin.Context) (any, error) {
+	return func(c *gin.Context) (any, error) {
+		var login smsLoginInfo
+		if err := c.ShouldBindJSON(&login); err != nil {
+			return "", auth.ErrMissingLoginValues
+		}
+
+		srv := service.NewService(store.Client(), storage.Client())
+		if err := srv.Verifications().VerifySMSCode(c, service.SceneLogin, login.Phone, login.Code); err != nil {
+			smsLoginFailed(c, srv, login.Phone)
+			return "", err
+		}
+
+		db := store.Client().DB()
+		user, err := store.Client().User().Get(c, db, login.Phone, options.WithQuery("phone = ?"))
+		switch {
+		case err == nil:
+			return user, nil
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			log.Errorf("get user information failed: %s", err.Error())
+			return "", auth.ErrFailedAuthentication
+		}
+
+		return registerByPhone(c, srv, login.Phone)
+	}
+}
+
+// registerByPhone 为已验证的手机号创建账号，账号使用随机密码，用户需要通过验证码登录或重置密码。
+func registerByPhone(c *gin.Context, srv service.Service, phone string) (*model.Users, error) {
+	pwdHash, err := hasher.Client().Hash(idutil.GenSecretKey())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to hash password")
+	}
+
+	user := &model.Users{
+		Phone:        phone,
+		EID:          idutil.GetInstanceID(idutil.GenUint64ID(), "eid"),
+		Nickname:     "用户" + phone[len(phone)-4:],
+		PasswordHash: pwdHash,
+	}
+
+	if err := srv.Users().Create(c, user); err != nil {
+		if errors.IsCode(err, code.ErrPhoneAlreadyExist) {
+			// 并发登录时账号可能已被另一个请求创建
+			return store.Client().User().Get(c, store.Client().DB(), phone, options.WithQuery("phone = ?"))
+		}
+
+		log.Errorf("create user error: %+v", err)
+		return nil, err
+	}
+
+	log.L(c).Infof("user %s registered by sms code", user.EID)
+	return user, nil
+}
+
 func parseWithBody(c *gin.Context) (loginInfo, error) {
 	var login loginInfo
 	if err := c.ShouldBindJSON(&login); err != nil {
@@ -95,32 +243,443 @@ func parseWithBody(c *gin.Context) (loginInfo, error) {
 	return login, nil
 }
 
-func refreshResponse() func(c *gin.Context, _ int, token string, expire time.Time) {
-	return func(c *gin.Context, _ int, token string, expire time.Time) {
+// tokenPair 是登录或刷新成功后签发给客户端的 token。
+type tokenPair struct {
+	Token         string
+	Expire        time.Time
+	RefreshToken  string
+	RefreshExpire time.Time
+}
+
+type refreshInfo struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+func refreshResponse() func(c *gin.Context, tokens *tokenPair) {
+	return func(c *gin.Context, tokens *tokenPair) {
 		c.JSON(http.StatusOK, gin.H{
-			"token":  token,
-			"expire": expire.Format(time.RFC3339),
+			"token":          tokens.Token,
+			"expire":         tokens.Expire.Format(time.RFC3339),
+			"refresh_token":  tokens.RefreshToken,
+			"refresh_expire": tokens.RefreshExpire.Format(time.RFC3339),
 		})
 	}
 }
 
-func loginResponse() func(c *gin.Context, _ int, token string, expire time.Time) {
-	return func(c *gin.Context, _ int, token string, expire time.Time) {
+func loginResponse() func(c *gin.Context, tokens *tokenPair) {
+	return func(c *gin.Context, tokens *tokenPair) {
 		c.JSON(http.StatusOK, gin.H{
-			"token":  token,
-			"expire": expire.Format(time.RFC3339),
+			"token":          tokens.Token,
+			"expire":         tokens.Expire.Format(time.RFC3339),
+			"refresh_token":  tokens.RefreshToken,
+			"refresh_expire": tokens.RefreshExpire.Format(time.RFC3339),
 		})
 	}
 }
 
+// mfaLoginInfo 是完成两步验证时提交的信息。
+type mfaLoginInfo struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code"      binding:"required,min=6,max=16"`
+}
+
+// mfaChallengeResponse 返回两步验证挑战，客户端需要使用它调用 POST /auth/token/mfa 完成登录。
+func mfaChallengeResponse(c *gin.Context, challenge string, expire time.Time) {
+	c.JSON(http.StatusOK, gin.H{
+		"mfa_required": true,
+		"mfa_token":    challenge,
+		"mfa_expire":   expire.Format(time.RFC3339),
+	})
+}
+
+// restrictedResponse 返回受限 token，受限 token 只能访问 restrict 允许的接口，并且不能刷新。
+func restrictedResponse(c *gin.Context, token string, expire time.Time, restrict string) {
+	c.JSON(http.StatusOK, gin.H{
+		"token":    token,
+		"expire":   expire.Format(time.RFC3339),
+		"restrict": restrict,
+	})
+}
+
+// loginHandler 使用 authenticator 验证用户身份，amr 是 authenticator 使用的认证方式。
+// 用户启用了两步验证时返回两步验证挑战；被强制要求启用两步验证但尚未启用时返回只能用于绑定的受限 token；
+// 否则签发 access token 与一个新令牌族的 refresh token。
+func loginHandler(mw *jwtAuth, authenticator func(c *gin.Context) (any, error), amr ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		data, err := authenticator(c)
+		if err != nil {
+			mw.Unauthorized(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		user := data.(*model.Users)
+		if rejectAbnormalUser(c, user) {
+			return
+		}
+
+		srv := service.NewService(store.Client(), storage.Client())
+
+		enabled, err := srv.MFA().Enabled(c, user.EID)
+		if err != nil {
+			log.L(c).Errorf("get mfa status failed: %+v", err)
+			core.WriteResponse(c, nil, core.WithError(err))
+			return
+		}
+
+		if enabled {
+			challenge, expire, err := srv.MFA().CreateChallenge(c, user.EID, amr)
+			if err != nil {
+				log.L(c).Errorf("create mfa challenge failed: %+v", err)
+				mw.Unauthorized(c, http.StatusUnauthorized, auth.ErrFailedTokenCreation)
+				return
+			}
+
+			mfaChallengeResponse(c, challenge, expire)
+			return
+		}
+
+		// 启用两步验证的用户在完成挑战后才清空失败次数，避免猜测动态验证码时通过重新登录重置失败次数
+		loginSucceeded(c, srv, user.EID)
+
+		required, err := srv.MFA().EnrollRequired(c, user.EID)
+		if err != nil {
+			log.L(c).Errorf("check mfa enrollment failed: %+v", err)
+			core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrUnknown, err.Error())))
+			return
+		}
+
+		if required {
+			restrictedLogin(c, mw, user, restrictMFAEnroll)
+			return
+		}
+
+		issueTokens(c, mw, srv, user, amr)
+	}
+}
+
+// mfaLoginHandler 使用动态验证码或恢复码完成两步验证挑战，并签发 token。
+func mfaLoginHandler(mw *jwtAuth) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body mfaLoginInfo
+		if err := c.ShouldBindJSON(&body); err != nil {
+			core.WriteResponse(
+				c,
+				validator.ParseValidationError(err),
+				core.WithError(errors.Code(code.ErrValidation, err.Error())),
+			)
+			return
+		}
+
+		srv := service.NewService(store.Client(), storage.Client())
+		eid, err := srv.MFA().ChallengeEID(c, body.MFAToken)
+		if err != nil {
+			if !errors.IsCode(err, code.ErrMFAChallengeInvalid) {
+				log.L(c).Errorf("get mfa challenge failed: %+v", err)
+			}
+			core.WriteResponse(c, nil, core.WithError(err))
+			return
+		}
+
+		// 两步验证的失败次数与密码登录共享同一个限制
+		if err := checkLoginLimit(c, srv, eid); err != nil {
+			core.WriteResponse(c, nil, core.WithError(err))
+			return
+		}
+
+		eid, amr, err := srv.MFA().CompleteChallenge(c, body.MFAToken, body.Code)
+		if err != nil {
+			if !errors.IsCode(err, code.ErrMFAChallengeInvalid) && !errors.IsCode(err, code.ErrMFACodeIncorrect) {
+				log.L(c).Errorf("complete mfa challenge failed: %+v", err)
+			}
+
+			if eid != "" && errors.IsCode(err, code.ErrMFACodeIncorrect) {
+				loginFailed(c, srv, eid, eid, amrOTP)
+			}
+
+			core.WriteResponse(c, nil, core.WithError(err))
+			return
+		}
+
+		loginSucceeded(c, srv, eid)
+
+		user, err := srv.Users().GetByEID(c, eid)
+		if err != nil {
+			log.L(c).Errorf("get user information failed: %+v", err)
+			core.WriteResponse(c, nil, core.WithError(err))
+			return
+		}
+
+		if rejectAbnormalUser(c, user) {
+			return
+		}
+
+		issueTokens(c, mw, srv, user, append(amr, amrOTP, amrMFA))
+	}
+}
+
+// restrictedLogin 签发只能访问 restrict 允许的接口的受限 token，受限 token 不能刷新。
+func restrictedLogin(c *gin.Context, mw *jwtAuth, user *model.Users, restrict string) {
+	claims := mw.PayloadFunc(user)
+	claims["restrict"] = restrict
+
+	token, expire, err := mw.SignToken(claims)
+	if err != nil {
+		mw.Unauthorized(c, http.StatusUnauthorized, auth.ErrFailedTokenCreation)
+		return
+	}
+
+	restrictedResponse(c, token, expire, restrict)
+}
+
+// rejectAbnormalUser 拒绝为状态异常的用户签发 token，返回 true 时已经写入响应。
+func rejectAbnormalUser(c *gin.Context, user *model.Users) bool {
+	if user.State == model.StatusNormal {
+		return false
+	}
+
+	core.WriteResponse(
+		c,
+		nil,
+		core.WithError(errors.Code(code.ErrUserStatusIsAbnormal, "user status is abnormal")),
+		core.WithMessage(fmt.Sprintf("用户已被%s", user.State.Msg())),
+	)
+	return true
+}
+
+// issueTokens 签发 access token 与一个新令牌族的 refresh token，并以令牌族 ID 作为会话 ID 记录本次登录的会话与登录成功事件。
+// amr 是用户本次登录使用的认证方式，与登录时间一起写入 token，用于敏感操作前检查是否需要重新验证身份。
+// 密码已经超过最长使用时间时只签发用于修改密码的受限 token。
+func issueTokens(c *gin.Context, mw *jwtAuth, srv service.Service, user *model.Users, amr []string) {
+	issueTokensWith(c, mw, srv, user, amr, loginResponse())
+}
+
+// issueTokensWith 与 issueTokens 相同，但使用 response 返回签发的 token。
+func issueTokensWith(
+	c *gin.Context,
+	mw *jwtAuth,
+	srv service.Service,
+	user *model.Users,
+	amr []string,
+	response func(c *gin.Context, tokens *tokenPair),
+) {
+	changeRequired, err := srv.Passwords().ChangeRequired(c, user)
+	if err != nil {
+		log.L(c).Errorf("check password change required failed: %+v", err)
+		mw.Unauthorized(c, http.StatusUnauthorized, auth.ErrFailedTokenCreation)
+		return
+	}
+	if changeRequired {
+		restrictedLogin(c, mw, user, restrictPasswordChange)
+		return
+	}
+
+	fid := idutil.GenSecretID()
+	record := &service.RefreshToken{
+		EID:      user.EID,
+		FamilyID: fid,
+		AuthTime: time.Now().Unix(),
+		AMR:      strings.Join(amr, " "),
+	}
+
+	token, expire, err := signToken(mw, user, record)
+	if err != nil {
+		mw.Unauthorized(c, http.StatusUnauthorized, auth.ErrFailedTokenCreation)
+		return
+	}
+
+	refreshToken, refreshExpire, err := srv.Tokens().CreateRefreshToken(c, record)
+	if err != nil {
+		log.L(c).Errorf("create refresh token failed: %+v", err)
+		mw.Unauthorized(c, http.StatusUnauthorized, auth.ErrFailedTokenCreation)
+		return
+	}
+
+	if err := createSession(c, srv, user.EID, fid, ""); err != nil {
+		log.L(c).Errorf("create session failed: %+v", err)
+		mw.Unauthorized(c, http.StatusUnauthorized, auth.ErrFailedTokenCreation)
+		return
+	}
+
+	srv.SecurityEvents().Record(c, model.NewSecurityEvent(c, user.EID, model.SecurityEventLoginSucceeded, record.AMR))
+
+	response(c, &tokenPair{
+		Token:         token,
+		Expire:        expire,
+		RefreshToken:  refreshToken,
+		RefreshExpire: refreshExpire,
+	})
+}
+
+// createSession 记录一次登录的会话，设备名称由客户端通过 X-Device-Name 请求头提供。
+func createSession(c *gin.Context, srv service.Service, eid, fid, clientID string) error {
+	return srv.Sessions().Create(c, &model.Sessions{
+		SID:       fid,
+		EID:       eid,
+		ClientID:  clientID,
+		Device:    model.Truncate(c.GetHeader(deviceHeader), 64),
+		UserAgent: model.Truncate(c.Request.UserAgent(), 255),
+		IP:        c.ClientIP(),
+	})
+}
+
+// refreshHandler 使用 refresh token 换取新的 access token，refresh token 每次使用后都会轮换。
+func refreshHandler(mw *jwtAuth) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body refreshInfo
+		if err := c.ShouldBindJSON(&body); err != nil {
+			core.WriteResponse(
+				c,
+				validator.ParseValidationError(err),
+				core.WithError(errors.Code(code.ErrValidation, err.Error())),
+			)
+			return
+		}
+
+		srv := service.NewService(store.Client(), storage.Client())
+		record, refreshToken, refreshExpire, err := srv.Tokens().RotateRefreshToken(c, body.RefreshToken, "")
+		if err != nil {
+			if !errors.IsCode(err, code.ErrRefreshTokenInvalid) && !errors.IsCode(err, code.ErrRefreshTokenReused) {
+				log.L(c).Errorf("rotate refresh token failed: %+v", err)
+				err = errors.Code(code.ErrUnknown, err.Error())
+			}
+
+			core.WriteResponse(c, nil, core.WithError(err))
+			return
+		}
+
+		srv.Sessions().Touch(c, record.FamilyID)
+
+		user, err := srv.Users().GetByEID(c, record.EID)
+		if err != nil {
+			log.L(c).Errorf("get user information failed: %+v", err)
+			core.WriteResponse(c, nil, core.WithError(err))
+			return
+		}
+
+		if rejectAbnormalUser(c, user) {
+			return
+		}
+
+		srv.SecurityEvents().Record(c, model.NewSecurityEvent(c, user.EID, model.SecurityEventTokenRefreshed, ""))
+
+		token, expire, err := signToken(mw, user, record)
+		if err != nil {
+			mw.Unauthorized(c, http.StatusUnauthorized, auth.ErrFailedTokenCreation)
+			return
+		}
+
+		refreshResponse()(c, &tokenPair{
+			Token:         token,
+			Expire:        expire,
+			RefreshToken:  refreshToken,
+			RefreshExpire: refreshExpire,
+		})
+	}
+}
+
+// signToken 为用户签发属于 record 令牌族的 access token，并写入用户完成身份验证的时间与认证方式。
+func signToken(mw *jwtAuth, user *model.Users, record *service.RefreshToken) (string, time.Time, error) {
+	claims := mw.PayloadFunc(user)
+	claims["fid"] = record.FamilyID
+	if record.AuthTime > 0 {
+		claims["auth_time"] = record.AuthTime
+		claims["amr"] = strings.Fields(record.AMR)
+	}
+
+	return mw.SignToken(claims)
+}
+
+// logoutHandler 吊销当前请求携带的 token 以及它所属令牌族的 refresh token，并结束对应的会话。
+func logoutHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := auth.ExtractClaimsFromContext(c)
+		srv := service.NewService(store.Client(), storage.Client())
+		if err := srv.Tokens().Revoke(c, claims); err != nil {
+			log.L(c).Errorf("revoke token failed: %+v", err)
+			core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrUnknown, err.Error())))
+			return
+		}
+
+		if fid, ok := claims["fid"].(string); ok {
+			eid, _ := claims["sub"].(string)
+			if err := srv.Sessions().End(c, eid, fid); err != nil && !errors.IsCode(err, code.ErrSessionNotExist) {
+				log.L(c).Errorf("end session failed: %+v", err)
+				core.WriteResponse(c, nil, core.WithError(err))
+				return
+			}
+
+			// 会话记录不存在时仍然需要吊销令牌族
+			if err := srv.Tokens().RevokeFamily(c, fid); err != nil {
+				log.L(c).Errorf("revoke token family failed: %+v", err)
+				core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrUnknown, err.Error())))
+				return
+			}
+		}
+
+		core.WriteResponse(c, nil, core.WithHttpStatus(http.StatusNoContent))
+	}
+}
+
+// tokenRevocation 拒绝已被吊销的 token 并更新会话的最近活跃时间，需要放在 jwt 中间件之后、casbin.RBACMiddleWare 之前。
+func tokenRevocation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		srv := service.NewService(store.Client(), storage.Client())
+		claims := auth.ExtractClaimsFromContext(c)
+		revoked, err := srv.Tokens().IsRevoked(c, claims)
+		if err != nil {
+			log.L(c).Errorf("check token revocation failed: %+v", err)
+			core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrUnknown, err.Error())), core.WithAbort())
+			return
+		}
+
+		if revoked {
+			unauthorized()(c, http.StatusUnauthorized, errTokenRevoked)
+			return
+		}
+
+		if fid, ok := claims["fid"].(string); ok {
+			srv.Sessions().Touch(c, fid)
+		}
+	}
+}
+
+// tokenRestriction 拒绝受限 token 访问 allowed 以外的接口。
+func tokenRestriction(allowed ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		restrict, ok := auth.ExtractClaimsFromContext(c)["restrict"].(string)
+		if !ok || restrict == "" {
+			return
+		}
+
+		for _, a := range allowed {
+			if a == restrict {
+				return
+			}
+		}
+
+		errCode, ok := restrictionCodes[restrict]
+		if !ok {
+			errCode = code.ErrPermissionDenied
+		}
+
+		core.WriteResponse(c, nil, core.WithError(errors.Code(errCode, "token is restricted to "+restrict)), core.WithAbort())
+	}
+}
+
 func payloadFunc() func(data any) auth.MapClaims {
 	return func(data any) auth.MapClaims {
+		now := time.Now()
 		claims := auth.MapClaims{
 			"iss": APIServerIssuer,
 			"aud": APIServerAudience,
+			"jti": idutil.GenSecretID(),
+			"iat": now.Unix(),
+			// iat 只精确到秒，iat_ms 用于判断 token 是否签发于用户最近一次全部吊销之前
+			"iat_ms": now.UnixMilli(),
 		}
 		if u, ok := data.(*model.Users); ok {
 			claims["sub"] = u.EID
+			claims["cv"] = u.CredentialVersion
 		}
 
 		return claims
@@ -146,10 +705,17 @@ func unauthorized() func(c *gin.Context, _ int, err error) {
 			errCode = code.ErrInvalidSigningAlgorithm
 		case auth.ErrFailedAuthentication:
 			errCode = code.ErrFailedAuthentication
+		case errTokenInvalid:
+			errCode = code.ErrTokenInvalid
+		case errTokenRevoked:
+			errCode = code.ErrTokenRevoked
 		case auth.ErrMissingLoginValues:
 			errCode = code.ErrValidation
 		case auth.ErrEmptyAuthHeader, auth.ErrEmptyParamToken, auth.ErrEmptyQueryToken:
 			errCode = code.ErrEmptyToken
+		default:
+			core.WriteResponse(c, nil, core.WithError(err), core.WithAbort())
+			return
 		}
 
 		core.WriteResponse(c, nil, core.WithError(errors.Code(errCode, err.Error())), core.WithAbort())
@@ -0,0 +1,29 @@
+package apikey
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/eachinchung/component-base/middleware/auth"
+
+	"github.com/eachinchung/e-service/internal/app/service"
+	"github.com/eachinchung/e-service/internal/app/storage"
+	"github.com/eachinchung/e-service/internal/app/store"
+)
+
+// Controller create an api key handler used to handle request for api key resource.
+type Controller struct {
+	srv service.Service
+}
+
+// NewController creates an api key handler.
+func NewController(store store.Store, storage storage.Storage) *Controller {
+	return &Controller{
+		srv: service.NewService(store, storage),
+	}
+}
+
+// currentEID 返回当前 token 所属的用户名。
+func currentEID(c *gin.Context) string {
+	eid, _ := auth.ExtractClaimsFromContext(c)["sub"].(string)
+	return eid
+}
@@ -0,0 +1,62 @@
+package apikey
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/eachinchung/component-base/core"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/app/store/model"
+	"github.com/eachinchung/e-service/internal/pkg/code"
+	"github.com/eachinchung/e-service/internal/pkg/validator"
+)
+
+type scopeBody struct {
+	Obj string `json:"obj" binding:"required,startswith=/,max=128,excludesall=0x20"` // 路径，支持 keyMatch2 格式
+	Act string `json:"act" binding:"required,max=64,excludesall=0x20,regexp"`        // 请求方法，支持正则表达式
+}
+
+type createBody struct {
+	Name      string      `json:"name"       binding:"required,min=1,max=64"`      // 名称
+	Scopes    []scopeBody `json:"scopes"     binding:"required,min=1,max=20,dive"` // 允许访问的接口
+	ExpiresIn int64       `json:"expires_in" binding:"omitempty,min=60"`           // 有效期，单位为秒，为空时使用允许的最长有效期
+}
+
+// Create create an api key for the current user, the key is only returned once.
+func (a *Controller) Create(c *gin.Context) {
+	body := &createBody{}
+	if err := c.ShouldBindJSON(body); err != nil {
+		core.WriteResponse(
+			c,
+			validator.ParseValidationError(err),
+			core.WithError(errors.Code(code.ErrValidation, err.Error())),
+		)
+		return
+	}
+
+	scopes := make([]model.APIKeyScope, 0, len(body.Scopes))
+	for _, scope := range body.Scopes {
+		scopes = append(scopes, model.APIKeyScope{Obj: scope.Obj, Act: scope.Act})
+	}
+
+	key := &model.APIKeys{EID: currentEID(c), Name: body.Name}
+	key.SetScopes(scopes)
+
+	secret, err := a.srv.APIKeys().Create(c, key, time.Duration(body.ExpiresIn)*time.Second)
+	if err != nil {
+		if !errors.IsCode(err, code.ErrAPIKeyLimitExceeded) {
+			log.Errorf("create api key error: %+v", err)
+		}
+
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	rsp := key.Response()
+	rsp["key"] = secret
+	core.WriteResponse(c, rsp, core.WithHttpStatus(http.StatusCreated))
+}
@@ -0,0 +1,24 @@
+package apikey
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/eachinchung/component-base/core"
+	"github.com/eachinchung/log"
+)
+
+// List list the api keys of the current user, the secrets are never returned.
+func (a *Controller) List(c *gin.Context) {
+	keys, err := a.srv.APIKeys().List(c, currentEID(c))
+	if err != nil {
+		log.Errorf("list api keys error: %+v", err)
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	rsp := make([]map[string]any, 0, len(keys))
+	for _, key := range keys {
+		rsp = append(rsp, key.Response())
+	}
+	core.WriteResponse(c, rsp)
+}
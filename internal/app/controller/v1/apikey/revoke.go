@@ -0,0 +1,42 @@
+package apikey
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/eachinchung/component-base/core"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/pkg/code"
+	"github.com/eachinchung/e-service/internal/pkg/validator"
+)
+
+type keyUri struct {
+	KeyID string `uri:"key_id" binding:"required"`
+}
+
+// Revoke revoke an api key of the current user, it stops working immediately.
+func (a *Controller) Revoke(c *gin.Context) {
+	uri := &keyUri{}
+	if err := c.ShouldBindUri(uri); err != nil {
+		core.WriteResponse(
+			c,
+			validator.ParseValidationError(err),
+			core.WithError(errors.Code(code.ErrValidation, err.Error())),
+		)
+		return
+	}
+
+	if err := a.srv.APIKeys().Revoke(c, currentEID(c), uri.KeyID); err != nil {
+		if !errors.IsCode(err, code.ErrAPIKeyNotExist) {
+			log.Errorf("revoke api key error: %+v", err)
+		}
+
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	core.WriteResponse(c, nil, core.WithHttpStatus(http.StatusNoContent))
+}
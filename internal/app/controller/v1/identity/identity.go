@@ -0,0 +1,66 @@
+package identity
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/eachinchung/component-base/core"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/app/service"
+	"github.com/eachinchung/e-service/internal/app/storage"
+	"github.com/eachinchung/e-service/internal/app/store"
+	"github.com/eachinchung/e-service/internal/pkg/code"
+	"github.com/eachinchung/e-service/internal/pkg/idp"
+	"github.com/eachinchung/e-service/internal/pkg/validator"
+)
+
+// Controller create an identity handler used to handle request for third-party identity resource.
+type Controller struct {
+	srv service.Service
+}
+
+// NewController creates an identity handler.
+func NewController(store store.Store, storage storage.Storage) *Controller {
+	return &Controller{
+		srv: service.NewService(store, storage),
+	}
+}
+
+type providerUri struct {
+	Provider string `uri:"provider" binding:"required,max=32"`
+}
+
+// Providers list the enabled identity providers.
+func (i *Controller) Providers(c *gin.Context) {
+	core.WriteResponse(c, gin.H{"providers": idp.Client().Names()})
+}
+
+// AuthorizeURL return the authorization url of the identity provider used to login.
+func (i *Controller) AuthorizeURL(c *gin.Context) {
+	i.authorizeURL(c, "")
+}
+
+func (i *Controller) authorizeURL(c *gin.Context, eid string) {
+	uri := &providerUri{}
+	if err := c.ShouldBindUri(uri); err != nil {
+		core.WriteResponse(
+			c,
+			validator.ParseValidationError(err),
+			core.WithError(errors.Code(code.ErrValidation, err.Error())),
+		)
+		return
+	}
+
+	url, err := i.srv.Identities().AuthCodeURL(c, uri.Provider, eid)
+	if err != nil {
+		if !errors.IsCode(err, code.ErrIdentityProviderNotExist) {
+			log.Errorf("get idp authorize url error: %+v", err)
+		}
+
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	core.WriteResponse(c, gin.H{"url": url})
+}
@@ -0,0 +1,104 @@
+package identity
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/eachinchung/component-base/core"
+	"github.com/eachinchung/component-base/middleware/auth"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/pkg/code"
+	"github.com/eachinchung/e-service/internal/pkg/validator"
+)
+
+type linkBody struct {
+	Code  string `json:"code"  binding:"required,max=512"`
+	State string `json:"state" binding:"required,max=128"`
+}
+
+// LinkURL return the authorization url of the identity provider used to link a third-party identity to the current user.
+func (i *Controller) LinkURL(c *gin.Context) {
+	eid, _ := auth.ExtractClaimsFromContext(c)["sub"].(string)
+	i.authorizeURL(c, eid)
+}
+
+// Link link the third-party identity authorized by the code to the current user.
+func (i *Controller) Link(c *gin.Context) {
+	uri := &providerUri{}
+	if err := c.ShouldBindUri(uri); err != nil {
+		core.WriteResponse(
+			c,
+			validator.ParseValidationError(err),
+			core.WithError(errors.Code(code.ErrValidation, err.Error())),
+		)
+		return
+	}
+
+	var body linkBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		core.WriteResponse(
+			c,
+			validator.ParseValidationError(err),
+			core.WithError(errors.Code(code.ErrValidation, err.Error())),
+		)
+		return
+	}
+
+	eid, _ := auth.ExtractClaimsFromContext(c)["sub"].(string)
+	if err := i.srv.Identities().Link(c, eid, uri.Provider, body.Code, body.State); err != nil {
+		if !errors.IsCode(err, code.ErrIdentityProviderNotExist) &&
+			!errors.IsCode(err, code.ErrIdentityStateInvalid) &&
+			!errors.IsCode(err, code.ErrIdentityExchangeFailed) &&
+			!errors.IsCode(err, code.ErrIdentityAlreadyLinked) &&
+			!errors.IsCode(err, code.ErrIdentityProviderAlreadyLinked) {
+			log.Errorf("link identity error: %+v", err)
+		}
+
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	core.WriteResponse(c, nil, core.WithHttpStatus(http.StatusNoContent))
+}
+
+// List list the third-party identities linked to the current user.
+func (i *Controller) List(c *gin.Context) {
+	eid, _ := auth.ExtractClaimsFromContext(c)["sub"].(string)
+
+	identities, err := i.srv.Identities().List(c, eid)
+	if err != nil {
+		log.Errorf("list identities error: %+v", err)
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	core.WriteResponse(c, identities)
+}
+
+// Unlink unlink the third-party identity of the provider from the current user.
+func (i *Controller) Unlink(c *gin.Context) {
+	uri := &providerUri{}
+	if err := c.ShouldBindUri(uri); err != nil {
+		core.WriteResponse(
+			c,
+			validator.ParseValidationError(err),
+			core.WithError(errors.Code(code.ErrValidation, err.Error())),
+		)
+		return
+	}
+
+	eid, _ := auth.ExtractClaimsFromContext(c)["sub"].(string)
+	if err := i.srv.Identities().Unlink(c, eid, uri.Provider); err != nil {
+		if !errors.IsCode(err, code.ErrIdentityNotExist) {
+			log.Errorf("unlink identity error: %+v", err)
+		}
+
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	core.WriteResponse(c, nil, core.WithHttpStatus(http.StatusNoContent))
+}
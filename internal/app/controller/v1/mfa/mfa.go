@@ -0,0 +1,63 @@
+package mfa
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/eachinchung/component-base/middleware/auth"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/app/service"
+	"github.com/eachinchung/e-service/internal/app/storage"
+	"github.com/eachinchung/e-service/internal/app/store"
+	"github.com/eachinchung/e-service/internal/app/store/model"
+	"github.com/eachinchung/e-service/internal/pkg/code"
+)
+
+// Controller create a mfa handler used to handle request for two-factor authentication.
+type Controller struct {
+	srv service.Service
+}
+
+// NewController creates a mfa handler.
+func NewController(store store.Store, storage storage.Storage) *Controller {
+	return &Controller{
+		srv: service.NewService(store, storage),
+	}
+}
+
+type codeBody struct {
+	Code string `json:"code" binding:"required,min=6,max=16"` // 动态验证码或恢复码
+}
+
+// currentEID 返回当前 token 所属的用户名。
+func currentEID(c *gin.Context) string {
+	eid, _ := auth.ExtractClaimsFromContext(c)["sub"].(string)
+	return eid
+}
+
+// isExpected 判断错误是否为用户操作导致的预期错误，预期错误不需要记录日志。
+func isExpected(err error) bool {
+	return errors.IsCode(err, code.ErrMFACodeIncorrect) ||
+		errors.IsCode(err, code.ErrMFAAlreadyEnabled) ||
+		errors.IsCode(err, code.ErrMFANotEnabled)
+}
+
+// checkAttempts 检查当前用户与客户端 IP 是否因验证失败次数过多被锁定，与登录共享同一个失败次数限制。
+func (m *Controller) checkAttempts(c *gin.Context) error {
+	err := m.srv.LoginLimits().Wait(c.Request.Context(), currentEID(c), c.ClientIP())
+	if err != nil && !errors.IsCode(err, code.ErrLoginLocked) {
+		log.Errorf("check login limit error: %+v", err)
+	}
+	return err
+}
+
+// verifyFailed 在动态验证码或恢复码错误时，通过登录限制记录一次失败，失败次数达到阈值时锁定账号。
+func (m *Controller) verifyFailed(c *gin.Context, err error) {
+	if !errors.IsCode(err, code.ErrMFACodeIncorrect) {
+		return
+	}
+
+	eid := currentEID(c)
+	m.srv.LoginLimits().Failed(c, eid, c.ClientIP(), model.NewSecurityEvent(c, eid, model.SecurityEventLoginFailed, "otp"))
+}
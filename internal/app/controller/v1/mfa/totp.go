@@ -0,0 +1,131 @@
+package mfa
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/eachinchung/component-base/core"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/app/store/model"
+	"github.com/eachinchung/e-service/internal/pkg/code"
+	"github.com/eachinchung/e-service/internal/pkg/validator"
+)
+
+// Status get whether two-factor authentication is enabled for the current user.
+func (m *Controller) Status(c *gin.Context) {
+	enabled, err := m.srv.MFA().Enabled(c, currentEID(c))
+	if err != nil {
+		log.Errorf("get mfa status error: %+v", err)
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	core.WriteResponse(c, gin.H{"enabled": enabled})
+}
+
+// Enroll generate a new TOTP secret, it takes effect after confirmed with the first code.
+func (m *Controller) Enroll(c *gin.Context) {
+	secret, uri, err := m.srv.MFA().Enroll(c, currentEID(c))
+	if err != nil {
+		if !isExpected(err) {
+			log.Errorf("enroll totp error: %+v", err)
+		}
+
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	core.WriteResponse(c, gin.H{"secret": secret, "uri": uri})
+}
+
+// Confirm enable two-factor authentication with the first TOTP code and return the recovery codes.
+func (m *Controller) Confirm(c *gin.Context) {
+	body := &codeBody{}
+	if err := c.ShouldBindJSON(body); err != nil {
+		core.WriteResponse(
+			c,
+			validator.ParseValidationError(err),
+			core.WithError(errors.Code(code.ErrValidation, err.Error())),
+		)
+		return
+	}
+
+	codes, err := m.srv.MFA().Confirm(c, currentEID(c), body.Code)
+	if err != nil {
+		if !isExpected(err) {
+			log.Errorf("confirm totp error: %+v", err)
+		}
+
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	m.srv.SecurityEvents().Record(c, model.NewSecurityEvent(c, currentEID(c), model.SecurityEventMFAEnabled, ""))
+	core.WriteResponse(c, gin.H{"recovery_codes": codes})
+}
+
+// Disable turn off two-factor authentication, a TOTP code or a recovery code is required.
+func (m *Controller) Disable(c *gin.Context) {
+	body := &codeBody{}
+	if err := c.ShouldBindJSON(body); err != nil {
+		core.WriteResponse(
+			c,
+			validator.ParseValidationError(err),
+			core.WithError(errors.Code(code.ErrValidation, err.Error())),
+		)
+		return
+	}
+
+	if err := m.checkAttempts(c); err != nil {
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	if err := m.srv.MFA().Disable(c, currentEID(c), body.Code); err != nil {
+		if !isExpected(err) {
+			log.Errorf("disable totp error: %+v", err)
+		}
+
+		m.verifyFailed(c, err)
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	m.srv.SecurityEvents().Record(c, model.NewSecurityEvent(c, currentEID(c), model.SecurityEventMFADisabled, ""))
+	core.WriteResponse(c, nil, core.WithHttpStatus(http.StatusNoContent))
+}
+
+// RegenerateRecoveryCodes replace all recovery codes of the current user.
+func (m *Controller) RegenerateRecoveryCodes(c *gin.Context) {
+	body := &codeBody{}
+	if err := c.ShouldBindJSON(body); err != nil {
+		core.WriteResponse(
+			c,
+			validator.ParseValidationError(err),
+			core.WithError(errors.Code(code.ErrValidation, err.Error())),
+		)
+		return
+	}
+
+	if err := m.checkAttempts(c); err != nil {
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	codes, err := m.srv.MFA().RegenerateRecoveryCodes(c, currentEID(c), body.Code)
+	if err != nil {
+		if !isExpected(err) {
+			log.Errorf("regenerate recovery codes error: %+v", err)
+		}
+
+		m.verifyFailed(c, err)
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	m.srv.SecurityEvents().Record(c, model.NewSecurityEvent(c, currentEID(c), model.SecurityEventRecoveryCodesRegenerated, ""))
+	core.WriteResponse(c, gin.H{"recovery_codes": codes})
+}
@@ -0,0 +1,132 @@
+package oauth
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/eachinchung/component-base/core"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/app/store/model"
+	"github.com/eachinchung/e-service/internal/pkg/casbin"
+	"github.com/eachinchung/e-service/internal/pkg/code"
+	"github.com/eachinchung/e-service/internal/pkg/validator"
+)
+
+type createClientBody struct {
+	Name         string   `json:"name"          binding:"required,min=1,max=64"`                   // 应用名称
+	RedirectURIs []string `json:"redirect_uris" binding:"required,min=1,max=10,dive,redirect_uri"` // 回调地址
+	Scopes       []string `json:"scopes"        binding:"required,min=1,dive,required"`            // 允许申请的权限
+	Confidential bool     `json:"confidential"`                                                    // 是否为持有密钥的机密应用
+}
+
+type clientUri struct {
+	ClientID string `uri:"client_id" binding:"required"`
+}
+
+// CreateClient register an oauth client, the secret of a confidential client is only returned once.
+func (o *Controller) CreateClient(c *gin.Context) {
+	body := &createClientBody{}
+	if err := c.ShouldBindJSON(body); err != nil {
+		core.WriteResponse(
+			c,
+			validator.ParseValidationError(err),
+			core.WithError(errors.Code(code.ErrValidation, err.Error())),
+		)
+		return
+	}
+
+	client := &model.OAuthClients{
+		Name:         body.Name,
+		RedirectURIs: strings.Join(body.RedirectURIs, " "),
+		Scopes:       strings.Join(body.Scopes, " "),
+		OwnerEID:     model.ExtractUsersFromContext(c).EID,
+	}
+
+	secret, err := o.srv.OAuth().CreateClient(c, client, body.Confidential)
+	if err != nil {
+		if !errors.IsCode(err, code.ErrOAuthInvalidScope) {
+			log.Errorf("create oauth client error: %+v", err)
+		}
+
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	rsp := client.Response()
+	if secret != "" {
+		rsp["client_secret"] = secret
+	}
+	core.WriteResponse(c, rsp, core.WithHttpStatus(http.StatusCreated))
+}
+
+// GetClient get an oauth client, only the owner or an administrator can do it.
+func (o *Controller) GetClient(c *gin.Context) {
+	client, ok := o.ownedClient(c, "get")
+	if !ok {
+		return
+	}
+
+	core.WriteResponse(c, client.Response())
+}
+
+// DeleteClient delete an oauth client, only the owner or an administrator can do it.
+func (o *Controller) DeleteClient(c *gin.Context) {
+	client, ok := o.ownedClient(c, "delete")
+	if !ok {
+		return
+	}
+
+	if err := o.srv.OAuth().DeleteClient(c, client); err != nil {
+		log.Errorf("delete oauth client error: %+v", err)
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	core.WriteResponse(c, nil, core.WithHttpStatus(http.StatusNoContent))
+}
+
+// ownedClient 获取 uri 中的应用，只有应用的创建者或拥有 act 权限的管理员可以操作。
+func (o *Controller) ownedClient(c *gin.Context, act string) (*model.OAuthClients, bool) {
+	uri := &clientUri{}
+	if err := c.ShouldBindUri(uri); err != nil {
+		core.WriteResponse(
+			c,
+			validator.ParseValidationError(err),
+			core.WithError(errors.Code(code.ErrValidation, err.Error())),
+		)
+		return nil, false
+	}
+
+	client, err := o.srv.OAuth().GetClient(c, uri.ClientID)
+	if err != nil {
+		if !errors.IsCode(err, code.ErrOAuthClientNotExist) {
+			log.Errorf("get oauth client error: %+v", err)
+		}
+
+		core.WriteResponse(c, nil, core.WithError(err))
+		return nil, false
+	}
+
+	user := model.ExtractUsersFromContext(c)
+	if client.OwnerEID == user.EID {
+		return client, true
+	}
+
+	ok, err := casbin.Enforce(c, user.EID, "admin:oauth", act)
+	if err != nil {
+		log.Errorf("get oauth client error: %+v", err)
+		core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrDatabase, err.Error())))
+		return nil, false
+	}
+
+	if !ok {
+		core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrPermissionDenied, "无权操作此应用")))
+		return nil, false
+	}
+
+	return client, true
+}
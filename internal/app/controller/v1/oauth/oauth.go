@@ -0,0 +1,19 @@
+package oauth
+
+import (
+	"github.com/eachinchung/e-service/internal/app/service"
+	"github.com/eachinchung/e-service/internal/app/storage"
+	"github.com/eachinchung/e-service/internal/app/store"
+)
+
+// Controller create an oauth handler used to handle request for oauth client resource.
+type Controller struct {
+	srv service.Service
+}
+
+// NewController creates an oauth handler.
+func NewController(store store.Store, storage storage.Storage) *Controller {
+	return &Controller{
+		srv: service.NewService(store, storage),
+	}
+}
@@ -0,0 +1,19 @@
+package password
+
+import (
+	"github.com/eachinchung/e-service/internal/app/service"
+	"github.com/eachinchung/e-service/internal/app/storage"
+	"github.com/eachinchung/e-service/internal/app/store"
+)
+
+// Controller create a password handler used to handle request for password resource.
+type Controller struct {
+	srv service.Service
+}
+
+// NewController creates a password handler.
+func NewController(store store.Store, storage storage.Storage) *Controller {
+	return &Controller{
+		srv: service.NewService(store, storage),
+	}
+}
@@ -0,0 +1,116 @@
+package password
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/eachinchung/component-base/core"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/app/store/model"
+	"github.com/eachinchung/e-service/internal/pkg/code"
+	"github.com/eachinchung/e-service/internal/pkg/validator"
+)
+
+type resetCodeBody struct {
+	Phone string `json:"phone" binding:"required,len=11,phone"` // 手机号
+}
+
+type resetTicketBody struct {
+	Phone string `json:"phone" binding:"required,len=11,phone"`
+	Code  string `json:"code"  binding:"required,len=6,numeric"`
+}
+
+type resetBody struct {
+	Ticket   string `json:"ticket"   binding:"required"`
+	Password string `json:"password" binding:"required,min=6,password"`
+}
+
+// SendResetCode send a password reset verification code to the phone.
+// The response is the same whether the phone is registered or not.
+func (p *Controller) SendResetCode(c *gin.Context) {
+	body := &resetCodeBody{}
+	if err := c.ShouldBindJSON(body); err != nil {
+		core.WriteResponse(
+			c,
+			validator.ParseValidationError(err),
+			core.WithError(errors.Code(code.ErrValidation, err.Error())),
+		)
+		return
+	}
+
+	if err := p.srv.Passwords().SendResetCode(c, body.Phone, c.ClientIP()); err != nil {
+		if !errors.IsCode(err, code.ErrSendTooFrequently) && !errors.IsCode(err, code.ErrSendCodeFailed) {
+			log.L(c).Errorf("send password reset code error: %+v", err)
+			err = errors.Code(code.ErrUnknown, err.Error())
+		}
+
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	core.WriteResponse(c, nil, core.WithHttpStatus(http.StatusNoContent))
+}
+
+// CreateResetTicket exchange a password reset verification code for a short-lived reset ticket.
+func (p *Controller) CreateResetTicket(c *gin.Context) {
+	body := &resetTicketBody{}
+	if err := c.ShouldBindJSON(body); err != nil {
+		core.WriteResponse(
+			c,
+			validator.ParseValidationError(err),
+			core.WithError(errors.Code(code.ErrValidation, err.Error())),
+		)
+		return
+	}
+
+	ticket, expire, err := p.srv.Passwords().CreateResetTicket(c, body.Phone, body.Code, c.ClientIP())
+	if err != nil {
+		if !errors.IsCode(err, code.ErrVerificationCodeIncorrect) &&
+			!errors.IsCode(err, code.ErrVerificationCodeExpired) &&
+			!errors.IsCode(err, code.ErrVerificationCodeAttemptsExceeded) &&
+			!errors.IsCode(err, code.ErrPasswordResetTooFrequently) {
+			log.L(c).Errorf("create password reset ticket error: %+v", err)
+			err = errors.Code(code.ErrUnknown, err.Error())
+		}
+
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	core.WriteResponse(c, gin.H{
+		"ticket": ticket,
+		"expire": expire.Format(time.RFC3339),
+	})
+}
+
+// Reset set a new password with a reset ticket, all sessions and tokens of the user are revoked.
+func (p *Controller) Reset(c *gin.Context) {
+	body := &resetBody{}
+	if err := c.ShouldBindJSON(body); err != nil {
+		core.WriteResponse(
+			c,
+			validator.ParseValidationError(err),
+			core.WithError(errors.Code(code.ErrValidation, err.Error())),
+		)
+		return
+	}
+
+	eid, err := p.srv.Passwords().Reset(c, body.Ticket, body.Password, c.ClientIP())
+	if err != nil {
+		if !errors.IsCode(err, code.ErrPasswordResetTicketInvalid) &&
+			!errors.IsCode(err, code.ErrPasswordResetTooFrequently) &&
+			!errors.IsCode(err, code.ErrValidation) {
+			log.L(c).Errorf("reset password error: %+v", err)
+		}
+
+		core.WriteResponse(c, validator.ParseValidationError(err), core.WithError(err))
+		return
+	}
+
+	p.srv.SecurityEvents().Record(c, model.NewSecurityEvent(c, eid, model.SecurityEventPasswordReset, ""))
+	core.WriteResponse(c, nil, core.WithHttpStatus(http.StatusNoContent))
+}
@@ -0,0 +1,23 @@
+package qrlogin
+
+import (
+	"github.com/eachinchung/e-service/internal/app/service"
+	"github.com/eachinchung/e-service/internal/app/storage"
+	"github.com/eachinchung/e-service/internal/app/store"
+)
+
+// Controller create a qr login handler used to handle request from the logged-in mobile app.
+type Controller struct {
+	srv service.Service
+}
+
+// NewController creates a qr login handler.
+func NewController(store store.Store, storage storage.Storage) *Controller {
+	return &Controller{
+		srv: service.NewService(store, storage),
+	}
+}
+
+type qrLoginUri struct {
+	Code string `uri:"code" binding:"required"`
+}
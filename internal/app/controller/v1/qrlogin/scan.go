@@ -0,0 +1,86 @@
+package qrlogin
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/eachinchung/component-base/core"
+	"github.com/eachinchung/component-base/middleware/auth"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/pkg/code"
+	"github.com/eachinchung/e-service/internal/pkg/validator"
+)
+
+// Scan mark the qr code as scanned by the current user,
+// the browser that created the qr code is returned so that the user can check it before confirming.
+func (q *Controller) Scan(c *gin.Context) {
+	uri := &qrLoginUri{}
+	if err := c.ShouldBindUri(uri); err != nil {
+		core.WriteResponse(
+			c,
+			validator.ParseValidationError(err),
+			core.WithError(errors.Code(code.ErrValidation, err.Error())),
+		)
+		return
+	}
+
+	eid, _ := auth.ExtractClaimsFromContext(c)["sub"].(string)
+	login, err := q.srv.QRLogins().Scan(c, uri.Code, eid)
+	if err != nil {
+		if !errors.IsCode(err, code.ErrQRLoginInvalid) && !errors.IsCode(err, code.ErrQRLoginStateInvalid) {
+			log.Errorf("scan qr login error: %+v", err)
+		}
+
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	core.WriteResponse(c, gin.H{
+		"ip":         login.IP,
+		"user_agent": login.UserAgent,
+	})
+}
+
+// Confirm confirm the login of the browser that created the scanned qr code.
+func (q *Controller) Confirm(c *gin.Context) {
+	q.decide(c, true)
+}
+
+// Deny deny the login of the browser that created the scanned qr code.
+func (q *Controller) Deny(c *gin.Context) {
+	q.decide(c, false)
+}
+
+func (q *Controller) decide(c *gin.Context, confirm bool) {
+	uri := &qrLoginUri{}
+	if err := c.ShouldBindUri(uri); err != nil {
+		core.WriteResponse(
+			c,
+			validator.ParseValidationError(err),
+			core.WithError(errors.Code(code.ErrValidation, err.Error())),
+		)
+		return
+	}
+
+	eid, _ := auth.ExtractClaimsFromContext(c)["sub"].(string)
+
+	var err error
+	if confirm {
+		err = q.srv.QRLogins().Confirm(c, uri.Code, eid)
+	} else {
+		err = q.srv.QRLogins().Deny(c, uri.Code, eid)
+	}
+	if err != nil {
+		if !errors.IsCode(err, code.ErrQRLoginInvalid) && !errors.IsCode(err, code.ErrQRLoginStateInvalid) {
+			log.Errorf("decide qr login error: %+v", err)
+		}
+
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	core.WriteResponse(c, nil, core.WithHttpStatus(http.StatusNoContent))
+}
@@ -0,0 +1,108 @@
+package rbac
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/eachinchung/component-base/core"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/app/store/model"
+	"github.com/eachinchung/e-service/internal/pkg/casbin"
+	"github.com/eachinchung/e-service/internal/pkg/code"
+	"github.com/eachinchung/e-service/internal/pkg/validator"
+)
+
+type userUri struct {
+	EID string `uri:"eid" binding:"required,max=32"`
+}
+
+// ListUserRoles list the roles assigned to a user.
+func (r *Controller) ListUserRoles(c *gin.Context) {
+	uri := &userUri{}
+	if err := c.ShouldBindUri(uri); err != nil {
+		core.WriteResponse(
+			c,
+			validator.ParseValidationError(err),
+			core.WithError(errors.Code(code.ErrValidation, err.Error())),
+		)
+		return
+	}
+
+	if _, err := r.srv.Users().GetByEIDUnscoped(c, uri.EID); err != nil {
+		if !errors.IsCode(err, code.ErrUserNotExist) {
+			log.Errorf("list user roles error: %+v", err)
+		}
+
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	roles, err := casbin.GetRolesForUser(c, uri.EID)
+	if err != nil {
+		log.Errorf("list user roles error: %+v", err)
+		core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrDatabase, err.Error())))
+		return
+	}
+	core.WriteResponse(c, roles)
+}
+
+// AssignRole assign an existing role to a user.
+func (r *Controller) AssignRole(c *gin.Context) {
+	uri := &userRoleUri{}
+	if err := c.ShouldBindUri(uri); err != nil {
+		core.WriteResponse(
+			c,
+			validator.ParseValidationError(err),
+			core.WithError(errors.Code(code.ErrValidation, err.Error())),
+		)
+		return
+	}
+
+	if !casbin.IsRole(c, uri.Role) {
+		core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrRoleNotExist, "role "+uri.Role+" not exist")))
+		return
+	}
+
+	if _, err := r.srv.Users().GetByEID(c, uri.EID); err != nil {
+		if !errors.IsCode(err, code.ErrUserNotExist) {
+			log.Errorf("assign role error: %+v", err)
+		}
+
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	if err := casbin.AddRolesForUser(c, uri.EID, uri.Role); err != nil {
+		log.Errorf("assign role error: %+v", err)
+		core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrDatabase, err.Error())))
+		return
+	}
+
+	log.L(c).Infof("role %s assigned to %s by %s", uri.Role, uri.EID, model.ExtractSubjectFromContext(c))
+	core.WriteResponse(c, nil, core.WithHttpStatus(http.StatusNoContent))
+}
+
+// UnassignRole remove a role from a user.
+func (r *Controller) UnassignRole(c *gin.Context) {
+	uri := &userRoleUri{}
+	if err := c.ShouldBindUri(uri); err != nil {
+		core.WriteResponse(
+			c,
+			validator.ParseValidationError(err),
+			core.WithError(errors.Code(code.ErrValidation, err.Error())),
+		)
+		return
+	}
+
+	if err := casbin.DeleteRoleForUser(c, uri.EID, uri.Role); err != nil {
+		log.Errorf("unassign role error: %+v", err)
+		core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrDatabase, err.Error())))
+		return
+	}
+
+	log.L(c).Infof("role %s removed from %s by %s", uri.Role, uri.EID, model.ExtractSubjectFromContext(c))
+	core.WriteResponse(c, nil, core.WithHttpStatus(http.StatusNoContent))
+}
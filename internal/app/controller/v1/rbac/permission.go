@@ -0,0 +1,106 @@
+package rbac
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/eachinchung/component-base/core"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/app/store/model"
+	"github.com/eachinchung/e-service/internal/pkg/casbin"
+	"github.com/eachinchung/e-service/internal/pkg/code"
+	"github.com/eachinchung/e-service/internal/pkg/validator"
+)
+
+// GrantPermission grant a permission to an existing role.
+func (r *Controller) GrantPermission(c *gin.Context) {
+	uri := &roleUri{}
+	if err := c.ShouldBindUri(uri); err != nil {
+		core.WriteResponse(
+			c,
+			validator.ParseValidationError(err),
+			core.WithError(errors.Code(code.ErrValidation, err.Error())),
+		)
+		return
+	}
+
+	body := &permissionBody{}
+	if err := c.ShouldBindJSON(body); err != nil {
+		core.WriteResponse(
+			c,
+			validator.ParseValidationError(err),
+			core.WithError(errors.Code(code.ErrValidation, err.Error())),
+		)
+		return
+	}
+
+	if !casbin.IsRole(c, uri.Role) {
+		core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrRoleNotExist, "role "+uri.Role+" not exist")))
+		return
+	}
+
+	if err := casbin.AddPermissionForRole(c, uri.Role, body.Obj, body.Act); err != nil {
+		log.Errorf("grant permission error: %+v", err)
+		core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrDatabase, err.Error())))
+		return
+	}
+
+	log.L(c).Infof("permission %s %s granted to role %s by %s", body.Act, body.Obj, uri.Role, model.ExtractSubjectFromContext(c))
+	core.WriteResponse(c, nil, core.WithHttpStatus(http.StatusNoContent))
+}
+
+// RevokePermission revoke a permission from a role, the permission is given by the obj and act query parameters.
+// The last permission of a role cannot be revoked, delete the role instead.
+func (r *Controller) RevokePermission(c *gin.Context) {
+	uri := &roleUri{}
+	if err := c.ShouldBindUri(uri); err != nil {
+		core.WriteResponse(
+			c,
+			validator.ParseValidationError(err),
+			core.WithError(errors.Code(code.ErrValidation, err.Error())),
+		)
+		return
+	}
+
+	query := &permissionBody{}
+	if err := c.ShouldBindQuery(query); err != nil {
+		core.WriteResponse(
+			c,
+			validator.ParseValidationError(err),
+			core.WithError(errors.Code(code.ErrValidation, err.Error())),
+		)
+		return
+	}
+
+	if !casbin.IsRole(c, uri.Role) {
+		core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrRoleNotExist, "role "+uri.Role+" not exist")))
+		return
+	}
+
+	if !casbin.HasPermissionForRole(c, uri.Role, query.Obj, query.Act) {
+		core.WriteResponse(c, nil, core.WithHttpStatus(http.StatusNoContent))
+		return
+	}
+
+	// 角色没有权限后不再是角色，它的成员会变成无法管理的孤立记录
+	if len(casbin.GetPermissionsForRole(c, uri.Role)) == 1 {
+		core.WriteResponse(
+			c,
+			nil,
+			core.WithError(errors.Code(code.ErrValidation, "cannot revoke the last permission of a role, delete the role instead")),
+		)
+		return
+	}
+
+	if err := casbin.DeletePermissionForRole(c, uri.Role, query.Obj, query.Act); err != nil {
+		log.Errorf("revoke permission error: %+v", err)
+		core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrDatabase, err.Error())))
+		return
+	}
+
+	log.L(c).Infof("permission %s %s revoked from role %s by %s", query.Act, query.Obj, uri.Role, model.ExtractSubjectFromContext(c))
+	core.WriteResponse(c, nil, core.WithHttpStatus(http.StatusNoContent))
+}
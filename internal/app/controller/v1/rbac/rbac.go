@@ -0,0 +1,33 @@
+package rbac
+
+import (
+	"github.com/eachinchung/e-service/internal/app/service"
+	"github.com/eachinchung/e-service/internal/app/storage"
+	"github.com/eachinchung/e-service/internal/app/store"
+)
+
+// Controller create a rbac handler used to manage casbin roles, permissions and role assignments.
+type Controller struct {
+	srv service.Service
+}
+
+// NewController creates a rbac handler.
+func NewController(store store.Store, storage storage.Storage) *Controller {
+	return &Controller{
+		srv: service.NewService(store, storage),
+	}
+}
+
+type roleUri struct {
+	Role string `uri:"role" binding:"required,role"`
+}
+
+type userRoleUri struct {
+	EID  string `uri:"eid"  binding:"required,max=32"`
+	Role string `uri:"role" binding:"required,role"`
+}
+
+type permissionBody struct {
+	Obj string `json:"obj" form:"obj" binding:"required,startswith=/,max=128"` // 路径，支持 keyMatch2 格式
+	Act string `json:"act" form:"act" binding:"required,max=64,regexp"`        // 请求方法，支持正则表达式
+}
@@ -0,0 +1,145 @@
+package rbac
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/eachinchung/component-base/core"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/app/store/model"
+	"github.com/eachinchung/e-service/internal/pkg/casbin"
+	"github.com/eachinchung/e-service/internal/pkg/code"
+	"github.com/eachinchung/e-service/internal/pkg/validator"
+)
+
+type createRoleBody struct {
+	Name        string           `json:"name"        binding:"required,role"`              // 角色名
+	Permissions []permissionBody `json:"permissions" binding:"required,min=1,max=50,dive"` // 角色的权限
+}
+
+// ListRoles list all roles, a role is a subject which has at least one permission.
+func (r *Controller) ListRoles(c *gin.Context) {
+	core.WriteResponse(c, casbin.GetAllRoles(c))
+}
+
+// GetRole get the permissions and the members of a role.
+func (r *Controller) GetRole(c *gin.Context) {
+	uri := &roleUri{}
+	if err := c.ShouldBindUri(uri); err != nil {
+		core.WriteResponse(
+			c,
+			validator.ParseValidationError(err),
+			core.WithError(errors.Code(code.ErrValidation, err.Error())),
+		)
+		return
+	}
+
+	if !casbin.IsRole(c, uri.Role) {
+		core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrRoleNotExist, "role "+uri.Role+" not exist")))
+		return
+	}
+
+	rsp, err := roleResponse(c, uri.Role)
+	if err != nil {
+		log.Errorf("get role error: %+v", err)
+		core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrDatabase, err.Error())))
+		return
+	}
+	core.WriteResponse(c, rsp)
+}
+
+// CreateRole create a role with its initial permissions, the permissions are added in a single transaction.
+// Roles live in their own namespace, so a role name never collides with a user name.
+func (r *Controller) CreateRole(c *gin.Context) {
+	body := &createRoleBody{}
+	if err := c.ShouldBindJSON(body); err != nil {
+		core.WriteResponse(
+			c,
+			validator.ParseValidationError(err),
+			core.WithError(errors.Code(code.ErrValidation, err.Error())),
+		)
+		return
+	}
+
+	permissions := make([][]string, 0, len(body.Permissions))
+	seen := make(map[permissionBody]bool, len(body.Permissions))
+	for _, p := range body.Permissions {
+		if !seen[p] {
+			seen[p] = true
+			permissions = append(permissions, []string{p.Obj, p.Act})
+		}
+	}
+
+	if casbin.IsRole(c, body.Name) {
+		core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrRoleAlreadyExist, "role "+body.Name+" already exist")))
+		return
+	}
+
+	// 角色的全部权限在同一个事务中添加，失败时不会留下只有部分权限的角色
+	ok, err := casbin.AddRole(c, body.Name, permissions...)
+	if err != nil {
+		log.Errorf("create role error: %+v", err)
+		core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrDatabase, err.Error())))
+		return
+	}
+	if !ok {
+		core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrRoleAlreadyExist, "role "+body.Name+" already exist")))
+		return
+	}
+
+	rsp, err := roleResponse(c, body.Name)
+	if err != nil {
+		log.Errorf("create role error: %+v", err)
+		core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrDatabase, err.Error())))
+		return
+	}
+
+	log.L(c).Infof("role %s created by %s", body.Name, model.ExtractSubjectFromContext(c))
+	core.WriteResponse(c, rsp, core.WithHttpStatus(http.StatusCreated))
+}
+
+// DeleteRole delete a role together with its permissions, the role is removed from all of its members.
+func (r *Controller) DeleteRole(c *gin.Context) {
+	uri := &roleUri{}
+	if err := c.ShouldBindUri(uri); err != nil {
+		core.WriteResponse(
+			c,
+			validator.ParseValidationError(err),
+			core.WithError(errors.Code(code.ErrValidation, err.Error())),
+		)
+		return
+	}
+
+	if !casbin.IsRole(c, uri.Role) {
+		core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrRoleNotExist, "role "+uri.Role+" not exist")))
+		return
+	}
+
+	if err := casbin.DeleteRole(c, uri.Role); err != nil {
+		log.Errorf("delete role error: %+v", err)
+		core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrDatabase, err.Error())))
+		return
+	}
+
+	log.L(c).Infof("role %s deleted by %s", uri.Role, model.ExtractSubjectFromContext(c))
+	core.WriteResponse(c, nil, core.WithHttpStatus(http.StatusNoContent))
+}
+
+// roleResponse 返回角色的权限以及拥有该角色的用户。
+func roleResponse(c *gin.Context, role string) (gin.H, error) {
+	members, err := casbin.GetUsersForRole(c, role)
+	if err != nil {
+		return nil, err
+	}
+
+	policies := casbin.GetPermissionsForRole(c, role)
+	permissions := make([]gin.H, 0, len(policies))
+	for _, p := range policies {
+		permissions = append(permissions, gin.H{"obj": p[1], "act": p[2]})
+	}
+
+	return gin.H{"name": role, "permissions": permissions, "members": members}, nil
+}
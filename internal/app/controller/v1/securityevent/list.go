@@ -0,0 +1,90 @@
+package securityevent
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/eachinchung/component-base/core"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/app/service"
+	"github.com/eachinchung/e-service/internal/pkg/code"
+	"github.com/eachinchung/e-service/internal/pkg/validator"
+)
+
+// searchQuery 是管理员查询所有用户安全事件的条件。
+type searchQuery struct {
+	pageQuery
+	EID string `form:"eid" binding:"omitempty,max=32"`
+	IP  string `form:"ip"  binding:"omitempty,ip"`
+}
+
+// List list the recent security events of the user, such as logins, token refreshes and password changes.
+func (s *Controller) List(c *gin.Context) {
+	query := &pageQuery{}
+	if err := c.ShouldBindQuery(query); err != nil {
+		core.WriteResponse(
+			c,
+			validator.ParseValidationError(err),
+			core.WithError(errors.Code(code.ErrValidation, err.Error())),
+		)
+		return
+	}
+	query.normalize()
+
+	eid, err := targetEID(c)
+	if err != nil {
+		if !errors.IsCode(err, code.ErrPermissionDenied) {
+			log.Errorf("list security events error: %+v", err)
+		}
+
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	filter := &service.SecurityEventFilter{EID: eid, Type: query.Type}
+	events, total, err := s.srv.SecurityEvents().List(c, filter, query.Page, query.PageSize)
+	if err != nil {
+		log.Errorf("list security events error: %+v", err)
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	core.WriteResponse(c, pageResponse(events, total, query))
+}
+
+// Search query the security events across all users, only administrators are allowed.
+func (s *Controller) Search(c *gin.Context) {
+	query := &searchQuery{}
+	if err := c.ShouldBindQuery(query); err != nil {
+		core.WriteResponse(
+			c,
+			validator.ParseValidationError(err),
+			core.WithError(errors.Code(code.ErrValidation, err.Error())),
+		)
+		return
+	}
+	query.normalize()
+
+	ok, err := isAdmin(c)
+	if err != nil {
+		log.Errorf("search security events error: %+v", err)
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	if !ok {
+		core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrPermissionDenied, "无权查看安全事件")))
+		return
+	}
+
+	filter := &service.SecurityEventFilter{EID: query.EID, Type: query.Type, IP: query.IP}
+	events, total, err := s.srv.SecurityEvents().List(c, filter, query.Page, query.PageSize)
+	if err != nil {
+		log.Errorf("search security events error: %+v", err)
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	core.WriteResponse(c, pageResponse(events, total, &query.pageQuery))
+}
@@ -0,0 +1,87 @@
+package securityevent
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/eachinchung/component-base/middleware/auth"
+	"github.com/eachinchung/errors"
+
+	"github.com/eachinchung/e-service/internal/app/service"
+	"github.com/eachinchung/e-service/internal/app/storage"
+	"github.com/eachinchung/e-service/internal/app/store"
+	"github.com/eachinchung/e-service/internal/app/store/model"
+	"github.com/eachinchung/e-service/internal/pkg/casbin"
+	"github.com/eachinchung/e-service/internal/pkg/code"
+)
+
+// defaultPageSize 是未指定 page_size 时每页返回的事件数量。
+const defaultPageSize = 20
+
+// Controller create a security event handler used to handle request for security event resource.
+type Controller struct {
+	srv service.Service
+}
+
+// NewController creates a security event handler.
+func NewController(store store.Store, storage storage.Storage) *Controller {
+	return &Controller{
+		srv: service.NewService(store, storage),
+	}
+}
+
+// pageQuery 是分页查询的参数。
+type pageQuery struct {
+	Page     int    `form:"page"      binding:"omitempty,min=1"`
+	PageSize int    `form:"page_size" binding:"omitempty,min=1,max=100"`
+	Type     string `form:"type"      binding:"omitempty,max=32"`
+}
+
+// normalize 为未指定的分页参数设置默认值。
+func (q *pageQuery) normalize() {
+	if q.Page == 0 {
+		q.Page = 1
+	}
+	if q.PageSize == 0 {
+		q.PageSize = defaultPageSize
+	}
+}
+
+// pageResponse 返回一页安全事件以及符合条件的事件总数。
+func pageResponse(events []*model.SecurityEvents, total int64, query *pageQuery) gin.H {
+	return gin.H{
+		"total":     total,
+		"page":      query.Page,
+		"page_size": query.PageSize,
+		"items":     events,
+	}
+}
+
+// isAdmin 判断当前主体是否可以查看其他用户的安全事件。
+func isAdmin(c *gin.Context) (bool, error) {
+	ok, err := casbin.Enforce(c, model.ExtractSubjectFromContext(c), "admin:user", "security_event")
+	if err != nil {
+		return false, errors.Code(code.ErrDatabase, err.Error())
+	}
+	return ok, nil
+}
+
+// targetEID 返回请求查询的用户名。
+// 路由中没有 eid 参数时查询当前用户的安全事件，否则只有用户本人或管理员可以查询。
+func targetEID(c *gin.Context) (string, error) {
+	current, _ := auth.ExtractClaimsFromContext(c)["sub"].(string)
+
+	eid := c.Param("eid")
+	if eid == "" || eid == current {
+		return current, nil
+	}
+
+	ok, err := isAdmin(c)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", errors.Code(code.ErrPermissionDenied, "无权查看此用户的安全事件")
+	}
+
+	return eid, nil
+}
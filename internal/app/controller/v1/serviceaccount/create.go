@@ -0,0 +1,126 @@
+package serviceaccount
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/eachinchung/component-base/core"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/app/store/model"
+	"github.com/eachinchung/e-service/internal/pkg/casbin"
+	"github.com/eachinchung/e-service/internal/pkg/code"
+	"github.com/eachinchung/e-service/internal/pkg/validator"
+)
+
+type createBody struct {
+	Name      string   `json:"name"       binding:"required,min=1,max=64"`     // 名称
+	Secret    bool     `json:"secret"`                                         // 是否生成密钥
+	PublicKey string   `json:"public_key" binding:"max=4096"`                  // PEM 格式的公钥
+	Roles     []string `json:"roles"      binding:"max=20,dive,required,role"` // casbin 中的角色
+}
+
+// Create create a service account, the secret is only returned once.
+// A service account authenticates with the secret or a client assertion signed by its private key.
+func (s *Controller) Create(c *gin.Context) {
+	body := &createBody{}
+	if err := c.ShouldBindJSON(body); err != nil {
+		core.WriteResponse(
+			c,
+			validator.ParseValidationError(err),
+			core.WithError(errors.Code(code.ErrValidation, err.Error())),
+		)
+		return
+	}
+
+	if !body.Secret && body.PublicKey == "" {
+		core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrValidation, "secret or public_key is required")))
+		return
+	}
+
+	for _, role := range body.Roles {
+		if !casbin.IsRole(c, role) {
+			core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrValidation, "role "+role+" not exist")))
+			return
+		}
+	}
+
+	owner := model.ExtractUsersFromContext(c).EID
+	if err := s.checkGrantable(c, owner, body.Roles); err != nil {
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	account := &model.ServiceAccounts{
+		Name:      body.Name,
+		PublicKey: body.PublicKey,
+		OwnerEID:  owner,
+	}
+
+	secret, err := s.srv.ServiceAccounts().Create(c, account, body.Secret)
+	if err != nil {
+		if !errors.IsCode(err, code.ErrServiceAccountKeyInvalid) {
+			log.Errorf("create service account error: %+v", err)
+		}
+
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	if err := casbin.AddRolesForUser(c, account.Subject(), body.Roles...); err != nil {
+		log.Errorf("add service account roles error: %+v", err)
+		core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrDatabase, err.Error())))
+		return
+	}
+
+	rsp, err := response(c, account)
+	if err != nil {
+		log.Errorf("get service account roles error: %+v", err)
+		core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrDatabase, err.Error())))
+		return
+	}
+
+	if secret != "" {
+		rsp["client_secret"] = secret
+	}
+	core.WriteResponse(c, rsp, core.WithHttpStatus(http.StatusCreated))
+}
+
+// checkGrantable 检查 eid 是否可以把 roles 授予服务账号，超级用户以外的用户只能授予自己拥有的角色，避免通过服务账号提升权限。
+func (s *Controller) checkGrantable(c *gin.Context, eid string, roles []string) error {
+	if len(roles) == 0 {
+		return nil
+	}
+
+	isSuperUser, err := s.srv.SuperUser().Exists(c, eid)
+	if err != nil {
+		log.Errorf("check super user error: %+v", err)
+		return errors.Code(code.ErrDatabase, err.Error())
+	}
+	if isSuperUser {
+		return nil
+	}
+
+	held, err := casbin.GetRolesForUser(c, eid)
+	if err != nil {
+		log.Errorf("get user roles error: %+v", err)
+		return errors.Code(code.ErrDatabase, err.Error())
+	}
+
+	for _, role := range roles {
+		found := false
+		for _, r := range held {
+			if r == role {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return errors.Code(code.ErrPermissionDenied, "不能授予自己没有的角色 "+role)
+		}
+	}
+	return nil
+}
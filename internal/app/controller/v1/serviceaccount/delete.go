@@ -0,0 +1,55 @@
+package serviceaccount
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/eachinchung/component-base/core"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/app/store/model"
+	"github.com/eachinchung/e-service/internal/pkg/casbin"
+	"github.com/eachinchung/e-service/internal/pkg/code"
+	"github.com/eachinchung/e-service/internal/pkg/validator"
+)
+
+// Delete delete a service account together with its roles and permissions,
+// the tokens it has been issued are rejected immediately.
+func (s *Controller) Delete(c *gin.Context) {
+	uri := &accountUri{}
+	if err := c.ShouldBindUri(uri); err != nil {
+		core.WriteResponse(
+			c,
+			validator.ParseValidationError(err),
+			core.WithError(errors.Code(code.ErrValidation, err.Error())),
+		)
+		return
+	}
+
+	account, err := s.srv.ServiceAccounts().Get(c, uri.ClientID)
+	if err != nil {
+		if !errors.IsCode(err, code.ErrServiceAccountNotExist) {
+			log.Errorf("delete service account error: %+v", err)
+		}
+
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	if err := s.srv.ServiceAccounts().Delete(c, account); err != nil {
+		log.Errorf("delete service account error: %+v", err)
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	if err := casbin.DeleteUser(c, account.Subject()); err != nil {
+		log.Errorf("delete service account roles error: %+v", err)
+		core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrDatabase, err.Error())))
+		return
+	}
+
+	log.L(c).Infof("service account %s deleted by %s", account.ClientID, model.ExtractSubjectFromContext(c))
+	core.WriteResponse(c, nil, core.WithHttpStatus(http.StatusNoContent))
+}
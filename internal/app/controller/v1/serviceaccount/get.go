@@ -0,0 +1,67 @@
+package serviceaccount
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/eachinchung/component-base/core"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/pkg/code"
+	"github.com/eachinchung/e-service/internal/pkg/validator"
+)
+
+// List list all service accounts.
+func (s *Controller) List(c *gin.Context) {
+	accounts, err := s.srv.ServiceAccounts().List(c)
+	if err != nil {
+		log.Errorf("list service accounts error: %+v", err)
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	items := make([]map[string]any, 0, len(accounts))
+	for _, account := range accounts {
+		rsp, err := response(c, account)
+		if err != nil {
+			log.Errorf("get service account roles error: %+v", err)
+			core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrDatabase, err.Error())))
+			return
+		}
+		items = append(items, rsp)
+	}
+
+	core.WriteResponse(c, items)
+}
+
+// Get get a service account by the client id.
+func (s *Controller) Get(c *gin.Context) {
+	uri := &accountUri{}
+	if err := c.ShouldBindUri(uri); err != nil {
+		core.WriteResponse(
+			c,
+			validator.ParseValidationError(err),
+			core.WithError(errors.Code(code.ErrValidation, err.Error())),
+		)
+		return
+	}
+
+	account, err := s.srv.ServiceAccounts().Get(c, uri.ClientID)
+	if err != nil {
+		if !errors.IsCode(err, code.ErrServiceAccountNotExist) {
+			log.Errorf("get service account error: %+v", err)
+		}
+
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	rsp, err := response(c, account)
+	if err != nil {
+		log.Errorf("get service account roles error: %+v", err)
+		core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrDatabase, err.Error())))
+		return
+	}
+
+	core.WriteResponse(c, rsp)
+}
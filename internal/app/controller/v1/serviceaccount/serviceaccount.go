@@ -0,0 +1,39 @@
+package serviceaccount
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/eachinchung/e-service/internal/app/service"
+	"github.com/eachinchung/e-service/internal/app/storage"
+	"github.com/eachinchung/e-service/internal/app/store"
+	"github.com/eachinchung/e-service/internal/app/store/model"
+	"github.com/eachinchung/e-service/internal/pkg/casbin"
+)
+
+// Controller create a service account handler used to handle request for service account resource.
+type Controller struct {
+	srv service.Service
+}
+
+// NewController creates a service account handler.
+func NewController(store store.Store, storage storage.Storage) *Controller {
+	return &Controller{
+		srv: service.NewService(store, storage),
+	}
+}
+
+type accountUri struct {
+	ClientID string `uri:"client_id" binding:"required"`
+}
+
+// response 返回服务账号的信息以及它在 casbin 中的角色。
+func response(c *gin.Context, account *model.ServiceAccounts) (map[string]any, error) {
+	roles, err := casbin.GetRolesForUser(c, account.Subject())
+	if err != nil {
+		return nil, err
+	}
+
+	rsp := account.Response()
+	rsp["roles"] = roles
+	return rsp, nil
+}
@@ -0,0 +1,64 @@
+package session
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/eachinchung/component-base/core"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/pkg/code"
+	"github.com/eachinchung/e-service/internal/pkg/validator"
+)
+
+type sessionUri struct {
+	SID string `uri:"sid" binding:"required"`
+}
+
+// End end a session of the user, the tokens issued in the session stop working immediately.
+func (s *Controller) End(c *gin.Context) {
+	uri := &sessionUri{}
+	if err := c.ShouldBindUri(uri); err != nil {
+		core.WriteResponse(
+			c,
+			validator.ParseValidationError(err),
+			core.WithError(errors.Code(code.ErrValidation, err.Error())),
+		)
+		return
+	}
+
+	eid, err := targetEID(c)
+	if err == nil {
+		err = s.srv.Sessions().End(c, eid, uri.SID)
+	}
+	if err != nil {
+		if !errors.IsCode(err, code.ErrPermissionDenied) && !errors.IsCode(err, code.ErrSessionNotExist) {
+			log.Errorf("end session error: %+v", err)
+		}
+
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	core.WriteResponse(c, nil, core.WithHttpStatus(http.StatusNoContent))
+}
+
+// EndAll end all sessions of the user, including the session of the current token.
+func (s *Controller) EndAll(c *gin.Context) {
+	eid, err := targetEID(c)
+	if err == nil {
+		err = s.srv.Sessions().EndAll(c, eid)
+	}
+	if err != nil {
+		if !errors.IsCode(err, code.ErrPermissionDenied) {
+			log.Errorf("end sessions error: %+v", err)
+		}
+
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	core.WriteResponse(c, nil, core.WithHttpStatus(http.StatusNoContent))
+}
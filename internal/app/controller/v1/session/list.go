@@ -0,0 +1,49 @@
+package session
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/eachinchung/component-base/core"
+	"github.com/eachinchung/component-base/middleware/auth"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/pkg/code"
+)
+
+// List list the active sessions of the user, the session of the current token is marked as current.
+func (s *Controller) List(c *gin.Context) {
+	eid, err := targetEID(c)
+	if err != nil {
+		if !errors.IsCode(err, code.ErrPermissionDenied) {
+			log.Errorf("list sessions error: %+v", err)
+		}
+
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	sessions, err := s.srv.Sessions().List(c, eid)
+	if err != nil {
+		log.Errorf("list sessions error: %+v", err)
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	fid, _ := auth.ExtractClaimsFromContext(c)["fid"].(string)
+
+	rsp := make([]gin.H, 0, len(sessions))
+	for _, session := range sessions {
+		rsp = append(rsp, gin.H{
+			"sid":          session.SID,
+			"client_id":    session.ClientID,
+			"device":       session.Device,
+			"user_agent":   session.UserAgent,
+			"ip":           session.IP,
+			"created_at":   session.CreatedAt,
+			"last_seen_at": session.LastSeenAt,
+			"current":      session.SID == fid,
+		})
+	}
+	core.WriteResponse(c, rsp)
+}
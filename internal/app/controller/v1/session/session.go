@@ -0,0 +1,47 @@
+package session
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/eachinchung/component-base/middleware/auth"
+	"github.com/eachinchung/errors"
+
+	"github.com/eachinchung/e-service/internal/app/service"
+	"github.com/eachinchung/e-service/internal/app/storage"
+	"github.com/eachinchung/e-service/internal/app/store"
+	"github.com/eachinchung/e-service/internal/pkg/casbin"
+	"github.com/eachinchung/e-service/internal/pkg/code"
+)
+
+// Controller create a session handler used to handle request for session resource.
+type Controller struct {
+	srv service.Service
+}
+
+// NewController creates a session handler.
+func NewController(store store.Store, storage storage.Storage) *Controller {
+	return &Controller{
+		srv: service.NewService(store, storage),
+	}
+}
+
+// targetEID 返回请求操作的用户名。
+// 路由中没有 eid 参数时操作当前用户的会话，否则只有用户本人或管理员可以操作。
+func targetEID(c *gin.Context) (string, error) {
+	current, _ := auth.ExtractClaimsFromContext(c)["sub"].(string)
+
+	eid := c.Param("eid")
+	if eid == "" || eid == current {
+		return current, nil
+	}
+
+	ok, err := casbin.Enforce(c, current, "admin:user", "session")
+	if err != nil {
+		return "", errors.Code(code.ErrDatabase, err.Error())
+	}
+	if !ok {
+		return "", errors.Code(code.ErrPermissionDenied, "无权管理此用户的会话")
+	}
+
+	return eid, nil
+}
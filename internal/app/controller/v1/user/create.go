@@ -1,9 +1,10 @@
 package user
 
 import (
+	"database/sql"
+
 	"github.com/gin-gonic/gin"
 
-	"github.com/eachinchung/component-base/auth"
 	"github.com/eachinchung/component-base/core"
 	"github.com/eachinchung/component-base/utils/idutil"
 	"github.com/eachinchung/errors"
@@ -11,11 +12,13 @@ import (
 
 	"github.com/eachinchung/e-service/internal/app/store/model"
 	"github.com/eachinchung/e-service/internal/pkg/code"
+	"github.com/eachinchung/e-service/internal/pkg/hasher"
 	"github.com/eachinchung/e-service/internal/pkg/validator"
 )
 
 type createBody struct {
 	Phone        string  `json:"phone"         binding:"required,len=11,phone"`                  // 手机号
+	Email        *string `json:"email"         binding:"omitempty,email,max=255"`                // 邮箱，需要验证后才能用于登录
 	Nickname     string  `json:"nickname"      binding:"required,min=1,max=32"`                  // 昵称
 	EID          *string `json:"eid"           binding:"omitempty,min=6,max=20,eid,is_not_role"` // 用户名
 	Password     string  `json:"password"      binding:"required,min=6,password"`                // 密码
@@ -34,7 +37,7 @@ func (u *Controller) Create(c *gin.Context) {
 		return
 	}
 
-	pwdHash, _ := auth.HashPassword(body.Password)
+	pwdHash, _ := hasher.Client().Hash(body.Password)
 
 	user := &model.Users{
 		Phone:        body.Phone,
@@ -42,6 +45,10 @@ func (u *Controller) Create(c *gin.Context) {
 		PasswordHash: pwdHash,
 	}
 
+	if body.Email != nil {
+		user.Email = sql.NullString{String: *body.Email, Valid: true}
+	}
+
 	if body.EID == nil {
 		user.EID = idutil.GetInstanceID(idutil.GenUint64ID(), "eid")
 	} else {
@@ -0,0 +1,132 @@
+package user
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/eachinchung/component-base/core"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/app/store/model"
+	"github.com/eachinchung/e-service/internal/pkg/code"
+	"github.com/eachinchung/e-service/internal/pkg/validator"
+)
+
+type updateEmailBody struct {
+	Email string `json:"email" binding:"required,email,max=255"` // 邮箱
+}
+
+type verifyEmailBody struct {
+	Code string `json:"code" binding:"required,len=6,numeric"` // 邮件验证码
+}
+
+// UpdateEmail set the email of the current user and send a verification code to it.
+// The email can not be used to login until it is verified.
+func (u *Controller) UpdateEmail(c *gin.Context) {
+	user, ok := u.bindSelf(c)
+	if !ok {
+		return
+	}
+
+	var body updateEmailBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		core.WriteResponse(
+			c,
+			validator.ParseValidationError(err),
+			core.WithError(errors.Code(code.ErrValidation, err.Error())),
+		)
+		return
+	}
+
+	if err := u.srv.Users().UpdateEmail(c, user.EID, body.Email); err != nil {
+		if !errors.IsCode(err, code.ErrEmailAlreadyExist) {
+			log.Errorf("update email error: %+v", err)
+		}
+
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	u.sendEmailCode(c, user.EID)
+}
+
+// SendEmailCode resend the verification code to the unverified email of the current user.
+func (u *Controller) SendEmailCode(c *gin.Context) {
+	user, ok := u.bindSelf(c)
+	if !ok {
+		return
+	}
+
+	u.sendEmailCode(c, user.EID)
+}
+
+// VerifyEmail check the verification code and mark the email of the current user as verified.
+func (u *Controller) VerifyEmail(c *gin.Context) {
+	user, ok := u.bindSelf(c)
+	if !ok {
+		return
+	}
+
+	var body verifyEmailBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		core.WriteResponse(
+			c,
+			validator.ParseValidationError(err),
+			core.WithError(errors.Code(code.ErrValidation, err.Error())),
+		)
+		return
+	}
+
+	if err := u.srv.Users().VerifyEmail(c, user.EID, body.Code); err != nil {
+		if !errors.IsCode(err, code.ErrEmailNotSet) &&
+			!errors.IsCode(err, code.ErrEmailAlreadyVerified) &&
+			!errors.IsCode(err, code.ErrVerificationCodeIncorrect) &&
+			!errors.IsCode(err, code.ErrVerificationCodeExpired) &&
+			!errors.IsCode(err, code.ErrVerificationCodeAttemptsExceeded) {
+			log.Errorf("verify email error: %+v", err)
+		}
+
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	core.WriteResponse(c, nil, core.WithHttpStatus(http.StatusNoContent))
+}
+
+func (u *Controller) sendEmailCode(c *gin.Context, eid string) {
+	if err := u.srv.Users().SendEmailCode(c, eid, c.ClientIP()); err != nil {
+		if !errors.IsCode(err, code.ErrEmailNotSet) &&
+			!errors.IsCode(err, code.ErrEmailAlreadyVerified) &&
+			!errors.IsCode(err, code.ErrSendTooFrequently) {
+			log.Errorf("send email code error: %+v", err)
+		}
+
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	core.WriteResponse(c, nil, core.WithHttpStatus(http.StatusNoContent))
+}
+
+// bindSelf 校验路径中的 eid 是否为当前用户，邮箱只能由用户本人修改与验证。
+func (u *Controller) bindSelf(c *gin.Context) (*model.Users, bool) {
+	uri := &getUri{}
+	if err := c.ShouldBindUri(uri); err != nil {
+		core.WriteResponse(
+			c,
+			validator.ParseValidationError(err),
+			core.WithError(errors.Code(code.ErrValidation, err.Error())),
+		)
+		return nil, false
+	}
+
+	user := model.ExtractUsersFromContext(c)
+	if user.EID != uri.EID {
+		core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrPermissionDenied, "只能修改自己的邮箱")))
+		return nil, false
+	}
+
+	return user, true
+}
@@ -29,8 +29,8 @@ func (u *Controller) GetByEID(c *gin.Context) {
 		return
 	}
 
-	user := model.ExtractUsersFromContext(c)
-	ok, err := casbin.Enforce(c, user.EID, "admin:user", "get")
+	subject := model.ExtractSubjectFromContext(c)
+	ok, err := casbin.Enforce(c, subject, "admin:user", "get")
 	if err != nil {
 		log.Errorf("get user error: %+v", err)
 		core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrDatabase, err.Error())))
@@ -38,16 +38,16 @@ func (u *Controller) GetByEID(c *gin.Context) {
 	}
 
 	if !ok {
-		if user.EID != uri.EID {
+		if subject != uri.EID {
 			core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrPermissionDenied, "无权获取此用户")))
 			return
 		}
 
-		core.WriteResponse(c, user)
+		core.WriteResponse(c, model.ExtractUsersFromContext(c))
 		return
 	}
 
-	user, err = u.srv.Users().GetByEIDUnscoped(c, uri.EID)
+	user, err := u.srv.Users().GetByEIDUnscoped(c, uri.EID)
 	if err != nil {
 		log.Errorf("get user error: %+v", err)
 		core.WriteResponse(c, nil, core.WithError(err))
@@ -0,0 +1,79 @@
+package user
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/eachinchung/component-base/core"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/app/store/model"
+	"github.com/eachinchung/e-service/internal/pkg/code"
+	"github.com/eachinchung/e-service/internal/pkg/validator"
+)
+
+type changePasswordBody struct {
+	OldPassword string `json:"old_password" binding:"required,max=255"`
+	Password    string `json:"password"     binding:"required,min=6,password"`
+}
+
+// ChangePassword change the password of the current user, the current password is required.
+// All tokens issued before the change stop working immediately.
+func (u *Controller) ChangePassword(c *gin.Context) {
+	uri := &getUri{}
+	if err := c.ShouldBindUri(uri); err != nil {
+		core.WriteResponse(
+			c,
+			validator.ParseValidationError(err),
+			core.WithError(errors.Code(code.ErrValidation, err.Error())),
+		)
+		return
+	}
+
+	var body changePasswordBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		core.WriteResponse(
+			c,
+			validator.ParseValidationError(err),
+			core.WithError(errors.Code(code.ErrValidation, err.Error())),
+		)
+		return
+	}
+
+	user := model.ExtractUsersFromContext(c)
+	if user.EID != uri.EID {
+		core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrPermissionDenied, "只能修改自己的密码")))
+		return
+	}
+
+	// 校验当前密码与登录共享同一个失败次数限制，避免持有 token 的人无限次猜测密码
+	if err := u.srv.LoginLimits().Wait(c.Request.Context(), user.EID, c.ClientIP()); err != nil {
+		if !errors.IsCode(err, code.ErrLoginLocked) {
+			log.Errorf("check login limit error: %+v", err)
+		}
+
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	if err := u.srv.Passwords().Change(c, user.EID, body.OldPassword, body.Password); err != nil {
+		if errors.IsCode(err, code.ErrPasswordIncorrect) {
+			event := model.NewSecurityEvent(c, user.EID, model.SecurityEventLoginFailed, "pwd")
+			u.srv.LoginLimits().Failed(c, user.EID, c.ClientIP(), event)
+		} else if !errors.IsCode(err, code.ErrValidation) {
+			log.Errorf("change password error: %+v", err)
+		}
+
+		core.WriteResponse(c, validator.ParseValidationError(err), core.WithError(err))
+		return
+	}
+
+	if err := u.srv.LoginLimits().Succeed(c, user.EID); err != nil {
+		log.Errorf("reset login failures error: %+v", err)
+	}
+
+	u.srv.SecurityEvents().Record(c, model.NewSecurityEvent(c, user.EID, model.SecurityEventPasswordChanged, ""))
+	core.WriteResponse(c, nil, core.WithHttpStatus(http.StatusNoContent))
+}
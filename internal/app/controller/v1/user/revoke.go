@@ -0,0 +1,55 @@
+package user
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/eachinchung/component-base/core"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/app/store/model"
+	"github.com/eachinchung/e-service/internal/pkg/casbin"
+	"github.com/eachinchung/e-service/internal/pkg/code"
+	"github.com/eachinchung/e-service/internal/pkg/validator"
+)
+
+// RevokeTokens revoke all tokens and end all sessions of the user, only the user or an administrator can do it.
+func (u *Controller) RevokeTokens(c *gin.Context) {
+	uri := &getUri{}
+	if err := c.ShouldBindUri(uri); err != nil {
+		core.WriteResponse(
+			c,
+			validator.ParseValidationError(err),
+			core.WithError(errors.Code(code.ErrValidation, err.Error())),
+		)
+		return
+	}
+
+	subject := model.ExtractSubjectFromContext(c)
+	ok, err := casbin.Enforce(c, subject, "admin:user", "revoke")
+	if err != nil {
+		log.Errorf("revoke tokens error: %+v", err)
+		core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrDatabase, err.Error())))
+		return
+	}
+
+	if !ok && subject != uri.EID {
+		core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrPermissionDenied, "无权吊销此用户的 token")))
+		return
+	}
+
+	if _, err := u.srv.Users().GetByEIDUnscoped(c, uri.EID); err != nil {
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	if err := u.srv.Sessions().EndAll(c, uri.EID); err != nil {
+		log.Errorf("revoke tokens error: %+v", err)
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	core.WriteResponse(c, nil, core.WithHttpStatus(http.StatusNoContent))
+}
@@ -0,0 +1,69 @@
+package user
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/eachinchung/component-base/core"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/app/store/model"
+	"github.com/eachinchung/e-service/internal/pkg/casbin"
+	"github.com/eachinchung/e-service/internal/pkg/code"
+	"github.com/eachinchung/e-service/internal/pkg/validator"
+)
+
+type stateInfo struct {
+	State *model.Status `json:"state" binding:"required,oneof=0 1 2"`
+}
+
+// UpdateState change the state of the user, only an administrator can do it.
+// The tokens of the user stop working immediately.
+func (u *Controller) UpdateState(c *gin.Context) {
+	uri := &getUri{}
+	if err := c.ShouldBindUri(uri); err != nil {
+		core.WriteResponse(
+			c,
+			validator.ParseValidationError(err),
+			core.WithError(errors.Code(code.ErrValidation, err.Error())),
+		)
+		return
+	}
+
+	var body stateInfo
+	if err := c.ShouldBindJSON(&body); err != nil {
+		core.WriteResponse(
+			c,
+			validator.ParseValidationError(err),
+			core.WithError(errors.Code(code.ErrValidation, err.Error())),
+		)
+		return
+	}
+
+	subject := model.ExtractSubjectFromContext(c)
+	ok, err := casbin.Enforce(c, subject, "admin:user", "state")
+	if err != nil {
+		log.Errorf("update user state error: %+v", err)
+		core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrDatabase, err.Error())))
+		return
+	}
+
+	if !ok {
+		core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrPermissionDenied, "无权修改此用户的状态")))
+		return
+	}
+
+	if err := u.srv.Users().UpdateState(c, uri.EID, *body.State); err != nil {
+		if !errors.IsCode(err, code.ErrUserNotExist) {
+			log.Errorf("update user state error: %+v", err)
+		}
+
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	log.L(c).Infof("user %s state changed to %s by %s", uri.EID, body.State.Msg(), subject)
+	core.WriteResponse(c, nil, core.WithHttpStatus(http.StatusNoContent))
+}
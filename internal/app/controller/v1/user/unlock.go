@@ -0,0 +1,57 @@
+package user
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/eachinchung/component-base/core"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/app/store/model"
+	"github.com/eachinchung/e-service/internal/pkg/casbin"
+	"github.com/eachinchung/e-service/internal/pkg/code"
+	"github.com/eachinchung/e-service/internal/pkg/validator"
+)
+
+// Unlock clear the login lockout of the user, only an administrator can do it.
+func (u *Controller) Unlock(c *gin.Context) {
+	uri := &getUri{}
+	if err := c.ShouldBindUri(uri); err != nil {
+		core.WriteResponse(
+			c,
+			validator.ParseValidationError(err),
+			core.WithError(errors.Code(code.ErrValidation, err.Error())),
+		)
+		return
+	}
+
+	subject := model.ExtractSubjectFromContext(c)
+	ok, err := casbin.Enforce(c, subject, "admin:user", "unlock")
+	if err != nil {
+		log.Errorf("unlock user error: %+v", err)
+		core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrDatabase, err.Error())))
+		return
+	}
+
+	if !ok {
+		core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrPermissionDenied, "无权解除此用户的锁定")))
+		return
+	}
+
+	if _, err := u.srv.Users().GetByEIDUnscoped(c, uri.EID); err != nil {
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	if err := u.srv.LoginLimits().Unlock(c, uri.EID); err != nil {
+		log.Errorf("unlock user error: %+v", err)
+		core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrUnknown, err.Error())))
+		return
+	}
+
+	log.L(c).Infof("user %s unlocked by %s", uri.EID, subject)
+	u.srv.SecurityEvents().Record(c, model.NewSecurityEvent(c, uri.EID, model.SecurityEventAccountUnlocked, subject))
+	core.WriteResponse(c, nil, core.WithHttpStatus(http.StatusNoContent))
+}
@@ -0,0 +1,44 @@
+package verification
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/eachinchung/component-base/core"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/app/service"
+	"github.com/eachinchung/e-service/internal/pkg/code"
+	"github.com/eachinchung/e-service/internal/pkg/validator"
+)
+
+type sendSMSBody struct {
+	Phone string `json:"phone" binding:"required,len=11,phone"` // 手机号
+}
+
+// SendLoginSMSCode send a login verification code to the phone.
+func (v *Controller) SendLoginSMSCode(c *gin.Context) {
+	body := &sendSMSBody{}
+	if err := c.ShouldBindJSON(body); err != nil {
+		core.WriteResponse(
+			c,
+			validator.ParseValidationError(err),
+			core.WithError(errors.Code(code.ErrValidation, err.Error())),
+		)
+		return
+	}
+
+	if err := v.srv.Verifications().SendSMSCode(c, service.SceneLogin, body.Phone, c.ClientIP()); err != nil {
+		if !errors.IsCode(err, code.ErrSendTooFrequently) && !errors.IsCode(err, code.ErrSendCodeFailed) {
+			log.L(c).Errorf("send sms code error: %+v", err)
+			err = errors.Code(code.ErrUnknown, err.Error())
+		}
+
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	core.WriteResponse(c, nil, core.WithHttpStatus(http.StatusNoContent))
+}
@@ -0,0 +1,19 @@
+package verification
+
+import (
+	"github.com/eachinchung/e-service/internal/app/service"
+	"github.com/eachinchung/e-service/internal/app/storage"
+	"github.com/eachinchung/e-service/internal/app/store"
+)
+
+// Controller create a verification handler used to send verification codes.
+type Controller struct {
+	srv service.Service
+}
+
+// NewController creates a verification handler.
+func NewController(store store.Store, storage storage.Storage) *Controller {
+	return &Controller{
+		srv: service.NewService(store, storage),
+	}
+}
@@ -0,0 +1,198 @@
+package app
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/eachinchung/component-base/core"
+	"github.com/eachinchung/component-base/middleware/auth"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/app/config"
+	"github.com/eachinchung/e-service/internal/app/service"
+	"github.com/eachinchung/e-service/internal/app/storage"
+	"github.com/eachinchung/e-service/internal/app/store"
+	"github.com/eachinchung/e-service/internal/app/store/model"
+	"github.com/eachinchung/e-service/internal/pkg/code"
+	"github.com/eachinchung/e-service/internal/pkg/validator"
+)
+
+// deviceAuthorizationRequest 是设备发起的授权请求，使用 application/x-www-form-urlencoded 编码。
+type deviceAuthorizationRequest struct {
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+}
+
+// deviceVerifyQuery 是用户在已登录的设备上输入的用户码。
+type deviceVerifyQuery struct {
+	UserCode string `form:"user_code" binding:"required,max=16"`
+}
+
+// deviceDecision 是用户对设备授权请求的决定。
+type deviceDecision struct {
+	UserCode string `json:"user_code" binding:"required,max=16"`
+	Approve  bool   `json:"approve"`
+}
+
+// deviceVerificationURI 返回用户输入用户码的前端地址，未配置时使用签发者地址加上 /device。
+func deviceVerificationURI() string {
+	if uri := config.GetConfigIns(nil).OAuthOptions.DeviceVerificationURI; uri != "" {
+		return uri
+	}
+	return oidcIssuer() + "/device"
+}
+
+// deviceAuthorizationHandler 是 RFC 8628 的设备授权端点，为命令行工具、电视等不便输入密码的设备签发设备码与用户码。
+func deviceAuthorizationHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req deviceAuthorizationRequest
+		if err := c.ShouldBind(&req); err != nil {
+			oauthError(c, errors.Code(code.ErrValidation, err.Error()))
+			return
+		}
+
+		srv := service.NewService(store.Client(), storage.Client())
+
+		clientID, clientSecret := clientCredentials(c, req.ClientID, req.ClientSecret)
+		client, err := srv.OAuth().AuthenticateClient(c, clientID, clientSecret)
+		if err != nil {
+			oauthError(c, err)
+			return
+		}
+
+		authorization, err := srv.DeviceAuthorizations().Create(c, client.ClientID)
+		if err != nil {
+			oauthError(c, err)
+			return
+		}
+
+		userCode := service.FormatUserCode(authorization.UserCode)
+		verificationURI := deviceVerificationURI()
+
+		c.Header("Cache-Control", "no-store")
+		c.JSON(http.StatusOK, gin.H{
+			"device_code":               authorization.DeviceCode,
+			"user_code":                 userCode,
+			"verification_uri":          verificationURI,
+			"verification_uri_complete": withQuery(verificationURI, url.Values{"user_code": {userCode}}),
+			"expires_in":                int64(time.Until(authorization.Expire).Seconds()),
+			"interval":                  int64(authorization.Interval.Seconds()),
+		})
+	}
+}
+
+// deviceCodeGrant 处理设备使用设备码轮询 token 端点的请求，用户同意后签发与密码登录相同的 token。
+func deviceCodeGrant(c *gin.Context, mw *jwtAuth, srv service.Service, clientID, deviceCode string) {
+	if deviceCode == "" {
+		oauthError(c, errors.Code(code.ErrValidation, "device_code is required"))
+		return
+	}
+
+	eid, err := srv.DeviceAuthorizations().Poll(c, deviceCode, clientID)
+	if err != nil {
+		oauthError(c, err)
+		return
+	}
+
+	user, err := srv.Users().GetByEID(c, eid)
+	if err != nil {
+		oauthError(c, errors.Code(code.ErrOAuthInvalidGrant, err.Error()))
+		return
+	}
+
+	if user.State != model.StatusNormal {
+		oauthError(c, errors.Code(code.ErrOAuthInvalidGrant, "user has been "+user.State.Msg()))
+		return
+	}
+
+	issueTokensWith(c, mw, srv, user, []string{amrMultiChannel}, deviceTokenResponse())
+}
+
+// deviceTokenResponse 按照 RFC 6749 的格式返回设备授权签发的 token。
+func deviceTokenResponse() func(c *gin.Context, tokens *tokenPair) {
+	return func(c *gin.Context, tokens *tokenPair) {
+		oauthTokenResponse(c, tokens, "", "")
+	}
+}
+
+// deviceVerifyHandler 返回用户码对应的应用，由前端向用户展示授权页面。
+func deviceVerifyHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var query deviceVerifyQuery
+		if err := c.ShouldBindQuery(&query); err != nil {
+			core.WriteResponse(
+				c,
+				validator.ParseValidationError(err),
+				core.WithError(errors.Code(code.ErrValidation, err.Error())),
+			)
+			return
+		}
+
+		srv := service.NewService(store.Client(), storage.Client())
+		eid := auth.ExtractClaimsFromContext(c)["sub"].(string)
+
+		authorization, err := srv.DeviceAuthorizations().Get(c, eid, query.UserCode)
+		if err != nil {
+			if !errors.IsCode(err, code.ErrDeviceUserCodeInvalid) {
+				log.L(c).Errorf("get device authorization failed: %+v", err)
+			}
+
+			core.WriteResponse(c, nil, core.WithError(err))
+			return
+		}
+
+		client, err := srv.OAuth().GetClient(c, authorization.ClientID)
+		if err != nil {
+			if !errors.IsCode(err, code.ErrOAuthClientNotExist) {
+				log.L(c).Errorf("get oauth client failed: %+v", err)
+			}
+
+			core.WriteResponse(c, nil, core.WithError(err))
+			return
+		}
+
+		core.WriteResponse(c, gin.H{
+			"user_code": service.FormatUserCode(authorization.UserCode),
+			"client":    gin.H{"client_id": client.ClientID, "name": client.Name},
+		})
+	}
+}
+
+// deviceDecisionHandler 记录用户对设备授权请求的决定，设备下一次轮询时获得结果。
+func deviceDecisionHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var decision deviceDecision
+		if err := c.ShouldBindJSON(&decision); err != nil {
+			core.WriteResponse(
+				c,
+				validator.ParseValidationError(err),
+				core.WithError(errors.Code(code.ErrValidation, err.Error())),
+			)
+			return
+		}
+
+		srv := service.NewService(store.Client(), storage.Client())
+		eid := auth.ExtractClaimsFromContext(c)["sub"].(string)
+
+		var err error
+		if decision.Approve {
+			err = srv.DeviceAuthorizations().Approve(c, eid, decision.UserCode)
+		} else {
+			err = srv.DeviceAuthorizations().Deny(c, eid, decision.UserCode)
+		}
+		if err != nil {
+			if !errors.IsCode(err, code.ErrDeviceUserCodeInvalid) {
+				log.L(c).Errorf("decide device authorization failed: %+v", err)
+			}
+
+			core.WriteResponse(c, nil, core.WithError(err))
+			return
+		}
+
+		core.WriteResponse(c, nil, core.WithHttpStatus(http.StatusNoContent))
+	}
+}
@@ -0,0 +1,123 @@
+package app
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/eachinchung/component-base/db/options"
+	"github.com/eachinchung/component-base/middleware/auth"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/app/service"
+	"github.com/eachinchung/e-service/internal/app/storage"
+	"github.com/eachinchung/e-service/internal/app/store"
+	"github.com/eachinchung/e-service/internal/pkg/code"
+)
+
+// idpLoginInfo 是第三方平台授权完成后，前端页面提交的授权码与 state。
+type idpLoginInfo struct {
+	Code  string `json:"code"  binding:"required,max=512"`
+	State string `json:"state" binding:"required,max=128"`
+}
+
+// idpBindInfo 是第三方账号首次登录时，绑定或注册账号提交的信息。
+type idpBindInfo struct {
+	Ticket string `json:"identity_ticket" binding:"required"`
+	Phone  string `json:"phone"           binding:"required,len=11,phone"`
+	Code   string `json:"code"            binding:"required,len=6,numeric"`
+}
+
+// identityTicketResponse 返回第三方账号绑定凭证，客户端需要使用它调用 POST /auth/idp/bind 完成登录。
+func identityTicketResponse(c *gin.Context, ticket *service.IdentityTicket) {
+	c.JSON(http.StatusOK, gin.H{
+		"identity_required": true,
+		"identity_ticket":   ticket.Ticket,
+		"identity_expire":   ticket.Expire.Format(time.RFC3339),
+		"provider":          ticket.Provider,
+		"nickname":          ticket.Nickname,
+	})
+}
+
+// idpLoginHandler 使用第三方平台的授权码登录。
+// 第三方账号已绑定用户时与密码登录一样签发 token；否则返回第三方账号绑定凭证。
+func idpLoginHandler(mw *jwtAuth) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var login idpLoginInfo
+		if err := c.ShouldBindJSON(&login); err != nil {
+			mw.Unauthorized(c, http.StatusUnauthorized, auth.ErrMissingLoginValues)
+			return
+		}
+
+		srv := service.NewService(store.Client(), storage.Client())
+		user, ticket, err := srv.Identities().Login(c, c.Param("provider"), login.Code, login.State)
+		if err != nil {
+			if !errors.IsCode(err, code.ErrIdentityProviderNotExist) &&
+				!errors.IsCode(err, code.ErrIdentityStateInvalid) &&
+				!errors.IsCode(err, code.ErrIdentityExchangeFailed) {
+				log.L(c).Errorf("idp login failed: %+v", err)
+			}
+
+			mw.Unauthorized(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		if ticket != nil {
+			identityTicketResponse(c, ticket)
+			return
+		}
+
+		loginHandler(mw, func(*gin.Context) (any, error) { return user, nil }, amrFederated)(c)
+	}
+}
+
+// idpBindAuthenticator 使用短信验证码确认手机号，把首次登录的第三方账号绑定到该手机号的账号上。
+// 手机号尚未注册时自动创建一个无密码的账号。
+func idpBindAuthenticator() func(ctx *gin.Context) (any, error) {
+	return func(c *gin.Context) (any, error) {
+		var login idpBindInfo
+		if err := c.ShouldBindJSON(&login); err != nil {
+			return "", auth.ErrMissingLoginValues
+		}
+
+		srv := service.NewService(store.Client(), storage.Client())
+
+		// 先消费绑定凭证，凭证无效时不会校验验证码，也不会创建账号
+		identity, err := srv.Identities().ConsumeTicket(c, login.Ticket)
+		if err != nil {
+			if !errors.IsCode(err, code.ErrIdentityTicketInvalid) {
+				log.L(c).Errorf("consume identity ticket failed: %+v", err)
+			}
+			return "", err
+		}
+
+		if err := srv.Verifications().VerifySMSCode(c, service.SceneLogin, login.Phone, login.Code); err != nil {
+			smsLoginFailed(c, srv, login.Phone)
+			return "", err
+		}
+
+		user, err := store.Client().User().Get(c, store.Client().DB(), login.Phone, options.WithQuery("phone = ?"))
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if user, err = registerByPhone(c, srv, login.Phone); err != nil {
+				return "", err
+			}
+		case err != nil:
+			log.Errorf("get user information failed: %s", err.Error())
+			return "", auth.ErrFailedAuthentication
+		}
+
+		if err := srv.Identities().Bind(c, identity, user); err != nil {
+			if !errors.IsCode(err, code.ErrIdentityAlreadyLinked) &&
+				!errors.IsCode(err, code.ErrIdentityProviderAlreadyLinked) {
+				log.L(c).Errorf("bind identity failed: %+v", err)
+			}
+			return "", err
+		}
+
+		return user, nil
+	}
+}
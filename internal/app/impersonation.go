@@ -0,0 +1,149 @@
+package app
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/eachinchung/component-base/core"
+	"github.com/eachinchung/component-base/middleware/auth"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/app/config"
+	"github.com/eachinchung/e-service/internal/app/service"
+	"github.com/eachinchung/e-service/internal/app/storage"
+	"github.com/eachinchung/e-service/internal/app/store"
+	"github.com/eachinchung/e-service/internal/app/store/model"
+	"github.com/eachinchung/e-service/internal/pkg/code"
+	"github.com/eachinchung/e-service/internal/pkg/validator"
+)
+
+// impersonateInfo 是超级用户申请模拟登录时提交的信息。
+type impersonateInfo struct {
+	EID    string `json:"eid"    binding:"required,max=32"`
+	Reason string `json:"reason" binding:"required,max=255"`
+}
+
+// actor 返回模拟登录 token 中 act 字段记录的实际操作者，不是模拟登录的 token 时返回空字符串。
+func actor(claims auth.MapClaims) string {
+	act, ok := claims["act"].(map[string]any)
+	if !ok {
+		return ""
+	}
+
+	sub, _ := act["sub"].(string)
+	return sub
+}
+
+// impersonateHandler 为超级用户签发一个代表目标用户的短期 token。
+// token 的 act 字段记录实际操作的超级用户，token 不能刷新，也不能执行 noImpersonation 保护的敏感操作。
+func impersonateHandler(mw *jwtAuth) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body impersonateInfo
+		if err := c.ShouldBindJSON(&body); err != nil {
+			core.WriteResponse(
+				c,
+				validator.ParseValidationError(err),
+				core.WithError(errors.Code(code.ErrValidation, err.Error())),
+			)
+			return
+		}
+
+		operator, _ := auth.ExtractClaimsFromContext(c)["sub"].(string)
+		srv := service.NewService(store.Client(), storage.Client())
+
+		user, err := srv.Impersonations().Authorize(c, operator, body.EID)
+		if err != nil {
+			if !errors.IsCode(err, code.ErrPermissionDenied) &&
+				!errors.IsCode(err, code.ErrImpersonationNotAllowed) &&
+				!errors.IsCode(err, code.ErrUserNotExist) &&
+				!errors.IsCode(err, code.ErrUserStatusIsAbnormal) {
+				log.L(c).Errorf("authorize impersonation failed: %+v", err)
+			}
+
+			core.WriteResponse(c, nil, core.WithError(err))
+			return
+		}
+
+		claims := mw.PayloadFunc(user)
+		claims["act"] = map[string]any{"sub": operator}
+
+		token, expire, err := mw.SignTokenWithTimeout(claims, config.GetConfigIns(nil).ImpersonationOptions.Timeout)
+		if err != nil {
+			log.L(c).Errorf("sign impersonation token failed: %+v", err)
+			core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrFailedTokenCreation, err.Error())))
+			return
+		}
+
+		// 签发记录写入失败时不返回 token，保证每一个模拟登录 token 都有审计日志
+		jti, _ := claims["jti"].(string)
+		if err := srv.Impersonations().Record(c, &model.ImpersonationLogs{
+			OperatorEID: operator,
+			EID:         user.EID,
+			JTI:         jti,
+			Method:      c.Request.Method,
+			Path:        c.Request.URL.Path,
+			Status:      http.StatusOK,
+			IP:          c.ClientIP(),
+			Reason:      body.Reason,
+		}); err != nil {
+			log.L(c).Errorf("record impersonation failed: %+v", err)
+			core.WriteResponse(c, nil, core.WithError(err))
+			return
+		}
+
+		log.L(c).Infof("super user %s impersonated %s: %s", operator, user.EID, body.Reason)
+		c.JSON(http.StatusOK, gin.H{
+			"token":  token,
+			"expire": expire.Format(time.RFC3339),
+			"act":    operator,
+		})
+	}
+}
+
+// noImpersonation 拒绝模拟登录的 token 执行修改密码、两步验证等敏感操作，需要放在 jwt 中间件之后。
+func noImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if actor(auth.ExtractClaimsFromContext(c)) == "" {
+			return
+		}
+
+		core.WriteResponse(
+			c,
+			nil,
+			core.WithError(errors.Code(code.ErrImpersonationForbidden, "impersonation token can not perform sensitive actions")),
+			core.WithAbort(),
+		)
+	}
+}
+
+// impersonationAudit 在请求结束后把模拟登录 token 发起的请求写入审计日志，需要在注册路由之前安装到全局。
+func impersonationAudit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		claims := auth.ExtractClaimsFromContext(c)
+		operator := actor(claims)
+		if operator == "" {
+			return
+		}
+
+		eid, _ := claims["sub"].(string)
+		jti, _ := claims["jti"].(string)
+
+		srv := service.NewService(store.Client(), storage.Client())
+		if err := srv.Impersonations().Record(c, &model.ImpersonationLogs{
+			OperatorEID: operator,
+			EID:         eid,
+			JTI:         jti,
+			Method:      c.Request.Method,
+			Path:        c.Request.URL.Path,
+			Status:      c.Writer.Status(),
+			IP:          c.ClientIP(),
+		}); err != nil {
+			log.L(c).Errorf("record impersonation request failed: %+v", err)
+		}
+	}
+}
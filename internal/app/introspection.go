@@ -0,0 +1,96 @@
+package app
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/eachinchung/component-base/middleware/auth"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/app/service"
+	"github.com/eachinchung/e-service/internal/app/storage"
+	"github.com/eachinchung/e-service/internal/app/store"
+	"github.com/eachinchung/e-service/internal/pkg/code"
+)
+
+// introspectionRequest 是 RFC 7662 定义的 token 自省请求。
+type introspectionRequest struct {
+	Token         string `form:"token"           binding:"required"`
+	TokenTypeHint string `form:"token_type_hint"`
+	ClientID      string `form:"client_id"`
+	ClientSecret  string `form:"client_secret"`
+}
+
+// introspectionHandler 是 RFC 7662 的 token 自省端点，供内部服务校验调用方的 access token。
+// 只有机密应用可以调用，token 签名无效、已过期、已被吊销或用户状态异常时返回 active 为 false。
+func introspectionHandler(mw *jwtAuth) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req introspectionRequest
+		if err := c.ShouldBind(&req); err != nil {
+			oauthError(c, errors.Code(code.ErrValidation, err.Error()))
+			return
+		}
+
+		srv := service.NewService(store.Client(), storage.Client())
+
+		clientID, clientSecret := req.ClientID, req.ClientSecret
+		if id, secret, ok := c.Request.BasicAuth(); ok {
+			clientID, _ = url.QueryUnescape(id)
+			clientSecret, _ = url.QueryUnescape(secret)
+		}
+
+		client, err := srv.OAuth().AuthenticateClient(c, clientID, clientSecret)
+		if err != nil {
+			oauthError(c, err)
+			return
+		}
+		if !client.Confidential() {
+			oauthError(c, errors.Code(code.ErrOAuthInvalidClient, "public client can not introspect tokens"))
+			return
+		}
+
+		c.Header("Cache-Control", "no-store")
+		c.Header("Pragma", "no-cache")
+
+		claims, ok := mw.parseAccessToken(req.Token)
+		if !ok {
+			c.JSON(http.StatusOK, &service.Introspection{Active: false})
+			return
+		}
+
+		result, err := srv.Tokens().Introspect(c, req.Token, claims)
+		if err != nil {
+			log.L(c).Errorf("introspect token failed: %+v", err)
+			oauthError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+// parseAccessToken 校验 access token 的签名、有效期与受众，ID token 等签发给其他受众的 token 视为无效。
+func (mw *jwtAuth) parseAccessToken(tokenString string) (auth.MapClaims, bool) {
+	token, err := mw.keys.Parse(tokenString)
+	if err != nil {
+		return nil, false
+	}
+
+	mapClaims := token.Claims.(jwt.MapClaims)
+	if !mapClaims.VerifyAudience(APIServerAudience, true) {
+		return nil, false
+	}
+	if _, ok := mapClaims["sub"].(string); !ok {
+		return nil, false
+	}
+
+	claims := auth.MapClaims{}
+	for key, value := range mapClaims {
+		claims[key] = value
+	}
+	return claims, true
+}
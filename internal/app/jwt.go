@@ -0,0 +1,166 @@
+package app
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/eachinchung/component-base/middleware/auth"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/pkg/keyset"
+)
+
+var errTokenInvalid = errors.New("token is invalid")
+
+// jwtAuth 使用 keyset 中的密钥签发与校验 jwt，签名密钥按照 kid 选择，支持密钥轮换。
+type jwtAuth struct {
+	// Realm 展示给用户的领域名称
+	Realm string
+
+	// Timeout token 的有效期
+	Timeout time.Duration
+
+	// Authenticator 根据登录信息验证用户身份，返回的数据会传给 PayloadFunc。
+	Authenticator func(c *gin.Context) (any, error)
+
+	// PayloadFunc 返回签发 token 时需要写入的 claims。
+	PayloadFunc func(data any) auth.MapClaims
+
+	// Unauthorized 处理身份验证失败的请求。
+	Unauthorized func(c *gin.Context, code int, err error)
+
+	// TimeFunc 返回当前时间。
+	TimeFunc func() time.Time
+
+	keys *keyset.KeySet
+}
+
+// MiddlewareFunc 返回校验 jwt 的中间件，只接受受众为 APIServerAudience 的 token，校验通过后 claims 可以通过 auth.ExtractClaimsFromContext 获取。
+func (mw *jwtAuth) MiddlewareFunc() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, err := mw.GetClaimsFromJWT(c)
+		if err != nil {
+			mw.unauthorized(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		if claims["exp"] == nil {
+			mw.unauthorized(c, http.StatusBadRequest, auth.ErrMissingExpField)
+			return
+		}
+
+		if _, ok := claims["exp"].(float64); !ok {
+			mw.unauthorized(c, http.StatusBadRequest, auth.ErrWrongFormatOfExp)
+			return
+		}
+
+		// ID token 等签发给其他受众的 token 与 access token 使用同一组密钥签名，不能作为 access token 使用
+		if !jwt.MapClaims(claims).VerifyAudience(APIServerAudience, true) {
+			mw.unauthorized(c, http.StatusUnauthorized, errTokenInvalid)
+			return
+		}
+
+		c.Set("JWT_PAYLOAD", claims)
+	}
+}
+
+// GetClaimsFromJWT 从请求中解析并校验 jwt，token 可以放在 Authorization 请求头或 token 查询参数中。
+func (mw *jwtAuth) GetClaimsFromJWT(c *gin.Context) (auth.MapClaims, error) {
+	tokenString, err := tokenFromRequest(c)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := mw.keys.Parse(tokenString)
+	if err != nil {
+		return nil, parseError(err)
+	}
+
+	claims := auth.MapClaims{}
+	for key, value := range token.Claims.(jwt.MapClaims) {
+		claims[key] = value
+	}
+
+	if sub, ok := claims["sub"]; ok {
+		c.Set(log.KeyEID, sub)
+	}
+	c.Set("JWT_TOKEN", tokenString)
+
+	return claims, nil
+}
+
+// SignToken 使用当前的签名密钥签发 token，并补充 exp 与 orig_iat 字段。
+func (mw *jwtAuth) SignToken(claims auth.MapClaims) (string, time.Time, error) {
+	return mw.SignTokenWithTimeout(claims, mw.Timeout)
+}
+
+// SignTokenWithTimeout 与 SignToken 相同，但是使用 timeout 作为 token 的有效期。
+func (mw *jwtAuth) SignTokenWithTimeout(claims auth.MapClaims, timeout time.Duration) (string, time.Time, error) {
+	now := mw.TimeFunc()
+	expire := now.Add(timeout)
+
+	mapClaims := jwt.MapClaims{}
+	for key, value := range claims {
+		mapClaims[key] = value
+	}
+	mapClaims["exp"] = expire.Unix()
+	mapClaims["orig_iat"] = now.Unix()
+
+	tokenString, err := mw.keys.Sign(mapClaims)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return tokenString, expire, nil
+}
+
+func (mw *jwtAuth) unauthorized(c *gin.Context, code int, err error) {
+	c.Header("WWW-Authenticate", "JWT realm="+mw.Realm)
+	mw.Unauthorized(c, code, err)
+}
+
+func tokenFromRequest(c *gin.Context) (string, error) {
+	if authHeader := c.Request.Header.Get("Authorization"); authHeader != "" {
+		parts := strings.SplitN(authHeader, " ", 2)
+		if !(len(parts) == 2 && parts[0] == "Bearer") {
+			return "", auth.ErrInvalidAuthHeader
+		}
+		return parts[1], nil
+	}
+
+	if token := c.Query("token"); token != "" {
+		return token, nil
+	}
+
+	return "", auth.ErrEmptyAuthHeader
+}
+
+// parseError 将 jwt 的校验错误转换为 auth 包中定义的错误。
+func parseError(err error) error {
+	var validationErr *jwt.ValidationError
+	if !errors.As(err, &validationErr) {
+		return errTokenInvalid
+	}
+
+	switch {
+	case validationErr.Errors&jwt.ValidationErrorExpired != 0:
+		return auth.ErrExpiredToken
+	case errors.Is(validationErr.Inner, keyset.ErrInvalidSigningAlgorithm):
+		return auth.ErrInvalidSigningAlgorithm
+	}
+
+	return errTokenInvalid
+}
+
+// jwksHandler 发布用于校验 token 的公钥，其它服务无需持有密钥即可校验 token。
+func jwksHandler(keys *keyset.KeySet) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, keys.JWKS())
+	}
+}
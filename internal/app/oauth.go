@@ -0,0 +1,424 @@
+package app
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/eachinchung/component-base/core"
+	"github.com/eachinchung/component-base/middleware/auth"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/app/service"
+	"github.com/eachinchung/e-service/internal/app/storage"
+	"github.com/eachinchung/e-service/internal/app/store"
+	"github.com/eachinchung/e-service/internal/app/store/model"
+	"github.com/eachinchung/e-service/internal/pkg/code"
+	"github.com/eachinchung/e-service/internal/pkg/validator"
+)
+
+// OAuth 2.0 授权类型。
+const (
+	grantTypeAuthorizationCode = "authorization_code"
+	grantTypeRefreshToken      = "refresh_token"
+	grantTypeDeviceCode        = "urn:ietf:params:oauth:grant-type:device_code"
+	grantTypeClientCredentials = "client_credentials"
+)
+
+// oauthErrors 将错误码转换为 RFC 6749 定义的错误。
+var oauthErrors = map[int]string{
+	code.ErrValidation:                   "invalid_request",
+	code.ErrOAuthInvalidRequest:          "invalid_request",
+	code.ErrOAuthInvalidClient:           "invalid_client",
+	code.ErrOAuthInvalidGrant:            "invalid_grant",
+	code.ErrOAuthInvalidScope:            "invalid_scope",
+	code.ErrOAuthUnsupportedGrantType:    "unsupported_grant_type",
+	code.ErrOAuthUnsupportedResponseType: "unsupported_response_type",
+	code.ErrOAuthAccessDenied:            "access_denied",
+	code.ErrRefreshTokenInvalid:          "invalid_grant",
+	code.ErrRefreshTokenReused:           "invalid_grant",
+	code.ErrDeviceAuthorizationPending:   "authorization_pending",
+	code.ErrDeviceSlowDown:               "slow_down",
+	code.ErrDeviceCodeExpired:            "expired_token",
+	code.ErrDeviceClientUnauthorized:     "unauthorized_client",
+}
+
+// authorizeRequest 是应用发起的授权请求。
+type authorizeRequest struct {
+	ResponseType        string `form:"response_type"         json:"response_type"         binding:"required"`
+	ClientID            string `form:"client_id"             json:"client_id"             binding:"required"`
+	RedirectURI         string `form:"redirect_uri"          json:"redirect_uri"          binding:"required"`
+	Scope               string `form:"scope"                 json:"scope"`
+	State               string `form:"state"                 json:"state"`
+	CodeChallenge       string `form:"code_challenge"        json:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method" json:"code_challenge_method"`
+	Nonce               string `form:"nonce"                 json:"nonce"                 binding:"max=255"`
+}
+
+// authorizeDecision 是用户对授权请求的决定。
+type authorizeDecision struct {
+	authorizeRequest
+	Approve bool `json:"approve"`
+}
+
+// tokenRequest 是应用向 token 端点发起的请求，使用 application/x-www-form-urlencoded 编码。
+type tokenRequest struct {
+	GrantType    string `form:"grant_type"    binding:"required"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	CodeVerifier string `form:"code_verifier"`
+	RefreshToken string `form:"refresh_token"`
+	DeviceCode   string `form:"device_code"`
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+
+	ClientAssertionType string `form:"client_assertion_type"`
+	ClientAssertion     string `form:"client_assertion"`
+}
+
+// authorizeHandler 校验授权请求。用户已经授予过申请的权限时直接签发授权码并返回回调地址，
+// 否则返回应用信息与申请的权限，由前端向用户展示授权页面。
+func authorizeHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req authorizeRequest
+		if err := c.ShouldBindQuery(&req); err != nil {
+			core.WriteResponse(
+				c,
+				validator.ParseValidationError(err),
+				core.WithError(errors.Code(code.ErrValidation, err.Error())),
+			)
+			return
+		}
+
+		srv := service.NewService(store.Client(), storage.Client())
+		eid := auth.ExtractClaimsFromContext(c)["sub"].(string)
+
+		client, scopes, err := prepareAuthorization(c, srv, &req)
+		if err != nil {
+			authorizeError(c, &req, err)
+			return
+		}
+
+		consented, err := srv.OAuth().HasConsent(c, eid, client.ClientID, scopes)
+		if err != nil {
+			log.L(c).Errorf("check oauth consent failed: %+v", err)
+			core.WriteResponse(c, nil, core.WithError(err))
+			return
+		}
+
+		if !consented {
+			core.WriteResponse(c, gin.H{
+				"consent_required": true,
+				"client":           gin.H{"client_id": client.ClientID, "name": client.Name},
+				"scopes":           scopes,
+			})
+			return
+		}
+
+		authorizeRedirect(c, srv, &req, eid, scopes)
+	}
+}
+
+// authorizeDecisionHandler 记录用户对授权请求的决定，同意时签发授权码，并返回携带结果的回调地址。
+func authorizeDecisionHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var decision authorizeDecision
+		if err := c.ShouldBindJSON(&decision); err != nil {
+			core.WriteResponse(
+				c,
+				validator.ParseValidationError(err),
+				core.WithError(errors.Code(code.ErrValidation, err.Error())),
+			)
+			return
+		}
+
+		req := &decision.authorizeRequest
+		srv := service.NewService(store.Client(), storage.Client())
+		eid := auth.ExtractClaimsFromContext(c)["sub"].(string)
+
+		client, scopes, err := prepareAuthorization(c, srv, req)
+		if err != nil {
+			authorizeError(c, req, err)
+			return
+		}
+
+		if !decision.Approve {
+			authorizeError(c, req, errors.Code(code.ErrOAuthAccessDenied, "user denied the request"))
+			return
+		}
+
+		if err := srv.OAuth().SaveConsent(c, eid, client.ClientID, scopes); err != nil {
+			log.L(c).Errorf("save oauth consent failed: %+v", err)
+			core.WriteResponse(c, nil, core.WithError(err))
+			return
+		}
+
+		authorizeRedirect(c, srv, req, eid, scopes)
+	}
+}
+
+// prepareAuthorization 校验授权请求，返回应用与申请的权限。
+func prepareAuthorization(c *gin.Context, srv service.Service, req *authorizeRequest) (*model.OAuthClients, []string, error) {
+	client, err := srv.OAuth().GetClient(c, req.ClientID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !client.HasRedirectURI(req.RedirectURI) {
+		return nil, nil, errors.Code(code.ErrOAuthInvalidRedirectURI, "redirect uri not registered")
+	}
+
+	if req.ResponseType != "code" {
+		return nil, nil, errors.Code(code.ErrOAuthUnsupportedResponseType, "only code response type is supported")
+	}
+
+	if req.CodeChallenge == "" && !client.Confidential() {
+		return nil, nil, errors.Code(code.ErrOAuthInvalidRequest, "public client must use pkce")
+	}
+
+	if req.CodeChallenge != "" && req.CodeChallengeMethod != service.CodeChallengeMethodS256 {
+		return nil, nil, errors.Code(code.ErrOAuthInvalidRequest, "code challenge method must be S256")
+	}
+
+	scopes, err := srv.OAuth().ValidateScopes(client, req.Scope)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return client, scopes, nil
+}
+
+// authorizeRedirect 签发授权码并返回携带授权码的回调地址。
+func authorizeRedirect(c *gin.Context, srv service.Service, req *authorizeRequest, eid string, scopes []string) {
+	authCode, err := srv.OAuth().CreateAuthorizationCode(c, &service.AuthorizationCode{
+		ClientID:            req.ClientID,
+		EID:                 eid,
+		RedirectURI:         req.RedirectURI,
+		Scope:               strings.Join(scopes, " "),
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+		Nonce:               req.Nonce,
+	})
+	if err != nil {
+		log.L(c).Errorf("create authorization code failed: %+v", err)
+		core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrUnknown, err.Error())))
+		return
+	}
+
+	params := url.Values{"code": {authCode}}
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+	core.WriteResponse(c, gin.H{"redirect_uri": withQuery(req.RedirectURI, params)})
+}
+
+// authorizeError 返回授权请求的错误。应用或回调地址不合法时不能跳转回应用，直接返回错误，
+// 其它错误通过回调地址告知应用。
+func authorizeError(c *gin.Context, req *authorizeRequest, err error) {
+	name, ok := oauthErrors[errors.ParseCoder(err).Code()]
+	if !ok {
+		core.WriteResponse(c, nil, core.WithError(err))
+		return
+	}
+
+	params := url.Values{"error": {name}, "error_description": {errors.ParseCoder(err).String()}}
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+	core.WriteResponse(c, gin.H{"redirect_uri": withQuery(req.RedirectURI, params)})
+}
+
+// oauthTokenHandler 是 OAuth 2.0 的 token 端点，支持授权码、refresh token 与设备码三种授权类型，
+// 以及服务账号使用的 client credentials 授权类型。
+func oauthTokenHandler(mw *jwtAuth) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req tokenRequest
+		if err := c.ShouldBind(&req); err != nil {
+			oauthError(c, errors.Code(code.ErrValidation, err.Error()))
+			return
+		}
+
+		srv := service.NewService(store.Client(), storage.Client())
+
+		// client credentials 只对服务账号开放，服务账号不是第三方应用
+		if req.GrantType == grantTypeClientCredentials {
+			clientCredentialsGrant(c, mw, srv, &req)
+			return
+		}
+
+		clientID, clientSecret := clientCredentials(c, req.ClientID, req.ClientSecret)
+		client, err := srv.OAuth().AuthenticateClient(c, clientID, clientSecret)
+		if err != nil {
+			oauthError(c, err)
+			return
+		}
+
+		if req.GrantType == grantTypeDeviceCode {
+			deviceCodeGrant(c, mw, srv, client.ClientID, req.DeviceCode)
+			return
+		}
+
+		var record *service.RefreshToken
+		var refreshToken, nonce string
+		var refreshExpire time.Time
+
+		switch req.GrantType {
+		case grantTypeAuthorizationCode:
+			var authCode *service.AuthorizationCode
+			authCode, err = srv.OAuth().ExchangeAuthorizationCode(c, req.Code, client.ClientID, req.RedirectURI, req.CodeVerifier)
+			if err != nil {
+				oauthError(c, err)
+				return
+			}
+
+			record = &service.RefreshToken{
+				EID:      authCode.EID,
+				FamilyID: authCode.FamilyID,
+				ClientID: authCode.ClientID,
+				Scope:    authCode.Scope,
+			}
+			nonce = authCode.Nonce
+			if refreshToken, refreshExpire, err = srv.Tokens().CreateRefreshToken(c, record); err == nil {
+				err = createSession(c, srv, record.EID, record.FamilyID, record.ClientID)
+			}
+		case grantTypeRefreshToken:
+			record, refreshToken, refreshExpire, err = srv.Tokens().RotateRefreshToken(c, req.RefreshToken, client.ClientID)
+			if err == nil {
+				srv.Sessions().Touch(c, record.FamilyID)
+				srv.SecurityEvents().Record(c, model.NewSecurityEvent(c, record.EID, model.SecurityEventTokenRefreshed, record.ClientID))
+			}
+		default:
+			err = errors.Code(code.ErrOAuthUnsupportedGrantType, "unsupported grant type")
+		}
+		if err != nil {
+			oauthError(c, err)
+			return
+		}
+
+		user, err := srv.Users().GetByEID(c, record.EID)
+		if err != nil {
+			oauthError(c, errors.Code(code.ErrOAuthInvalidGrant, err.Error()))
+			return
+		}
+
+		if user.State != model.StatusNormal {
+			oauthError(c, errors.Code(code.ErrOAuthInvalidGrant, "user has been "+user.State.Msg()))
+			return
+		}
+
+		token, expire, err := signClientToken(mw, user, record)
+		if err != nil {
+			oauthError(c, err)
+			return
+		}
+
+		var idToken string
+		if service.HasScope(strings.Fields(record.Scope), scopeOpenID) {
+			if idToken, err = signIDToken(mw, user, record, nonce); err != nil {
+				oauthError(c, err)
+				return
+			}
+		}
+
+		oauthTokenResponse(c, &tokenPair{
+			Token:         token,
+			Expire:        expire,
+			RefreshToken:  refreshToken,
+			RefreshExpire: refreshExpire,
+		}, record.Scope, idToken)
+	}
+}
+
+// clientCredentials 返回应用的 ID 与密钥，优先使用 HTTP Basic 认证，否则使用请求体中的参数。
+func clientCredentials(c *gin.Context, clientID, clientSecret string) (string, string) {
+	if id, secret, ok := c.Request.BasicAuth(); ok {
+		clientID, _ = url.QueryUnescape(id)
+		clientSecret, _ = url.QueryUnescape(secret)
+	}
+	return clientID, clientSecret
+}
+
+// signClientToken 为第三方应用签发 access token，token 中记录应用与授予的权限。
+func signClientToken(mw *jwtAuth, user *model.Users, record *service.RefreshToken) (string, time.Time, error) {
+	claims := mw.PayloadFunc(user)
+	claims["fid"] = record.FamilyID
+	claims["client_id"] = record.ClientID
+	claims["scope"] = record.Scope
+
+	return mw.SignToken(claims)
+}
+
+// oauthTokenResponse 按照 RFC 6749 的格式返回 token，申请了 openid 权限时同时返回 ID token。
+func oauthTokenResponse(c *gin.Context, tokens *tokenPair, scope, idToken string) {
+	rsp := gin.H{
+		"access_token": tokens.Token,
+		"token_type":   "Bearer",
+		"expires_in":   int64(time.Until(tokens.Expire).Seconds()),
+	}
+	if tokens.RefreshToken != "" {
+		rsp["refresh_token"] = tokens.RefreshToken
+	}
+	if scope != "" {
+		rsp["scope"] = scope
+	}
+	if idToken != "" {
+		rsp["id_token"] = idToken
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+	c.JSON(http.StatusOK, rsp)
+}
+
+// oauthError 按照 RFC 6749 的格式返回 token 端点的错误。
+func oauthError(c *gin.Context, err error) {
+	coder := errors.ParseCoder(err)
+	name, ok := oauthErrors[coder.Code()]
+
+	status := http.StatusBadRequest
+	switch {
+	case !ok:
+		log.L(c).Errorf("oauth token request failed: %+v", err)
+		name, status = "server_error", http.StatusInternalServerError
+	case name == "invalid_client":
+		c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+		status = http.StatusUnauthorized
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+	c.JSON(status, gin.H{"error": name, "error_description": coder.String()})
+}
+
+// firstPartyOnly 拒绝签发给第三方应用的 token 访问只对用户本人开放的接口。
+func firstPartyOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := auth.ExtractClaimsFromContext(c)["client_id"]; ok {
+			core.WriteResponse(
+				c,
+				nil,
+				core.WithError(errors.Code(code.ErrPermissionDenied, "token issued to a client")),
+				core.WithAbort(),
+			)
+		}
+	}
+}
+
+// withQuery 将 params 追加到 rawURL 的查询参数中。
+func withQuery(rawURL string, params url.Values) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	query := u.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
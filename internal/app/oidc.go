@@ -0,0 +1,139 @@
+package app
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/eachinchung/component-base/core"
+	"github.com/eachinchung/component-base/middleware/auth"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/app/config"
+	"github.com/eachinchung/e-service/internal/app/service"
+	"github.com/eachinchung/e-service/internal/app/storage"
+	"github.com/eachinchung/e-service/internal/app/store"
+	"github.com/eachinchung/e-service/internal/app/store/model"
+	"github.com/eachinchung/e-service/internal/pkg/code"
+	"github.com/eachinchung/e-service/internal/pkg/keyset"
+)
+
+// OpenID Connect 定义的权限。
+const (
+	scopeOpenID  = "openid"
+	scopeProfile = "profile"
+	scopePhone   = "phone"
+)
+
+// oidcIssuer 返回 OpenID Connect 的签发者地址，未配置时使用 api 的域名。
+func oidcIssuer() string {
+	if issuer := config.GetConfigIns(nil).OAuthOptions.Issuer; issuer != "" {
+		return strings.TrimSuffix(issuer, "/")
+	}
+	return "https://" + APIServerAudience
+}
+
+// discoveryHandler 发布 OpenID Connect 的服务发现文档。
+func discoveryHandler(keys *keyset.KeySet) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		issuer := oidcIssuer()
+
+		authorizationEndpoint := config.GetConfigIns(nil).OAuthOptions.AuthorizationEndpoint
+		if authorizationEndpoint == "" {
+			authorizationEndpoint = issuer + "/oauth/authorize"
+		}
+
+		c.Header("Cache-Control", "public, max-age=3600")
+		c.JSON(http.StatusOK, gin.H{
+			"issuer":                                issuer,
+			"authorization_endpoint":                authorizationEndpoint,
+			"token_endpoint":                        issuer + "/oauth/token",
+			"device_authorization_endpoint":         issuer + "/oauth/device_authorization",
+			"userinfo_endpoint":                     issuer + "/userinfo",
+			"introspection_endpoint":                issuer + "/auth/introspect",
+			"jwks_uri":                              issuer + "/.well-known/jwks.json",
+			"scopes_supported":                      config.GetConfigIns(nil).OAuthOptions.Scopes,
+			"response_types_supported":              []string{"code"},
+			"grant_types_supported":                 []string{grantTypeAuthorizationCode, grantTypeRefreshToken, grantTypeDeviceCode, grantTypeClientCredentials},
+			"subject_types_supported":               []string{"public"},
+			"id_token_signing_alg_values_supported": keys.Algorithms(),
+			"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "private_key_jwt", "none"},
+			"code_challenge_methods_supported":      []string{service.CodeChallengeMethodS256},
+			"claims_supported": []string{
+				"iss", "sub", "aud", "exp", "iat", "nonce",
+				"nickname", "picture", "phone_number", "phone_number_verified",
+			},
+		})
+	}
+}
+
+// signIDToken 为应用签发 ID token，token 中的用户信息由授予的权限决定。
+func signIDToken(mw *jwtAuth, user *model.Users, record *service.RefreshToken, nonce string) (string, error) {
+	now := mw.TimeFunc()
+
+	claims := jwt.MapClaims{
+		"iss": oidcIssuer(),
+		"sub": user.EID,
+		"aud": record.ClientID,
+		"iat": now.Unix(),
+		"exp": now.Add(mw.Timeout).Unix(),
+	}
+	if nonce != "" {
+		claims["nonce"] = nonce
+	}
+
+	for key, value := range userClaims(user, strings.Fields(record.Scope)) {
+		claims[key] = value
+	}
+
+	return mw.keys.Sign(claims)
+}
+
+// userinfoHandler 返回 access token 所属用户的信息，返回的字段由授予的权限决定。
+func userinfoHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := auth.ExtractClaimsFromContext(c)
+
+		scope, _ := claims["scope"].(string)
+		scopes := strings.Fields(scope)
+		if !service.HasScope(scopes, scopeOpenID) {
+			c.Header("WWW-Authenticate", `Bearer error="insufficient_scope", scope="openid"`)
+			core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrPermissionDenied, "openid scope required")))
+			return
+		}
+
+		srv := service.NewService(store.Client(), storage.Client())
+		user, err := srv.Users().GetByEID(c, claims["sub"].(string))
+		if err != nil {
+			log.L(c).Errorf("get user information failed: %+v", err)
+			core.WriteResponse(c, nil, core.WithError(err))
+			return
+		}
+
+		info := userClaims(user, scopes)
+		info["sub"] = user.EID
+		core.WriteResponse(c, info)
+	}
+}
+
+// userClaims 返回 scopes 允许公开的用户信息。
+func userClaims(user *model.Users, scopes []string) gin.H {
+	claims := gin.H{}
+
+	if service.HasScope(scopes, scopeProfile) {
+		claims["nickname"] = user.Nickname
+		if user.Avatar.Valid {
+			claims["picture"] = user.Avatar.String
+		}
+	}
+
+	if service.HasScope(scopes, scopePhone) {
+		claims["phone_number"] = "+86" + user.Phone
+		claims["phone_number_verified"] = true
+	}
+
+	return claims
+}
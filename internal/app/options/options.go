@@ -3,6 +3,7 @@ package options
 //goland:noinspection SpellCheckingInspection
 import (
 	"encoding/json"
+	"fmt"
 
 	"github.com/eachinchung/component-base/cli/flag"
 	baseoptions "github.com/eachinchung/component-base/options"
@@ -12,21 +13,47 @@ import (
 )
 
 type Options struct {
-	GenericServerRunOptions *options.ServerRunOptions    `json:"server"        mapstructure:"server"`
-	TencentCloudOptions     *options.TencentCloudOptions `json:"tencent-cloud" mapstructure:"tencentcloud"`
-	PostgresOptions         *baseoptions.PostgresOptions `json:"postgres"      mapstructure:"postgres"`
-	RedisOptions            *baseoptions.RedisOptions    `json:"redis"         mapstructure:"redis"`
-	JWTOptions              *baseoptions.JWTOptions      `json:"jwt"           mapstructure:"jwt"`
-	CasbinOptions           *baseoptions.CasbinOptions   `json:"casbin"        mapstructure:"casbin"`
-	LogOptions              *log.Options                 `json:"log"           mapstructure:"log"`
+	GenericServerRunOptions *options.ServerRunOptions      `json:"server"          mapstructure:"server"`
+	TencentCloudOptions     *options.TencentCloudOptions   `json:"tencent-cloud"   mapstructure:"tencentcloud"`
+	SMSOptions              *options.SMSOptions            `json:"sms"             mapstructure:"sms"`
+	MailOptions             *options.MailOptions           `json:"mail"            mapstructure:"mail"`
+	IDPOptions              *options.IDPOptions            `json:"idp"             mapstructure:"idp"`
+	ImpersonationOptions    *options.ImpersonationOptions  `json:"impersonation"   mapstructure:"impersonation"`
+	QRLoginOptions          *options.QRLoginOptions        `json:"qr-login"        mapstructure:"qr-login"`
+	PostgresOptions         *baseoptions.PostgresOptions   `json:"postgres"        mapstructure:"postgres"`
+	RedisOptions            *baseoptions.RedisOptions      `json:"redis"           mapstructure:"redis"`
+	JWTOptions              *baseoptions.JWTOptions        `json:"jwt"             mapstructure:"jwt"`
+	JWTKeyOptions           *options.JWTKeyOptions         `json:"jwt-keys"        mapstructure:"jwt-keys"`
+	LoginLimitOptions       *options.LoginLimitOptions     `json:"login-limit"     mapstructure:"login-limit"`
+	MFAOptions              *options.MFAOptions            `json:"mfa"             mapstructure:"mfa"`
+	OAuthOptions            *options.OAuthOptions          `json:"oauth"           mapstructure:"oauth"`
+	APIKeyOptions           *options.APIKeyOptions         `json:"api-key"         mapstructure:"api-key"`
+	PasswordResetOptions    *options.PasswordResetOptions  `json:"password-reset"  mapstructure:"password-reset"`
+	PasswordHashOptions     *options.PasswordHashOptions   `json:"password-hash"   mapstructure:"password-hash"`
+	PasswordPolicyOptions   *options.PasswordPolicyOptions `json:"password-policy" mapstructure:"password-policy"`
+	CasbinOptions           *baseoptions.CasbinOptions     `json:"casbin"          mapstructure:"casbin"`
+	LogOptions              *log.Options                   `json:"log"             mapstructure:"log"`
 }
 
 func (o Options) Flags() (fss flag.NamedFlagSets) {
 	o.GenericServerRunOptions.AddFlags(fss.FlagSet("server"))
 	o.TencentCloudOptions.AddFlags(fss.FlagSet("tencent-cloud"))
+	o.SMSOptions.AddFlags(fss.FlagSet("sms"))
+	o.MailOptions.AddFlags(fss.FlagSet("mail"))
+	o.IDPOptions.AddFlags(fss.FlagSet("idp"))
+	o.ImpersonationOptions.AddFlags(fss.FlagSet("impersonation"))
+	o.QRLoginOptions.AddFlags(fss.FlagSet("qr-login"))
 	o.PostgresOptions.AddFlags(fss.FlagSet("postgres"))
 	o.RedisOptions.AddFlags(fss.FlagSet("rides"))
 	o.JWTOptions.AddFlags(fss.FlagSet("jwt"))
+	o.JWTKeyOptions.AddFlags(fss.FlagSet("jwt-keys"))
+	o.LoginLimitOptions.AddFlags(fss.FlagSet("login-limit"))
+	o.MFAOptions.AddFlags(fss.FlagSet("mfa"))
+	o.OAuthOptions.AddFlags(fss.FlagSet("oauth"))
+	o.APIKeyOptions.AddFlags(fss.FlagSet("api-key"))
+	o.PasswordResetOptions.AddFlags(fss.FlagSet("password-reset"))
+	o.PasswordHashOptions.AddFlags(fss.FlagSet("password-hash"))
+	o.PasswordPolicyOptions.AddFlags(fss.FlagSet("password-policy"))
 	o.CasbinOptions.AddFlags(fss.FlagSet("casbin"))
 	o.LogOptions.AddFlags(fss.FlagSet("logs"))
 
@@ -38,9 +65,26 @@ func (o *Options) Validate() []error {
 
 	errs = append(errs, o.GenericServerRunOptions.Validate()...)
 	errs = append(errs, o.TencentCloudOptions.Validate()...)
+	errs = append(errs, o.SMSOptions.Validate()...)
+	errs = append(errs, o.MailOptions.Validate()...)
+	errs = append(errs, o.IDPOptions.Validate()...)
+	errs = append(errs, o.ImpersonationOptions.Validate()...)
+	errs = append(errs, o.QRLoginOptions.Validate()...)
 	errs = append(errs, o.PostgresOptions.Validate()...)
 	errs = append(errs, o.RedisOptions.Validate()...)
 	errs = append(errs, o.JWTOptions.Validate()...)
+	errs = append(errs, o.JWTKeyOptions.Validate()...)
+	errs = append(errs, o.LoginLimitOptions.Validate()...)
+	errs = append(errs, o.MFAOptions.Validate()...)
+	errs = append(errs, o.OAuthOptions.Validate()...)
+	// ID token 需要由第三方应用使用公钥校验，不能使用服务端密钥以 HS512 签名
+	if o.OAuthOptions.OpenIDEnabled() && len(o.JWTKeyOptions.Keys) == 0 {
+		errs = append(errs, fmt.Errorf("--oauth.scopes 包含 openid 时必须通过 --jwt-keys.keys 配置非对称签名密钥"))
+	}
+	errs = append(errs, o.APIKeyOptions.Validate()...)
+	errs = append(errs, o.PasswordResetOptions.Validate()...)
+	errs = append(errs, o.PasswordHashOptions.Validate()...)
+	errs = append(errs, o.PasswordPolicyOptions.Validate()...)
 	errs = append(errs, o.CasbinOptions.Validate()...)
 	errs = append(errs, o.LogOptions.Validate()...)
 
@@ -51,9 +95,22 @@ func NewOptions() *Options {
 	return &Options{
 		GenericServerRunOptions: options.NewServerRunOptions(),
 		TencentCloudOptions:     options.NewTencentCloudOptions(),
+		SMSOptions:              options.NewSMSOptions(),
+		MailOptions:             options.NewMailOptions(),
+		IDPOptions:              options.NewIDPOptions(),
+		ImpersonationOptions:    options.NewImpersonationOptions(),
+		QRLoginOptions:          options.NewQRLoginOptions(),
 		PostgresOptions:         baseoptions.NewPostgresOptions(),
 		RedisOptions:            baseoptions.NewRedisOptions(),
 		JWTOptions:              baseoptions.NewJWTOptions(),
+		JWTKeyOptions:           options.NewJWTKeyOptions(),
+		LoginLimitOptions:       options.NewLoginLimitOptions(),
+		MFAOptions:              options.NewMFAOptions(),
+		OAuthOptions:            options.NewOAuthOptions(),
+		APIKeyOptions:           options.NewAPIKeyOptions(),
+		PasswordResetOptions:    options.NewPasswordResetOptions(),
+		PasswordHashOptions:     options.NewPasswordHashOptions(),
+		PasswordPolicyOptions:   options.NewPasswordPolicyOptions(),
 		CasbinOptions:           baseoptions.NewCasbinOptions(),
 		LogOptions:              log.NewOptions(),
 	}
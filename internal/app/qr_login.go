@@ -0,0 +1,125 @@
+package app
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/eachinchung/component-base/core"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/app/config"
+	"github.com/eachinchung/e-service/internal/app/service"
+	"github.com/eachinchung/e-service/internal/app/storage"
+	"github.com/eachinchung/e-service/internal/app/store"
+	"github.com/eachinchung/e-service/internal/app/store/model"
+	"github.com/eachinchung/e-service/internal/pkg/code"
+	"github.com/eachinchung/e-service/internal/pkg/validator"
+)
+
+type qrLoginUri struct {
+	Code string `uri:"code" binding:"required"`
+}
+
+// qrLoginPollQuery 是浏览器查询二维码状态时提交的信息，state 为浏览器已知的状态，状态变化后立即返回。
+type qrLoginPollQuery struct {
+	Ticket string `form:"ticket" binding:"required"`
+	State  string `form:"state"`
+}
+
+// qrLoginCreateHandler 创建登录二维码，code 用于生成二维码，ticket 由浏览器保存，用于查询状态与领取 token。
+func qrLoginCreateHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		srv := service.NewService(store.Client(), storage.Client())
+		login, err := srv.QRLogins().Create(c, c.ClientIP(), model.Truncate(c.Request.UserAgent(), 255))
+		if err != nil {
+			log.L(c).Errorf("create qr login failed: %+v", err)
+			core.WriteResponse(c, nil, core.WithError(err))
+			return
+		}
+
+		core.WriteResponse(c, gin.H{
+			"code":   login.Code,
+			"ticket": login.Ticket,
+			"expire": login.Expire.Format(time.RFC3339),
+		})
+	}
+}
+
+// qrLoginPollHandler 以长轮询的方式查询二维码状态，状态与浏览器已知的状态相同时最多等待 poll-timeout。
+// 用户在手机上确认登录后，与密码登录一样签发 token。
+func qrLoginPollHandler(mw *jwtAuth) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var uri qrLoginUri
+		if err := c.ShouldBindUri(&uri); err != nil {
+			core.WriteResponse(
+				c,
+				validator.ParseValidationError(err),
+				core.WithError(errors.Code(code.ErrValidation, err.Error())),
+			)
+			return
+		}
+
+		var query qrLoginPollQuery
+		if err := c.ShouldBindQuery(&query); err != nil {
+			core.WriteResponse(
+				c,
+				validator.ParseValidationError(err),
+				core.WithError(errors.Code(code.ErrValidation, err.Error())),
+			)
+			return
+		}
+
+		opts := config.GetConfigIns(nil).QRLoginOptions
+		srv := service.NewService(store.Client(), storage.Client())
+
+		deadline := time.NewTimer(opts.PollTimeout)
+		defer deadline.Stop()
+		ticker := time.NewTicker(opts.PollInterval)
+		defer ticker.Stop()
+
+		for {
+			login, err := srv.QRLogins().Status(c, uri.Code, query.Ticket)
+			if err != nil {
+				if !errors.IsCode(err, code.ErrQRLoginInvalid) {
+					log.L(c).Errorf("get qr login status failed: %+v", err)
+				}
+
+				core.WriteResponse(c, nil, core.WithError(err))
+				return
+			}
+
+			if login.State == service.QRLoginConfirmed {
+				loginHandler(mw, qrAuthenticator(srv, uri.Code, query.Ticket), amrMultiChannel)(c)
+				return
+			}
+
+			if login.State != query.State || login.State == service.QRLoginDenied {
+				core.WriteResponse(c, gin.H{"state": login.State})
+				return
+			}
+
+			select {
+			case <-ticker.C:
+			case <-deadline.C:
+				core.WriteResponse(c, gin.H{"state": login.State})
+				return
+			case <-c.Request.Context().Done():
+				return
+			}
+		}
+	}
+}
+
+// qrAuthenticator 领取已确认的二维码对应的用户，二维码只能使用一次。
+func qrAuthenticator(srv service.Service, qrCode, ticket string) func(c *gin.Context) (any, error) {
+	return func(c *gin.Context) (any, error) {
+		eid, err := srv.QRLogins().Consume(c, qrCode, ticket)
+		if err != nil {
+			return "", err
+		}
+
+		return srv.Users().GetByEID(c, eid)
+	}
+}
@@ -6,11 +6,24 @@ import (
 	"github.com/eachinchung/component-base/core"
 	"github.com/eachinchung/errors"
 
+	"github.com/eachinchung/e-service/internal/app/config"
+	"github.com/eachinchung/e-service/internal/app/controller/v1/apikey"
+	"github.com/eachinchung/e-service/internal/app/controller/v1/identity"
+	"github.com/eachinchung/e-service/internal/app/controller/v1/mfa"
+	"github.com/eachinchung/e-service/internal/app/controller/v1/oauth"
+	"github.com/eachinchung/e-service/internal/app/controller/v1/password"
+	"github.com/eachinchung/e-service/internal/app/controller/v1/qrlogin"
+	"github.com/eachinchung/e-service/internal/app/controller/v1/rbac"
+	"github.com/eachinchung/e-service/internal/app/controller/v1/securityevent"
+	"github.com/eachinchung/e-service/internal/app/controller/v1/serviceaccount"
+	"github.com/eachinchung/e-service/internal/app/controller/v1/session"
 	"github.com/eachinchung/e-service/internal/app/controller/v1/user"
+	"github.com/eachinchung/e-service/internal/app/controller/v1/verification"
 	"github.com/eachinchung/e-service/internal/app/storage"
 	"github.com/eachinchung/e-service/internal/app/store/postgres"
 	"github.com/eachinchung/e-service/internal/pkg/casbin"
 	"github.com/eachinchung/e-service/internal/pkg/code"
+	"github.com/eachinchung/e-service/internal/pkg/keyset"
 )
 
 func initRouter(g *gin.Engine) {
@@ -20,27 +33,305 @@ func initRouter(g *gin.Engine) {
 func installController(g *gin.Engine) {
 	jwtStrategy := newJWTAuth()
 
+	storeIns, _ := postgres.GetPostgresFactoryOr(nil)
+	storageIns := storage.Client()
+
+	g.Use(impersonationAudit())
+
 	auth := g.Group("/auth")
 	{
-		auth.POST("token", jwtStrategy.LoginHandler)
-		auth.PUT("token", jwtStrategy.RefreshHandler)
+		verificationController := verification.NewController(storeIns, storageIns)
+		passwordController := password.NewController(storeIns, storageIns)
+		identityController := identity.NewController(storeIns, storageIns)
+
+		auth.POST("token", loginHandler(jwtStrategy, jwtStrategy.Authenticator, amrPassword))
+		auth.PUT("token", refreshHandler(jwtStrategy))
+		auth.POST("token/mfa", mfaLoginHandler(jwtStrategy))
+		auth.DELETE("token", jwtStrategy.MiddlewareFunc(), tokenRevocation(), logoutHandler())
+		auth.POST("introspect", introspectionHandler(jwtStrategy))
+		auth.POST(
+			"step-up",
+			jwtStrategy.MiddlewareFunc(),
+			tokenRevocation(),
+			tokenRestriction(),
+			firstPartyOnly(),
+			noImpersonation(),
+			noServiceAccount(),
+			stepUpHandler(jwtStrategy),
+		)
+
+		auth.POST("sms/code", verificationController.SendLoginSMSCode)
+		auth.POST("sms/token", loginHandler(jwtStrategy, smsAuthenticator(), amrSMS))
+
+		auth.POST("password/code", passwordController.SendResetCode)
+		auth.POST("password/ticket", passwordController.CreateResetTicket)
+		auth.PUT("password", passwordController.Reset)
+
+		auth.GET("idp", identityController.Providers)
+		auth.GET("idp/:provider/authorize", identityController.AuthorizeURL)
+		auth.POST("idp/:provider/token", idpLoginHandler(jwtStrategy))
+		auth.POST("idp/bind", loginHandler(jwtStrategy, idpBindAuthenticator(), amrFederated, amrSMS))
+
+		auth.POST("qr", qrLoginCreateHandler())
+		auth.GET("qr/:code", qrLoginPollHandler(jwtStrategy))
+	}
+
+	oauthGroup := g.Group("/oauth")
+	{
+		authorize := oauthGroup.Group(
+			"/authorize",
+			jwtStrategy.MiddlewareFunc(),
+			tokenRevocation(),
+			tokenRestriction(),
+			firstPartyOnly(),
+			noImpersonation(),
+			noServiceAccount(),
+		)
+		authorize.GET("", authorizeHandler())
+		authorize.POST("", authorizeDecisionHandler())
+
+		// 用户在已登录的设备上输入用户码，为命令行工具、电视等设备授权
+		device := oauthGroup.Group(
+			"/device",
+			jwtStrategy.MiddlewareFunc(),
+			tokenRevocation(),
+			tokenRestriction(),
+			firstPartyOnly(),
+			noImpersonation(),
+			noServiceAccount(),
+		)
+		device.GET("", deviceVerifyHandler())
+		device.POST("", deviceDecisionHandler())
+
+		oauthGroup.POST("device_authorization", deviceAuthorizationHandler())
+		oauthGroup.POST("token", oauthTokenHandler(jwtStrategy))
+	}
+
+	g.GET("/.well-known/jwks.json", jwksHandler(keyset.Client()))
+	if config.GetConfigIns(nil).OAuthOptions.OpenIDEnabled() {
+		g.GET("/.well-known/openid-configuration", discoveryHandler(keyset.Client()))
+	}
+
+	userinfo := g.Group("/userinfo", jwtStrategy.MiddlewareFunc(), tokenRevocation(), tokenRestriction(), noServiceAccount())
+	{
+		userinfo.GET("", userinfoHandler())
+		userinfo.POST("", userinfoHandler())
 	}
 
 	g.NoRoute(jwtStrategy.MiddlewareFunc(), func(c *gin.Context) {
 		core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrPageNotFound, "page not found")))
 	})
 
-	storeIns, _ := postgres.GetPostgresFactoryOr(nil)
-	storageIns := storage.Client()
 	v1 := g.Group("/v1")
 	{
+		userController := user.NewController(storeIns, storageIns)
+		sessionController := session.NewController(storeIns, storageIns)
+		securityEventController := securityevent.NewController(storeIns, storageIns)
+
 		users := v1.Group("/users")
 		{
-			userController := user.NewController(storeIns, storageIns)
-
-			users.Use(jwtStrategy.MiddlewareFunc(), casbin.RBACMiddleWare())
+			users.Use(userAuthentication(jwtStrategy), tokenRestriction(), firstPartyOnly(), casbin.RBACMiddleWare())
 			users.POST("", userController.Create)
 			users.GET(":eid", userController.GetByEID)
+			users.DELETE(":eid/tokens", userController.RevokeTokens)
+			users.DELETE(":eid/lockout", userController.Unlock)
+			users.PUT(":eid/state", stepUp(stepUpMaxAge), userController.UpdateState)
+			users.PUT(":eid/email", noImpersonation(), noServiceAccount(), userController.UpdateEmail)
+			users.POST(":eid/email/code", noImpersonation(), noServiceAccount(), userController.SendEmailCode)
+			users.POST(":eid/email/verify", noImpersonation(), noServiceAccount(), userController.VerifyEmail)
+			users.GET(":eid/sessions", sessionController.List)
+			users.DELETE(":eid/sessions", sessionController.EndAll)
+			users.DELETE(":eid/sessions/:sid", sessionController.End)
+			users.GET(":eid/security-events", securityEventController.List)
+		}
+
+		// 密码过期时签发的受限 token 只能用于修改密码
+		v1.PUT(
+			"/users/:eid/password",
+			userAuthentication(jwtStrategy),
+			tokenRestriction(restrictPasswordChange),
+			firstPartyOnly(),
+			noImpersonation(),
+			noServiceAccount(),
+			casbin.RBACMiddleWare(),
+			userController.ChangePassword,
+		)
+
+		// 模拟登录的 token 不能再次申请模拟登录，申请前需要重新验证身份
+		v1.POST(
+			"/impersonation",
+			jwtStrategy.MiddlewareFunc(),
+			tokenRevocation(),
+			tokenRestriction(),
+			firstPartyOnly(),
+			noImpersonation(),
+			noServiceAccount(),
+			stepUp(stepUpMaxAge),
+			impersonateHandler(jwtStrategy),
+		)
+
+		sessions := v1.Group("/sessions")
+		{
+			sessions.Use(jwtStrategy.MiddlewareFunc(), tokenRevocation(), tokenRestriction(), firstPartyOnly(), noServiceAccount())
+			sessions.GET("", sessionController.List)
+			sessions.DELETE("", sessionController.EndAll)
+			sessions.DELETE(":sid", sessionController.End)
+		}
+
+		// 当前用户最近的登录、修改密码等安全事件
+		v1.GET(
+			"/security-events",
+			jwtStrategy.MiddlewareFunc(),
+			tokenRevocation(),
+			tokenRestriction(),
+			firstPartyOnly(),
+			noServiceAccount(),
+			securityEventController.List,
+		)
+
+		// 管理员查询所有用户的安全事件
+		v1.GET(
+			"/admin/security-events",
+			userAuthentication(jwtStrategy),
+			tokenRestriction(),
+			firstPartyOnly(),
+			casbin.RBACMiddleWare(),
+			securityEventController.Search,
+		)
+
+		identities := v1.Group("/identities")
+		{
+			identityController := identity.NewController(storeIns, storageIns)
+
+			identities.Use(
+				jwtStrategy.MiddlewareFunc(),
+				tokenRevocation(),
+				tokenRestriction(),
+				firstPartyOnly(),
+				noImpersonation(),
+				noServiceAccount(),
+			)
+			identities.GET("", identityController.List)
+			identities.GET(":provider/authorize", identityController.LinkURL)
+			identities.POST(":provider", identityController.Link)
+			identities.DELETE(":provider", identityController.Unlink)
+		}
+
+		// 扫码登录需要手机上已登录的用户本人确认
+		qrLogin := v1.Group("/qr-login")
+		{
+			qrLoginController := qrlogin.NewController(storeIns, storageIns)
+
+			qrLogin.Use(
+				jwtStrategy.MiddlewareFunc(),
+				tokenRevocation(),
+				tokenRestriction(),
+				firstPartyOnly(),
+				noImpersonation(),
+				noServiceAccount(),
+			)
+			qrLogin.POST(":code/scan", qrLoginController.Scan)
+			qrLogin.POST(":code/confirm", qrLoginController.Confirm)
+			qrLogin.POST(":code/deny", qrLoginController.Deny)
+		}
+
+		mfaGroup := v1.Group("/mfa")
+		{
+			mfaController := mfa.NewController(storeIns, storageIns)
+
+			mfaGroup.Use(
+				jwtStrategy.MiddlewareFunc(),
+				tokenRevocation(),
+				tokenRestriction(restrictMFAEnroll),
+				firstPartyOnly(),
+				noImpersonation(),
+				noServiceAccount(),
+			)
+			mfaGroup.GET("", mfaController.Status)
+			mfaGroup.POST("totp", mfaController.Enroll)
+			mfaGroup.PUT("totp", mfaController.Confirm)
+			mfaGroup.DELETE("totp", mfaController.Disable)
+			mfaGroup.POST("recovery-codes", mfaController.RegenerateRecoveryCodes)
+		}
+
+		clients := v1.Group("/oauth/clients")
+		{
+			oauthController := oauth.NewController(storeIns, storageIns)
+
+			clients.Use(
+				jwtStrategy.MiddlewareFunc(),
+				tokenRevocation(),
+				tokenRestriction(),
+				firstPartyOnly(),
+				noImpersonation(),
+				noServiceAccount(),
+				casbin.RBACMiddleWare(),
+			)
+			clients.POST("", oauthController.CreateClient)
+			clients.GET(":client_id", oauthController.GetClient)
+			clients.DELETE(":client_id", oauthController.DeleteClient)
+		}
+
+		apiKeys := v1.Group("/api-keys")
+		{
+			apiKeyController := apikey.NewController(storeIns, storageIns)
+
+			apiKeys.Use(
+				jwtStrategy.MiddlewareFunc(),
+				tokenRevocation(),
+				tokenRestriction(),
+				firstPartyOnly(),
+				noImpersonation(),
+				noServiceAccount(),
+			)
+			apiKeys.POST("", apiKeyController.Create)
+			apiKeys.GET("", apiKeyController.List)
+			apiKeys.DELETE(":key_id", apiKeyController.Revoke)
+		}
+
+		// 服务账号只能由用户管理，服务账号本身不能创建或删除服务账号
+		serviceAccounts := v1.Group("/service-accounts")
+		{
+			serviceAccountController := serviceaccount.NewController(storeIns, storageIns)
+
+			serviceAccounts.Use(
+				jwtStrategy.MiddlewareFunc(),
+				tokenRevocation(),
+				tokenRestriction(),
+				firstPartyOnly(),
+				noImpersonation(),
+				noServiceAccount(),
+				casbin.RBACMiddleWare(),
+			)
+			serviceAccounts.POST("", stepUp(stepUpMaxAge), serviceAccountController.Create)
+			serviceAccounts.GET("", serviceAccountController.List)
+			serviceAccounts.GET(":client_id", serviceAccountController.Get)
+			serviceAccounts.DELETE(":client_id", stepUp(stepUpMaxAge), serviceAccountController.Delete)
+		}
+
+		// 管理 casbin 的角色、权限与用户的角色，修改权限前需要重新验证身份
+		rbacGroup := v1.Group("/rbac")
+		{
+			rbacController := rbac.NewController(storeIns, storageIns)
+
+			rbacGroup.Use(
+				jwtStrategy.MiddlewareFunc(),
+				tokenRevocation(),
+				tokenRestriction(),
+				firstPartyOnly(),
+				noImpersonation(),
+				noServiceAccount(),
+				casbin.RBACMiddleWare(),
+			)
+			rbacGroup.GET("roles", rbacController.ListRoles)
+			rbacGroup.POST("roles", stepUp(stepUpMaxAge), rbacController.CreateRole)
+			rbacGroup.GET("roles/:role", rbacController.GetRole)
+			rbacGroup.DELETE("roles/:role", stepUp(stepUpMaxAge), rbacController.DeleteRole)
+			rbacGroup.POST("roles/:role/permissions", stepUp(stepUpMaxAge), rbacController.GrantPermission)
+			rbacGroup.DELETE("roles/:role/permissions", stepUp(stepUpMaxAge), rbacController.RevokePermission)
+			rbacGroup.GET("users/:eid/roles", rbacController.ListUserRoles)
+			rbacGroup.PUT("users/:eid/roles/:role", stepUp(stepUpMaxAge), rbacController.AssignRole)
+			rbacGroup.DELETE("users/:eid/roles/:role", stepUp(stepUpMaxAge), rbacController.UnassignRole)
 		}
 	}
 }
@@ -10,7 +10,13 @@ import (
 	"github.com/eachinchung/e-service/internal/app/store"
 	"github.com/eachinchung/e-service/internal/app/store/postgres"
 	"github.com/eachinchung/e-service/internal/pkg/casbin"
+	"github.com/eachinchung/e-service/internal/pkg/hasher"
+	"github.com/eachinchung/e-service/internal/pkg/idp"
+	"github.com/eachinchung/e-service/internal/pkg/keyset"
+	"github.com/eachinchung/e-service/internal/pkg/mail"
+	"github.com/eachinchung/e-service/internal/pkg/pwdpolicy"
 	"github.com/eachinchung/e-service/internal/pkg/server"
+	"github.com/eachinchung/e-service/internal/pkg/sms"
 	"github.com/eachinchung/e-service/internal/pkg/validator"
 )
 
@@ -46,6 +52,30 @@ func createAPIServer(cfg *config.Config) (*apiServer, error) {
 		log.Fatalf("获取 casbin 失败, error: %v", err)
 	}
 
+	if _, err := keyset.GetKeySetOr(cfg.JWTKeyOptions, cfg.JWTOptions.Key); err != nil {
+		log.Fatalf("获取 jwt 密钥失败, error: %v", err)
+	}
+
+	if _, err := hasher.GetHasherOr(cfg.PasswordHashOptions); err != nil {
+		log.Fatalf("获取密码哈希器失败, error: %v", err)
+	}
+
+	if _, err := pwdpolicy.GetPolicyOr(cfg.PasswordPolicyOptions); err != nil {
+		log.Fatalf("获取密码策略失败, error: %v", err)
+	}
+
+	if _, err := sms.GetSenderOr(cfg.SMSOptions, cfg.TencentCloudOptions); err != nil {
+		log.Fatalf("获取短信发送器失败, error: %v", err)
+	}
+
+	if _, err := mail.GetSenderOr(cfg.MailOptions); err != nil {
+		log.Fatalf("获取邮件发送器失败, error: %v", err)
+	}
+
+	if _, err := idp.GetRegistryOr(cfg.IDPOptions); err != nil {
+		log.Fatalf("获取第三方登录平台失败, error: %v", err)
+	}
+
 	genericConfig, err := buildGenericConfig(cfg)
 	if err != nil {
 		return nil, err
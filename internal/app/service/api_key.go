@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/eachinchung/component-base/utils/idutil"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/app/config"
+	"github.com/eachinchung/e-service/internal/app/storage"
+	"github.com/eachinchung/e-service/internal/app/store"
+	"github.com/eachinchung/e-service/internal/app/store/model"
+	"github.com/eachinchung/e-service/internal/pkg/code"
+)
+
+// apiKeyPrefix 是 API key 明文的前缀，便于在代码仓库等位置识别泄露的 key。
+const apiKeyPrefix = "ek"
+
+// APIKeySrv defines functions used to handle api keys.
+type APIKeySrv interface {
+	Create(ctx context.Context, key *model.APIKeys, ttl time.Duration) (string, error)
+	List(ctx context.Context, eid string) ([]*model.APIKeys, error)
+	Revoke(ctx context.Context, eid, keyID string) error
+	Authenticate(ctx context.Context, rawKey string) (*model.APIKeys, error)
+}
+
+type apiKeyService struct {
+	store   store.Store
+	storage storage.Storage
+}
+
+var _ APIKeySrv = &apiKeyService{}
+
+func newAPIKeys(srv *service) *apiKeyService {
+	return &apiKeyService{store: srv.store, storage: srv.storage}
+}
+
+// Create 为用户创建 API key，ttl 为 0 时使用允许的最长有效期，key 的明文只在创建时返回一次。
+func (a apiKeyService) Create(ctx context.Context, key *model.APIKeys, ttl time.Duration) (string, error) {
+	opts := config.GetConfigIns(nil).APIKeyOptions
+	db := a.store.DB()
+
+	count, err := a.store.APIKeys().CountActive(ctx, db, key.EID)
+	if err != nil {
+		return "", errors.Code(code.ErrDatabase, err.Error())
+	}
+	if count >= opts.MaxKeysPerUser {
+		return "", errors.Code(code.ErrAPIKeyLimitExceeded, "too many api keys")
+	}
+
+	if ttl <= 0 || ttl > opts.MaxExpire {
+		ttl = opts.MaxExpire
+	}
+
+	secret := idutil.GenSecretKey()
+	key.KeyID = idutil.GenSecretID()
+	key.SecretHash = hashToken(secret)
+	key.ExpiresAt = sql.NullTime{Time: time.Now().Add(ttl), Valid: true}
+
+	if err := a.store.APIKeys().Create(ctx, db, key); err != nil {
+		return "", errors.Code(code.ErrDatabase, err.Error())
+	}
+
+	log.L(ctx).Infof("user %s created api key %s", key.EID, key.KeyID)
+	return strings.Join([]string{apiKeyPrefix, key.KeyID, secret}, "_"), nil
+}
+
+// List 返回用户所有未吊销的 API key。
+func (a apiKeyService) List(ctx context.Context, eid string) ([]*model.APIKeys, error) {
+	keys, err := a.store.APIKeys().List(ctx, a.store.DB(), eid)
+	if err != nil {
+		return nil, errors.Code(code.ErrDatabase, err.Error())
+	}
+	return keys, nil
+}
+
+// Revoke 吊销用户的 API key，吊销后立即失效。
+func (a apiKeyService) Revoke(ctx context.Context, eid, keyID string) error {
+	db := a.store.DB()
+
+	key, err := a.store.APIKeys().Get(ctx, db, keyID)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return errors.Code(code.ErrAPIKeyNotExist, err.Error())
+	case err != nil:
+		return errors.Code(code.ErrDatabase, err.Error())
+	case key.EID != eid || key.RevokedAt.Valid:
+		return errors.Code(code.ErrAPIKeyNotExist, "api key not found")
+	}
+
+	key.RevokedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	if err := a.store.APIKeys().Update(ctx, db, key); err != nil {
+		return errors.Code(code.ErrDatabase, err.Error())
+	}
+
+	log.L(ctx).Infof("user %s revoked api key %s", eid, keyID)
+	return nil
+}
+
+// Authenticate 校验 API key，并记录最近一次使用时间。
+func (a apiKeyService) Authenticate(ctx context.Context, rawKey string) (*model.APIKeys, error) {
+	parts := strings.Split(rawKey, "_")
+	if len(parts) != 3 || parts[0] != apiKeyPrefix {
+		return nil, errors.Code(code.ErrAPIKeyInvalid, "malformed api key")
+	}
+
+	key, err := a.store.APIKeys().Get(ctx, a.store.DB(), parts[1])
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, errors.Code(code.ErrAPIKeyInvalid, "api key not found")
+	case err != nil:
+		return nil, errors.Code(code.ErrDatabase, err.Error())
+	}
+
+	if subtle.ConstantTimeCompare([]byte(key.SecretHash), []byte(hashToken(parts[2]))) != 1 {
+		return nil, errors.Code(code.ErrAPIKeyInvalid, "api key secret incorrect")
+	}
+
+	if !key.Active(time.Now()) {
+		return nil, errors.Code(code.ErrAPIKeyInvalid, "api key revoked or expired")
+	}
+
+	a.touch(ctx, key.KeyID)
+	return key, nil
+}
+
+// touch 更新 API key 的最近使用时间，为了减少数据库写入，同一个 key 在 TouchInterval 内只更新一次。
+func (a apiKeyService) touch(ctx context.Context, keyID string) {
+	interval := config.GetConfigIns(nil).APIKeyOptions.TouchInterval
+
+	fresh, err := a.storage.SetNX(ctx, fmt.Sprintf(storage.KeyAPIKeyUsed, keyID), true, interval)
+	if err != nil || !fresh {
+		return
+	}
+
+	if err := a.store.APIKeys().Touch(ctx, a.store.DB(), keyID); err != nil {
+		log.L(ctx).Errorf("touch api key failed: %+v", err)
+	}
+}
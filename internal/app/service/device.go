@@ -0,0 +1,279 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eachinchung/component-base/utils/idutil"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/app/config"
+	"github.com/eachinchung/e-service/internal/app/storage"
+	"github.com/eachinchung/e-service/internal/app/store"
+	"github.com/eachinchung/e-service/internal/pkg/code"
+)
+
+// 设备授权的状态，只能从 pending 变为 approved 或 denied。
+const (
+	DeviceAuthorizationPending  = "pending"
+	DeviceAuthorizationApproved = "approved"
+	DeviceAuthorizationDenied   = "denied"
+)
+
+const (
+	// userCodeCharset 是用户码使用的字符，去掉了元音与容易混淆的字符，参考 RFC 8628 6.1。
+	userCodeCharset = "BCDFGHJKLMNPQRSTVWXZ"
+	userCodeLength  = 8
+
+	// maxUserCodeTries 是用户在设备码有效期内可以输错用户码的次数。
+	maxUserCodeTries = 10
+)
+
+// DeviceAuthorizationSrv defines functions used to handle the OAuth 2.0 device authorization grant (RFC 8628).
+type DeviceAuthorizationSrv interface {
+	Create(ctx context.Context, clientID string) (*DeviceAuthorization, error)
+	Get(ctx context.Context, eid, userCode string) (*DeviceAuthorization, error)
+	Approve(ctx context.Context, eid, userCode string) error
+	Deny(ctx context.Context, eid, userCode string) error
+	Poll(ctx context.Context, deviceCode, clientID string) (string, error)
+}
+
+// DeviceAuthorization 是一次设备授权请求。
+// DeviceCode 只返回给发起授权的设备，用于轮询 token 端点；UserCode 展示给用户，由用户在已登录的设备上输入。
+type DeviceAuthorization struct {
+	ClientID   string
+	DeviceCode string
+	UserCode   string
+	Expire     time.Time
+	Interval   time.Duration
+}
+
+// deviceCodeRecord 是保存在 storage 中的设备授权请求，以设备码的哈希值作为 key。
+type deviceCodeRecord struct {
+	ClientID string `redis:"client_id"` // 应用 ID
+	UserCode string `redis:"user_code"` // 用户码
+	State    string `redis:"state"`     // 授权状态
+	EID      string `redis:"eid"`       // 授权的用户
+	Used     int64  `redis:"used"`      // 使用次数
+}
+
+type deviceAuthorizationService struct {
+	store   store.Store
+	storage storage.Storage
+}
+
+var _ DeviceAuthorizationSrv = &deviceAuthorizationService{}
+
+func newDeviceAuthorizations(srv *service) *deviceAuthorizationService {
+	return &deviceAuthorizationService{store: srv.store, storage: srv.storage}
+}
+
+// Create 为应用创建设备码与用户码，只有配置允许的自有应用可以使用设备授权。
+func (d deviceAuthorizationService) Create(ctx context.Context, clientID string) (*DeviceAuthorization, error) {
+	opts := config.GetConfigIns(nil).OAuthOptions
+	if !contains(opts.DeviceClients, clientID) {
+		return nil, errors.Code(code.ErrDeviceClientUnauthorized, "client is not allowed to use device authorization")
+	}
+
+	deviceCode := idutil.GenSecretKey()
+	hashed := hashToken(deviceCode)
+
+	// 用户码较短，可能与未过期的用户码重复，重复时重新生成。
+	var userCode string
+	for i := 0; ; i++ {
+		if i == 3 {
+			return nil, errors.New("failed to generate a unique user code")
+		}
+
+		var err error
+		if userCode, err = genUserCode(); err != nil {
+			return nil, err
+		}
+
+		ok, err := d.storage.SetNX(ctx, fmt.Sprintf(storage.KeyOAuthUserCode, userCode), hashed, opts.DeviceCodeExpire)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to save user code")
+		}
+		if ok {
+			break
+		}
+	}
+
+	record := &deviceCodeRecord{ClientID: clientID, UserCode: userCode, State: DeviceAuthorizationPending}
+	if err := d.storage.HSetAllWithExpire(ctx, fmt.Sprintf(storage.KeyOAuthDeviceCode, hashed), record, opts.DeviceCodeExpire); err != nil {
+		return nil, errors.Wrap(err, "failed to save device code")
+	}
+
+	return &DeviceAuthorization{
+		ClientID:   clientID,
+		DeviceCode: deviceCode,
+		UserCode:   userCode,
+		Expire:     time.Now().Add(opts.DeviceCodeExpire),
+		Interval:   opts.DevicePollInterval,
+	}, nil
+}
+
+// Get 返回用户码对应的授权请求，用于向用户展示发起授权的应用。
+func (d deviceAuthorizationService) Get(ctx context.Context, eid, userCode string) (*DeviceAuthorization, error) {
+	_, record, err := d.lookup(ctx, eid, userCode)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DeviceAuthorization{ClientID: record.ClientID, UserCode: record.UserCode}, nil
+}
+
+// Approve 用户同意授权，设备随后可以从 token 端点领取 token。
+func (d deviceAuthorizationService) Approve(ctx context.Context, eid, userCode string) error {
+	return d.decide(ctx, eid, userCode, DeviceAuthorizationApproved)
+}
+
+// Deny 用户拒绝授权。
+func (d deviceAuthorizationService) Deny(ctx context.Context, eid, userCode string) error {
+	return d.decide(ctx, eid, userCode, DeviceAuthorizationDenied)
+}
+
+// Poll 设备使用设备码轮询授权结果，用户同意后返回授权的用户，设备码只能使用一次。
+// 轮询间隔小于配置的间隔时返回 slow_down。
+func (d deviceAuthorizationService) Poll(ctx context.Context, deviceCode, clientID string) (string, error) {
+	opts := config.GetConfigIns(nil).OAuthOptions
+
+	hashed := hashToken(deviceCode)
+	key := fmt.Sprintf(storage.KeyOAuthDeviceCode, hashed)
+
+	record := &deviceCodeRecord{}
+	if err := d.storage.HGetAll(ctx, key, record); err != nil {
+		if errors.Is(err, storage.ErrKeyNotFound) {
+			return "", errors.Code(code.ErrDeviceCodeExpired, "device code not found or expired")
+		}
+		return "", errors.Wrap(err, "failed to get device code")
+	}
+
+	if record.ClientID != clientID {
+		return "", errors.Code(code.ErrOAuthInvalidGrant, "device code was issued to another client")
+	}
+
+	ok, err := d.storage.SetNX(ctx, fmt.Sprintf(storage.KeyOAuthDevicePoll, hashed), 1, opts.DevicePollInterval)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to save device poll time")
+	}
+	if !ok {
+		return "", errors.Code(code.ErrDeviceSlowDown, "device polls too frequently")
+	}
+
+	switch record.State {
+	case DeviceAuthorizationPending:
+		return "", errors.Code(code.ErrDeviceAuthorizationPending, "user has not approved the device")
+	case DeviceAuthorizationDenied:
+		_ = d.storage.Del(ctx, key, fmt.Sprintf(storage.KeyOAuthUserCode, record.UserCode))
+		return "", errors.Code(code.ErrOAuthAccessDenied, "user denied the device")
+	}
+
+	used, err := d.storage.HIncrByIfExists(ctx, key, "used", 1)
+	if err != nil {
+		if errors.Is(err, storage.ErrKeyNotFound) {
+			return "", errors.Code(code.ErrDeviceCodeExpired, "device code not found or expired")
+		}
+		return "", errors.Wrap(err, "failed to mark device code used")
+	}
+	_ = d.storage.Del(ctx, key, fmt.Sprintf(storage.KeyOAuthUserCode, record.UserCode))
+
+	if used > 1 {
+		return "", errors.Code(code.ErrOAuthInvalidGrant, "device code has been used")
+	}
+
+	log.L(ctx).Infof("user %s authorized device of client %s", record.EID, record.ClientID)
+	return record.EID, nil
+}
+
+func (d deviceAuthorizationService) decide(ctx context.Context, eid, userCode, state string) error {
+	key, record, err := d.lookup(ctx, eid, userCode)
+	if err != nil {
+		return err
+	}
+
+	if record.State != DeviceAuthorizationPending {
+		return errors.Code(code.ErrDeviceUserCodeInvalid, "device authorization has been decided")
+	}
+
+	// 多个用户同时输入同一个用户码时只有第一个决定生效
+	ok, err := d.storage.HCompareAndSet(ctx, key, "state", DeviceAuthorizationPending, "state", state, "eid", eid)
+	if err != nil {
+		if errors.Is(err, storage.ErrKeyNotFound) {
+			return errors.Code(code.ErrDeviceUserCodeInvalid, "device code not found")
+		}
+		return errors.Wrap(err, "failed to update device code")
+	}
+	if !ok {
+		return errors.Code(code.ErrDeviceUserCodeInvalid, "device authorization has been decided")
+	}
+	return nil
+}
+
+// lookup 返回用户码对应的设备授权请求，用户在设备码有效期内输错用户码的次数有上限。
+func (d deviceAuthorizationService) lookup(ctx context.Context, eid, userCode string) (string, *deviceCodeRecord, error) {
+	opts := config.GetConfigIns(nil).OAuthOptions
+
+	triesKey := fmt.Sprintf(storage.KeyOAuthUserCodeTries, eid)
+	tries, err := d.storage.Get(ctx, triesKey)
+	if err != nil && !errors.Is(err, storage.ErrKeyNotFound) {
+		return "", nil, errors.Wrap(err, "failed to get user code tries")
+	}
+	if n, _ := strconv.Atoi(tries); n >= maxUserCodeTries {
+		return "", nil, errors.Code(code.ErrDeviceUserCodeInvalid, "too many user code tries")
+	}
+
+	hashed, err := d.storage.Get(ctx, fmt.Sprintf(storage.KeyOAuthUserCode, normalizeUserCode(userCode)))
+	if err != nil {
+		if !errors.Is(err, storage.ErrKeyNotFound) {
+			return "", nil, errors.Wrap(err, "failed to get user code")
+		}
+
+		if _, err := d.storage.IncrWithExpire(ctx, triesKey, opts.DeviceCodeExpire); err != nil {
+			return "", nil, errors.Wrap(err, "failed to count user code tries")
+		}
+		return "", nil, errors.Code(code.ErrDeviceUserCodeInvalid, "user code not found")
+	}
+
+	key := fmt.Sprintf(storage.KeyOAuthDeviceCode, hashed)
+	record := &deviceCodeRecord{}
+	if err := d.storage.HGetAll(ctx, key, record); err != nil {
+		if errors.Is(err, storage.ErrKeyNotFound) {
+			return "", nil, errors.Code(code.ErrDeviceUserCodeInvalid, "device code not found")
+		}
+		return "", nil, errors.Wrap(err, "failed to get device code")
+	}
+
+	return key, record, nil
+}
+
+// genUserCode 随机生成用户码。
+func genUserCode() (string, error) {
+	max := big.NewInt(int64(len(userCodeCharset)))
+
+	b := make([]byte, userCodeLength)
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to generate user code")
+		}
+		b[i] = userCodeCharset[n.Int64()]
+	}
+	return string(b), nil
+}
+
+// FormatUserCode 将用户码格式化为 XXXX-XXXX 的形式，便于用户阅读与输入。
+func FormatUserCode(userCode string) string {
+	return userCode[:userCodeLength/2] + "-" + userCode[userCodeLength/2:]
+}
+
+// normalizeUserCode 忽略用户输入的大小写、空格与连字符。
+func normalizeUserCode(userCode string) string {
+	return strings.NewReplacer("-", "", " ", "").Replace(strings.ToUpper(userCode))
+}
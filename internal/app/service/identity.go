@@ -0,0 +1,274 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/eachinchung/component-base/utils/idutil"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/app/config"
+	"github.com/eachinchung/e-service/internal/app/storage"
+	"github.com/eachinchung/e-service/internal/app/store"
+	"github.com/eachinchung/e-service/internal/app/store/model"
+	"github.com/eachinchung/e-service/internal/pkg/code"
+	"github.com/eachinchung/e-service/internal/pkg/idp"
+)
+
+// IdentitySrv defines functions used to login with and manage third-party identities.
+type IdentitySrv interface {
+	AuthCodeURL(ctx context.Context, provider, eid string) (string, error)
+	Login(ctx context.Context, provider, authCode, state string) (*model.Users, *IdentityTicket, error)
+	Link(ctx context.Context, eid, provider, authCode, state string) error
+	ConsumeTicket(ctx context.Context, ticket string) (*model.Identities, error)
+	Bind(ctx context.Context, identity *model.Identities, user *model.Users) error
+	List(ctx context.Context, eid string) ([]*model.Identities, error)
+	Unlink(ctx context.Context, eid, provider string) error
+}
+
+// IdentityTicket 是第三方账号首次登录时签发的凭证，客户端需要使用它绑定已有账号或注册新账号。
+type IdentityTicket struct {
+	Ticket   string
+	Expire   time.Time
+	Provider string
+	Nickname string
+}
+
+// identityState 是跳转到第三方平台前保存的 state，EID 不为空时表示已登录用户绑定第三方账号。
+type identityState struct {
+	Provider string `redis:"provider"` // 第三方登录平台
+	EID      string `redis:"eid"`      // 绑定第三方账号的用户名
+	Used     int64  `redis:"used"`     // 使用次数
+}
+
+// identityTicket 是保存在 storage 中的第三方账号信息，凭证本身只保存哈希值。
+type identityTicket struct {
+	Provider string `redis:"provider"` // 第三方登录平台
+	Subject  string `redis:"subject"`  // 第三方平台的用户 ID
+	Nickname string `redis:"nickname"` // 第三方平台的昵称
+	Used     int64  `redis:"used"`     // 使用次数
+}
+
+type identityService struct {
+	store   store.Store
+	storage storage.Storage
+}
+
+var _ IdentitySrv = &identityService{}
+
+func newIdentities(srv *service) *identityService {
+	return &identityService{store: srv.store, storage: srv.storage}
+}
+
+// AuthCodeURL 生成 state 并返回第三方平台的授权地址，eid 为空时用于登录，否则用于绑定第三方账号。
+func (i identityService) AuthCodeURL(ctx context.Context, provider, eid string) (string, error) {
+	opts := config.GetConfigIns(nil).IDPOptions
+
+	p, err := i.provider(provider)
+	if err != nil {
+		return "", err
+	}
+
+	if opts.RedirectURI == "" {
+		return "", errors.New("idp redirect uri is not configured")
+	}
+
+	state := idutil.GenSecretKey()
+	key := fmt.Sprintf(storage.KeyIDPState, hashToken(state))
+	if err := i.storage.HSetAllWithExpire(ctx, key, &identityState{Provider: provider, EID: eid}, opts.StateExpire); err != nil {
+		return "", errors.Wrap(err, "failed to save idp state")
+	}
+
+	return p.AuthCodeURL(state, opts.RedirectURI), nil
+}
+
+// Login 使用第三方平台的授权码登录。
+// 第三方账号已绑定用户时返回该用户；否则返回 IdentityTicket，由客户端绑定已有账号或注册新账号。
+func (i identityService) Login(ctx context.Context, provider, authCode, state string) (*model.Users, *IdentityTicket, error) {
+	opts := config.GetConfigIns(nil).IDPOptions
+
+	identity, err := i.exchange(ctx, provider, authCode, state, "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	db := i.store.DB()
+	linked, err := i.store.Identities().Get(ctx, db, provider, identity.Subject)
+	switch {
+	case err == nil:
+		user, err := i.store.User().Get(ctx, db, linked.EID)
+		if err != nil {
+			return nil, nil, errors.Code(code.ErrDatabase, err.Error())
+		}
+		return user, nil, nil
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, nil, errors.Code(code.ErrDatabase, err.Error())
+	}
+
+	ticket := idutil.GenSecretKey()
+	record := &identityTicket{Provider: provider, Subject: identity.Subject, Nickname: identity.Nickname}
+	key := fmt.Sprintf(storage.KeyIDPTicket, hashToken(ticket))
+	if err := i.storage.HSetAllWithExpire(ctx, key, record, opts.TicketExpire); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to save idp ticket")
+	}
+
+	return nil, &IdentityTicket{
+		Ticket:   ticket,
+		Expire:   time.Now().Add(opts.TicketExpire),
+		Provider: provider,
+		Nickname: identity.Nickname,
+	}, nil
+}
+
+// Link 使用第三方平台的授权码为已登录的用户绑定第三方账号，state 必须由同一个用户生成。
+func (i identityService) Link(ctx context.Context, eid, provider, authCode, state string) error {
+	identity, err := i.exchange(ctx, provider, authCode, state, eid)
+	if err != nil {
+		return err
+	}
+
+	return i.create(ctx, &model.Identities{
+		EID:      eid,
+		Provider: provider,
+		Subject:  identity.Subject,
+		Nickname: identity.Nickname,
+	})
+}
+
+// ConsumeTicket 校验并消费第三方账号首次登录时签发的凭证，返回尚未绑定用户的第三方账号。
+// 凭证只能使用一次，调用方需要在验证手机号、创建账号之前调用，避免使用无效的凭证创建账号。
+func (i identityService) ConsumeTicket(ctx context.Context, ticket string) (*model.Identities, error) {
+	record := &identityTicket{}
+	if err := i.consume(ctx, fmt.Sprintf(storage.KeyIDPTicket, hashToken(ticket)), record); err != nil {
+		if errors.Is(err, storage.ErrKeyNotFound) {
+			return nil, errors.Code(code.ErrIdentityTicketInvalid, "idp ticket not found or has been used")
+		}
+		return nil, err
+	}
+
+	return &model.Identities{
+		Provider: record.Provider,
+		Subject:  record.Subject,
+		Nickname: record.Nickname,
+	}, nil
+}
+
+// Bind 把 ConsumeTicket 返回的第三方账号绑定到 user。
+func (i identityService) Bind(ctx context.Context, identity *model.Identities, user *model.Users) error {
+	identity.EID = user.EID
+	return i.create(ctx, identity)
+}
+
+// List 返回用户绑定的所有第三方账号。
+func (i identityService) List(ctx context.Context, eid string) ([]*model.Identities, error) {
+	identities, err := i.store.Identities().List(ctx, i.store.DB(), eid)
+	if err != nil {
+		return nil, errors.Code(code.ErrDatabase, err.Error())
+	}
+	return identities, nil
+}
+
+// Unlink 解除用户与 provider 平台第三方账号的绑定。
+func (i identityService) Unlink(ctx context.Context, eid, provider string) error {
+	if err := i.store.Identities().Delete(ctx, i.store.DB(), eid, provider); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.Code(code.ErrIdentityNotExist, err.Error())
+		}
+		return errors.Code(code.ErrDatabase, err.Error())
+	}
+
+	log.L(ctx).Infof("user %s unlinked %s identity", eid, provider)
+	return nil
+}
+
+// exchange 校验 state 并使用授权码换取第三方平台的用户信息，state 只能使用一次。
+func (i identityService) exchange(ctx context.Context, provider, authCode, state, eid string) (*idp.Identity, error) {
+	p, err := i.provider(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &identityState{}
+	if err := i.consume(ctx, fmt.Sprintf(storage.KeyIDPState, hashToken(state)), record); err != nil {
+		if errors.Is(err, storage.ErrKeyNotFound) {
+			return nil, errors.Code(code.ErrIdentityStateInvalid, "idp state not found or has been used")
+		}
+		return nil, err
+	}
+	if record.Provider != provider || record.EID != eid {
+		return nil, errors.Code(code.ErrIdentityStateInvalid, "idp state mismatch")
+	}
+
+	identity, err := p.Exchange(ctx, authCode, config.GetConfigIns(nil).IDPOptions.RedirectURI)
+	if err != nil {
+		log.L(ctx).Warnf("exchange %s auth code failed: %+v", provider, err)
+		return nil, errors.Code(code.ErrIdentityExchangeFailed, err.Error())
+	}
+
+	return identity, nil
+}
+
+// create 保存第三方账号与用户的绑定关系，第三方账号已绑定同一用户时直接返回。
+func (i identityService) create(ctx context.Context, identity *model.Identities) error {
+	db := i.store.DB()
+
+	linked, err := i.store.Identities().Get(ctx, db, identity.Provider, identity.Subject)
+	switch {
+	case err == nil && linked.EID == identity.EID:
+		return nil
+	case err == nil:
+		return errors.Code(code.ErrIdentityAlreadyLinked, "identity has been linked to another user")
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return errors.Code(code.ErrDatabase, err.Error())
+	}
+
+	if err := i.store.Identities().Create(ctx, db, identity); err != nil {
+		switch {
+		case strings.Contains(err.Error(), "identities_eid_provider_key"):
+			return errors.Code(code.ErrIdentityProviderAlreadyLinked, err.Error())
+		case strings.Contains(err.Error(), "identities_provider_subject_key"):
+			return errors.Code(code.ErrIdentityAlreadyLinked, err.Error())
+		}
+		return errors.Code(code.ErrDatabase, err.Error())
+	}
+
+	log.L(ctx).Infof("user %s linked %s identity", identity.EID, identity.Provider)
+	return nil
+}
+
+// consume 读取并删除 key 中保存的一次性记录，记录已被使用时返回 storage.ErrKeyNotFound。
+func (i identityService) consume(ctx context.Context, key string, record any) error {
+	if err := i.storage.HGetAll(ctx, key, record); err != nil {
+		if errors.Is(err, storage.ErrKeyNotFound) {
+			return err
+		}
+		return errors.Wrap(err, "failed to get idp record")
+	}
+
+	used, err := i.storage.HIncrByIfExists(ctx, key, "used", 1)
+	if err != nil {
+		if errors.Is(err, storage.ErrKeyNotFound) {
+			return err
+		}
+		return errors.Wrap(err, "failed to mark idp record used")
+	}
+	_ = i.storage.Del(ctx, key)
+
+	if used > 1 {
+		return storage.ErrKeyNotFound
+	}
+	return nil
+}
+
+func (i identityService) provider(name string) (idp.Provider, error) {
+	p, ok := idp.Client().Get(name)
+	if !ok {
+		return nil, errors.Code(code.ErrIdentityProviderNotExist, fmt.Sprintf("identity provider %s not exist", name))
+	}
+	return p, nil
+}
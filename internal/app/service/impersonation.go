@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+
+	"github.com/eachinchung/errors"
+
+	"github.com/eachinchung/e-service/internal/app/storage"
+	"github.com/eachinchung/e-service/internal/app/store"
+	"github.com/eachinchung/e-service/internal/app/store/model"
+	"github.com/eachinchung/e-service/internal/pkg/code"
+)
+
+// ImpersonationSrv defines functions used to authorize and audit impersonation.
+type ImpersonationSrv interface {
+	Authorize(ctx context.Context, operator, eid string) (*model.Users, error)
+	Record(ctx context.Context, log *model.ImpersonationLogs) error
+}
+
+type impersonationService struct {
+	store   store.Store
+	storage storage.Storage
+}
+
+var _ ImpersonationSrv = &impersonationService{}
+
+func newImpersonations(srv *service) *impersonationService {
+	return &impersonationService{store: srv.store, storage: srv.storage}
+}
+
+// Authorize 检查 operator 是否可以模拟登录 eid，只有超级用户可以模拟登录，并且不能模拟登录自己或其他超级用户。
+func (i impersonationService) Authorize(ctx context.Context, operator, eid string) (*model.Users, error) {
+	superUsers := superUserService{store: i.store, storage: i.storage}
+
+	ok, err := superUsers.Exists(ctx, operator)
+	if err != nil {
+		return nil, errors.Code(code.ErrDatabase, err.Error())
+	}
+	if !ok {
+		return nil, errors.Code(code.ErrPermissionDenied, "only super users can impersonate")
+	}
+
+	if operator == eid {
+		return nil, errors.Code(code.ErrImpersonationNotAllowed, "can not impersonate yourself")
+	}
+
+	if ok, err = superUsers.Exists(ctx, eid); err != nil {
+		return nil, errors.Code(code.ErrDatabase, err.Error())
+	}
+	if ok {
+		return nil, errors.Code(code.ErrImpersonationNotAllowed, "can not impersonate a super user")
+	}
+
+	user, err := (userService{store: i.store, storage: i.storage}).GetByEID(ctx, eid)
+	if err != nil {
+		return nil, err
+	}
+	if user.State != model.StatusNormal {
+		return nil, errors.Code(code.ErrUserStatusIsAbnormal, "user has been "+user.State.Msg())
+	}
+
+	return user, nil
+}
+
+// Record 写入一条模拟登录审计日志。
+func (i impersonationService) Record(ctx context.Context, log *model.ImpersonationLogs) error {
+	if err := i.store.ImpersonationLogs().Create(ctx, i.store.DB(), log); err != nil {
+		return errors.Code(code.ErrDatabase, err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/eachinchung/component-base/middleware/auth"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/app/config"
+	"github.com/eachinchung/e-service/internal/app/storage"
+	"github.com/eachinchung/e-service/internal/app/store/model"
+	"github.com/eachinchung/e-service/internal/pkg/code"
+)
+
+// Introspection 是 RFC 7662 定义的 token 自省结果，token 无效时只有 Active 字段，用户状态异常时另外返回 Sub 与 State。
+type Introspection struct {
+	Active    bool          `json:"active"`
+	Scope     string        `json:"scope,omitempty"`
+	ClientID  string        `json:"client_id,omitempty"`
+	TokenType string        `json:"token_type,omitempty"`
+	Exp       int64         `json:"exp,omitempty"`
+	Iat       int64         `json:"iat,omitempty"`
+	Sub       string        `json:"sub,omitempty"`
+	Aud       string        `json:"aud,omitempty"`
+	Iss       string        `json:"iss,omitempty"`
+	Jti       string        `json:"jti,omitempty"`
+	State     *model.Status `json:"state,omitempty"`    // 用户当前的状态
+	Restrict  string        `json:"restrict,omitempty"` // 受限 token 允许访问的接口
+}
+
+// Introspect 根据已经校验过签名与有效期的 claims 检查 token 是否被吊销、用户状态是否正常。
+// 结果按 token 缓存一小段时间，缓存期间 token 被吊销或用户被禁用不会立即反映在结果中。
+func (t tokenService) Introspect(ctx context.Context, token string, claims auth.MapClaims) (*Introspection, error) {
+	key := fmt.Sprintf(storage.KeyOAuthIntrospection, hashToken(token))
+
+	if cached, err := t.storage.Get(ctx, key); err == nil {
+		result := &Introspection{}
+		if err := json.Unmarshal([]byte(cached), result); err == nil {
+			return result, nil
+		}
+	}
+
+	result, err := t.introspect(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := config.GetConfigIns(nil).OAuthOptions.IntrospectionCache
+	if exp, ok := claims["exp"].(float64); ok {
+		if remain := time.Until(time.Unix(int64(exp), 0)); remain < ttl {
+			ttl = remain
+		}
+	}
+	if ttl > 0 {
+		if data, err := json.Marshal(result); err == nil {
+			if err := t.storage.Set(ctx, key, string(data), ttl); err != nil {
+				log.L(ctx).Errorf("cache token introspection failed: %+v", err)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func (t tokenService) introspect(ctx context.Context, claims auth.MapClaims) (*Introspection, error) {
+	inactive := &Introspection{Active: false}
+
+	revoked, err := t.IsRevoked(ctx, claims)
+	if err != nil {
+		return nil, errors.Code(code.ErrUnknown, err.Error())
+	}
+	if revoked {
+		return inactive, nil
+	}
+
+	sub, _ := claims["sub"].(string)
+
+	var state *model.Status
+	if clientID, ok := model.ServiceAccountClientID(sub); ok {
+		if _, err := (serviceAccountService{store: t.store, storage: t.storage}).Get(ctx, clientID); err != nil {
+			if errors.IsCode(err, code.ErrServiceAccountNotExist) {
+				return inactive, nil
+			}
+			return nil, err
+		}
+	} else {
+		user, err := (userService{store: t.store, storage: t.storage}).GetByEID(ctx, sub)
+		if err != nil {
+			if errors.IsCode(err, code.ErrUserNotExist) {
+				return inactive, nil
+			}
+			return nil, err
+		}
+		// 用户状态异常时 token 无效，但仍然返回用户与状态，便于调用方提示用户已被禁用
+		if user.State != model.StatusNormal {
+			return &Introspection{Active: false, Sub: sub, State: &user.State}, nil
+		}
+		state = &user.State
+	}
+
+	result := &Introspection{
+		Active:    true,
+		TokenType: "Bearer",
+		Exp:       int64Claim(claims, "exp"),
+		Iat:       issuedAt(claims),
+		Sub:       sub,
+		State:     state,
+	}
+	result.Scope, _ = claims["scope"].(string)
+	result.ClientID, _ = claims["client_id"].(string)
+	result.Aud, _ = claims["aud"].(string)
+	result.Iss, _ = claims["iss"].(string)
+	result.Jti, _ = claims["jti"].(string)
+	result.Restrict, _ = claims["restrict"].(string)
+
+	return result, nil
+}
+
+func int64Claim(claims auth.MapClaims, name string) int64 {
+	if v, ok := claims[name].(float64); ok {
+		return int64(v)
+	}
+	return 0
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/app/config"
+	"github.com/eachinchung/e-service/internal/app/storage"
+	"github.com/eachinchung/e-service/internal/app/store"
+	"github.com/eachinchung/e-service/internal/app/store/model"
+	"github.com/eachinchung/e-service/internal/pkg/code"
+)
+
+// LoginLimitSrv defines functions used to protect login from brute-force attacks.
+type LoginLimitSrv interface {
+	Check(ctx context.Context, username, ip string) (time.Duration, error)
+	Wait(ctx context.Context, username, ip string) error
+	Fail(ctx context.Context, username, ip string) (bool, error)
+	Failed(ctx context.Context, username, ip string, event *model.SecurityEvents)
+	Succeed(ctx context.Context, username string) error
+	Unlock(ctx context.Context, username string) error
+}
+
+type loginLimitService struct {
+	store   store.Store
+	storage storage.Storage
+}
+
+var _ LoginLimitSrv = &loginLimitService{}
+
+func newLoginLimits(srv *service) *loginLimitService {
+	return &loginLimitService{store: srv.store, storage: srv.storage}
+}
+
+// Check 检查账号与 IP 是否已被锁定，未锁定时返回本次登录前需要等待的时长，失败次数越多等待越久。
+func (l loginLimitService) Check(ctx context.Context, username, ip string) (time.Duration, error) {
+	opts := config.GetConfigIns(nil).LoginLimitOptions
+
+	locked, err := l.exists(ctx, fmt.Sprintf(storage.KeyLoginLocked, username))
+	if err != nil {
+		return 0, err
+	}
+	if locked {
+		return 0, errors.Code(code.ErrLoginLocked, "user is locked")
+	}
+
+	locked, err = l.exists(ctx, fmt.Sprintf(storage.KeyLoginIPLocked, ip))
+	if err != nil {
+		return 0, err
+	}
+	if locked {
+		return 0, errors.Code(code.ErrLoginLocked, "ip is locked")
+	}
+
+	userFailures, err := l.count(ctx, fmt.Sprintf(storage.KeyLoginFailures, username))
+	if err != nil {
+		return 0, err
+	}
+
+	ipFailures, err := l.count(ctx, fmt.Sprintf(storage.KeyLoginIPFailures, ip))
+	if err != nil {
+		return 0, err
+	}
+
+	failures := userFailures
+	if ipFailures > failures {
+		failures = ipFailures
+	}
+
+	delay := opts.DelayStep * time.Duration(failures)
+	if delay > opts.MaxDelay {
+		delay = opts.MaxDelay
+	}
+	return delay, nil
+}
+
+// Wait 检查账号与 IP 是否已被锁定，未锁定时按照失败次数等待，ctx 结束时停止等待并返回 ctx.Err()。
+// 登录、重新验证身份、两步验证与修改密码等校验用户凭证的操作都需要先调用 Wait。
+func (l loginLimitService) Wait(ctx context.Context, username, ip string) error {
+	delay, err := l.Check(ctx, username, ip)
+	if err != nil || delay <= 0 {
+		return err
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Failed 记录一次凭证校验失败，event 不为空时同时记录用户的失败事件，账号因本次失败被锁定时记录锁定事件。
+// 记录失败只打印日志，不影响本次请求。
+func (l loginLimitService) Failed(ctx context.Context, username, ip string, event *model.SecurityEvents) {
+	locked, err := l.Fail(ctx, username, ip)
+	if err != nil {
+		log.L(ctx).Errorf("record login failure failed: %+v", err)
+	}
+
+	if event == nil {
+		return
+	}
+
+	events := securityEventService{store: l.store, storage: l.storage}
+	events.Record(ctx, event)
+	if locked {
+		lockedEvent := *event
+		lockedEvent.ID, lockedEvent.Type, lockedEvent.Detail = 0, model.SecurityEventAccountLocked, ""
+		events.Record(ctx, &lockedEvent)
+	}
+}
+
+// Fail 记录一次登录失败，失败次数达到阈值时锁定账号或 IP，返回账号是否因本次失败被锁定。
+func (l loginLimitService) Fail(ctx context.Context, username, ip string) (bool, error) {
+	opts := config.GetConfigIns(nil).LoginLimitOptions
+
+	userFailures, err := l.storage.IncrWithExpire(ctx, fmt.Sprintf(storage.KeyLoginFailures, username), opts.FailureWindow)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to count user login failures")
+	}
+	locked := userFailures >= opts.MaxUserFailures
+	if locked {
+		log.L(ctx).Warnf("user %s login failed %d times, locked for %s", username, userFailures, opts.LockoutDuration)
+		if err := l.lock(ctx, storage.KeyLoginLocked, storage.KeyLoginFailures, username); err != nil {
+			return false, err
+		}
+	}
+
+	// 记录账号登录失败时使用过的 IP，解除账号锁定时一并解除这些 IP 的锁定
+	if err := l.recordFailedIP(ctx, username, ip); err != nil {
+		log.L(ctx).Errorf("record login failed ip failed: %+v", err)
+	}
+
+	ipFailures, err := l.storage.IncrWithExpire(ctx, fmt.Sprintf(storage.KeyLoginIPFailures, ip), opts.FailureWindow)
+	if err != nil {
+		return locked, errors.Wrap(err, "failed to count ip login failures")
+	}
+	if ipFailures >= opts.MaxIPFailures {
+		log.L(ctx).Warnf("ip %s login failed %d times, locked for %s", ip, ipFailures, opts.LockoutDuration)
+		if err := l.lock(ctx, storage.KeyLoginIPLocked, storage.KeyLoginIPFailures, ip); err != nil {
+			return locked, err
+		}
+	}
+
+	return locked, nil
+}
+
+// Succeed 登录成功后清空账号的失败次数。
+func (l loginLimitService) Succeed(ctx context.Context, username string) error {
+	return l.storage.Del(ctx, fmt.Sprintf(storage.KeyLoginFailures, username))
+}
+
+// Unlock 解除账号的锁定并清空失败次数，账号登录失败时使用过的 IP 的锁定与失败次数也一并清空。
+func (l loginLimitService) Unlock(ctx context.Context, username string) error {
+	ipsKey := fmt.Sprintf(storage.KeyLoginFailedIPs, username)
+	ips, err := l.storage.HKeys(ctx, ipsKey)
+	if err != nil {
+		return errors.Wrap(err, "failed to get login failed ips")
+	}
+
+	keys := []string{
+		fmt.Sprintf(storage.KeyLoginLocked, username),
+		fmt.Sprintf(storage.KeyLoginFailures, username),
+		ipsKey,
+	}
+	for _, ip := range ips {
+		keys = append(keys, fmt.Sprintf(storage.KeyLoginIPLocked, ip), fmt.Sprintf(storage.KeyLoginIPFailures, ip))
+	}
+	return l.storage.Del(ctx, keys...)
+}
+
+func (l loginLimitService) recordFailedIP(ctx context.Context, username, ip string) error {
+	opts := config.GetConfigIns(nil).LoginLimitOptions
+
+	key := fmt.Sprintf(storage.KeyLoginFailedIPs, username)
+	if err := l.storage.HSet(ctx, key, ip, time.Now().Unix()); err != nil {
+		return err
+	}
+
+	// IP 最晚在失败计数过期前被锁定，记录保留到锁定结束
+	return l.storage.Expire(ctx, key, opts.FailureWindow+opts.LockoutDuration)
+}
+
+func (l loginLimitService) lock(ctx context.Context, lockKey, failuresKey, id string) error {
+	opts := config.GetConfigIns(nil).LoginLimitOptions
+
+	if err := l.storage.Set(ctx, fmt.Sprintf(lockKey, id), true, opts.LockoutDuration); err != nil {
+		return errors.Wrap(err, "failed to lock login")
+	}
+	return l.storage.Del(ctx, fmt.Sprintf(failuresKey, id))
+}
+
+func (l loginLimitService) exists(ctx context.Context, key string) (bool, error) {
+	_, err := l.storage.Get(ctx, key)
+	switch {
+	case errors.Is(err, storage.ErrKeyNotFound):
+		return false, nil
+	case err != nil:
+		return false, err
+	}
+	return true, nil
+}
+
+func (l loginLimitService) count(ctx context.Context, key string) (int64, error) {
+	val, err := l.storage.Get(ctx, key)
+	switch {
+	case errors.Is(err, storage.ErrKeyNotFound):
+		return 0, nil
+	case err != nil:
+		return 0, err
+	}
+
+	n, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to parse login failures")
+	}
+	return n, nil
+}
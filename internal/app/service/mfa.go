@@ -0,0 +1,345 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/eachinchung/component-base/utils/idutil"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/app/config"
+	"github.com/eachinchung/e-service/internal/app/storage"
+	"github.com/eachinchung/e-service/internal/app/store"
+	"github.com/eachinchung/e-service/internal/app/store/model"
+	"github.com/eachinchung/e-service/internal/pkg/code"
+	"github.com/eachinchung/e-service/internal/pkg/totp"
+)
+
+// recoveryCodeAlphabet 去掉了容易混淆的字符。
+const recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// MFASrv defines functions used to handle TOTP two-factor authentication.
+type MFASrv interface {
+	Enabled(ctx context.Context, eid string) (bool, error)
+	EnrollRequired(ctx context.Context, eid string) (bool, error)
+	Enroll(ctx context.Context, eid string) (string, string, error)
+	Confirm(ctx context.Context, eid, verifyCode string) ([]string, error)
+	Disable(ctx context.Context, eid, verifyCode string) error
+	RegenerateRecoveryCodes(ctx context.Context, eid, verifyCode string) ([]string, error)
+	Verify(ctx context.Context, eid, verifyCode string) error
+
+	CreateChallenge(ctx context.Context, eid string, amr []string) (string, time.Time, error)
+	ChallengeEID(ctx context.Context, challenge string) (string, error)
+	CompleteChallenge(ctx context.Context, challenge, verifyCode string) (string, []string, error)
+}
+
+// mfaChallenge 是登录时等待完成两步验证的挑战。
+type mfaChallenge struct {
+	EID      string `redis:"eid"`      // 用户名
+	AMR      string `redis:"amr"`      // 第一步验证使用的认证方式，以空格分隔
+	Attempts int64  `redis:"attempts"` // 已尝试次数
+}
+
+type mfaService struct {
+	store   store.Store
+	storage storage.Storage
+}
+
+var _ MFASrv = &mfaService{}
+
+func newMFA(srv *service) *mfaService {
+	return &mfaService{store: srv.store, storage: srv.storage}
+}
+
+// Enabled 返回用户是否已启用两步验证。
+func (m mfaService) Enabled(ctx context.Context, eid string) (bool, error) {
+	secret, err := m.getSecret(ctx, eid)
+	if err != nil {
+		if errors.IsCode(err, code.ErrMFANotEnabled) {
+			return false, nil
+		}
+		return false, err
+	}
+	return secret.Enabled(), nil
+}
+
+// EnrollRequired 返回用户是否被强制要求启用两步验证但尚未启用。
+func (m mfaService) EnrollRequired(ctx context.Context, eid string) (bool, error) {
+	if !config.GetConfigIns(nil).MFAOptions.EnforceSuperUsers {
+		return false, nil
+	}
+
+	isSuperUser, err := superUserService{store: m.store, storage: m.storage}.Exists(ctx, eid)
+	if err != nil || !isSuperUser {
+		return false, err
+	}
+
+	enabled, err := m.Enabled(ctx, eid)
+	return !enabled, err
+}
+
+// Enroll 为用户生成新的密钥，返回密钥与 otpauth URI，用户需要调用 Confirm 提交第一个动态验证码后才会启用。
+func (m mfaService) Enroll(ctx context.Context, eid string) (string, string, error) {
+	secret, err := m.getSecret(ctx, eid)
+	switch {
+	case errors.IsCode(err, code.ErrMFANotEnabled):
+		secret = &model.TOTPSecrets{EID: eid}
+	case err != nil:
+		return "", "", err
+	case secret.Enabled():
+		return "", "", errors.Code(code.ErrMFAAlreadyEnabled, "totp already enabled")
+	}
+
+	if secret.Secret, err = totp.GenerateSecret(); err != nil {
+		return "", "", errors.Code(code.ErrUnknown, err.Error())
+	}
+
+	if err := m.store.TOTP().Save(ctx, m.store.DB(), secret); err != nil {
+		return "", "", errors.Code(code.ErrDatabase, err.Error())
+	}
+
+	return secret.Secret, totp.URI(config.GetConfigIns(nil).MFAOptions.Issuer, eid, secret.Secret), nil
+}
+
+// Confirm 使用第一个动态验证码确认绑定，启用两步验证并返回一组一次性恢复码。
+func (m mfaService) Confirm(ctx context.Context, eid, verifyCode string) ([]string, error) {
+	secret, err := m.getSecret(ctx, eid)
+	if err != nil {
+		return nil, err
+	}
+	if secret.Enabled() {
+		return nil, errors.Code(code.ErrMFAAlreadyEnabled, "totp already enabled")
+	}
+
+	if err := m.verifyTOTP(ctx, secret, verifyCode); err != nil {
+		return nil, err
+	}
+
+	codes, hashes := generateRecoveryCodes(config.GetConfigIns(nil).MFAOptions.RecoveryCodes)
+	secret.EnabledAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+	err = m.store.DB().Transaction(func(tx *gorm.DB) error {
+		if err := m.store.TOTP().Save(ctx, tx, secret); err != nil {
+			return err
+		}
+		return m.store.RecoveryCodes().Replace(ctx, tx, eid, hashes)
+	})
+	if err != nil {
+		return nil, errors.Code(code.ErrDatabase, err.Error())
+	}
+
+	log.L(ctx).Infof("user %s enabled totp", eid)
+	return codes, nil
+}
+
+// Disable 校验动态验证码或恢复码后关闭两步验证。
+func (m mfaService) Disable(ctx context.Context, eid, verifyCode string) error {
+	if err := m.Verify(ctx, eid, verifyCode); err != nil {
+		return err
+	}
+
+	err := m.store.DB().Transaction(func(tx *gorm.DB) error {
+		if err := m.store.RecoveryCodes().Delete(ctx, tx, eid); err != nil {
+			return err
+		}
+		return m.store.TOTP().Delete(ctx, tx, eid)
+	})
+	if err != nil {
+		return errors.Code(code.ErrDatabase, err.Error())
+	}
+
+	log.L(ctx).Infof("user %s disabled totp", eid)
+	return nil
+}
+
+// RegenerateRecoveryCodes 校验动态验证码后重新生成恢复码，旧的恢复码全部失效。
+func (m mfaService) RegenerateRecoveryCodes(ctx context.Context, eid, verifyCode string) ([]string, error) {
+	secret, err := m.getSecret(ctx, eid)
+	if err != nil {
+		return nil, err
+	}
+	if !secret.Enabled() {
+		return nil, errors.Code(code.ErrMFANotEnabled, "totp not enabled")
+	}
+
+	if err := m.verifyTOTP(ctx, secret, verifyCode); err != nil {
+		return nil, err
+	}
+
+	codes, hashes := generateRecoveryCodes(config.GetConfigIns(nil).MFAOptions.RecoveryCodes)
+	if err := m.store.RecoveryCodes().Replace(ctx, m.store.DB(), eid, hashes); err != nil {
+		return nil, errors.Code(code.ErrDatabase, err.Error())
+	}
+
+	return codes, nil
+}
+
+// Verify 校验动态验证码或恢复码，恢复码只能使用一次，同一个动态验证码也只能使用一次。
+// Verify 不限制失败次数，调用方需要通过 LoginLimitSrv 检查并记录失败，Disable 与 RegenerateRecoveryCodes 同理。
+func (m mfaService) Verify(ctx context.Context, eid, verifyCode string) error {
+	secret, err := m.getSecret(ctx, eid)
+	if err != nil {
+		return err
+	}
+	if !secret.Enabled() {
+		return errors.Code(code.ErrMFANotEnabled, "totp not enabled")
+	}
+
+	if len(verifyCode) == totp.Digits {
+		return m.verifyTOTP(ctx, secret, verifyCode)
+	}
+
+	used, err := m.store.RecoveryCodes().Use(ctx, m.store.DB(), eid, hashToken(normalizeRecoveryCode(verifyCode)))
+	if err != nil {
+		return errors.Code(code.ErrDatabase, err.Error())
+	}
+	if !used {
+		return errors.Code(code.ErrMFACodeIncorrect, "recovery code incorrect")
+	}
+
+	log.L(ctx).Infof("user %s used a recovery code", eid)
+	return nil
+}
+
+// CreateChallenge 为已通过第一步验证的用户创建两步验证挑战，amr 是第一步验证使用的认证方式。
+func (m mfaService) CreateChallenge(ctx context.Context, eid string, amr []string) (string, time.Time, error) {
+	expire := config.GetConfigIns(nil).MFAOptions.ChallengeExpire
+	challenge := idutil.GenSecretKey()
+
+	key := fmt.Sprintf(storage.KeyMFAChallenge, hashToken(challenge))
+	if err := m.storage.HSetAllWithExpire(ctx, key, &mfaChallenge{EID: eid, AMR: strings.Join(amr, " ")}, expire); err != nil {
+		return "", time.Time{}, errors.Wrap(err, "failed to save mfa challenge")
+	}
+
+	return challenge, time.Now().Add(expire), nil
+}
+
+// ChallengeEID 返回挑战所属的用户名，用于在校验验证码之前检查用户是否已被锁定。
+func (m mfaService) ChallengeEID(ctx context.Context, challenge string) (string, error) {
+	val, err := m.storage.HMGet(ctx, fmt.Sprintf(storage.KeyMFAChallenge, hashToken(challenge)), "eid")
+	if err != nil && !errors.Is(err, storage.ErrKeyNotFound) {
+		return "", errors.Wrap(err, "failed to get mfa challenge")
+	}
+
+	var eid string
+	if len(val) > 0 {
+		eid, _ = val[0].(string)
+	}
+	if eid == "" {
+		return "", errors.Code(code.ErrMFAChallengeInvalid, "mfa challenge not found")
+	}
+	return eid, nil
+}
+
+// CompleteChallenge 使用动态验证码或恢复码完成挑战，返回挑战所属的用户名与第一步验证使用的认证方式，挑战尝试次数过多时失效。
+// 验证码错误时同样返回挑战所属的用户名，用于记录登录失败事件。
+func (m mfaService) CompleteChallenge(ctx context.Context, challenge, verifyCode string) (string, []string, error) {
+	opts := config.GetConfigIns(nil).MFAOptions
+	key := fmt.Sprintf(storage.KeyMFAChallenge, hashToken(challenge))
+
+	record := &mfaChallenge{}
+	if err := m.storage.HGetAll(ctx, key, record); err != nil {
+		if errors.Is(err, storage.ErrKeyNotFound) {
+			return "", nil, errors.Code(code.ErrMFAChallengeInvalid, "mfa challenge not found")
+		}
+		return "", nil, errors.Wrap(err, "failed to get mfa challenge")
+	}
+
+	attempts, err := m.storage.HIncrByIfExists(ctx, key, "attempts", 1)
+	if err != nil {
+		if errors.Is(err, storage.ErrKeyNotFound) {
+			return "", nil, errors.Code(code.ErrMFAChallengeInvalid, "mfa challenge not found")
+		}
+		return "", nil, errors.Wrap(err, "failed to count mfa challenge attempts")
+	}
+	if attempts > opts.MaxChallengeAttempts {
+		_ = m.storage.Del(ctx, key)
+		return "", nil, errors.Code(code.ErrMFAChallengeInvalid, "too many mfa challenge attempts")
+	}
+
+	if err := m.Verify(ctx, record.EID, verifyCode); err != nil {
+		return record.EID, nil, err
+	}
+
+	// 同一个挑战被并发完成时只有第一次有效
+	used, err := m.storage.HIncrByIfExists(ctx, key, "used", 1)
+	switch {
+	case errors.Is(err, storage.ErrKeyNotFound), err == nil && used > 1:
+		return "", nil, errors.Code(code.ErrMFAChallengeInvalid, "mfa challenge has been completed")
+	case err != nil:
+		return "", nil, errors.Wrap(err, "failed to mark mfa challenge completed")
+	}
+	_ = m.storage.Del(ctx, key)
+	return record.EID, strings.Fields(record.AMR), nil
+}
+
+func (m mfaService) getSecret(ctx context.Context, eid string) (*model.TOTPSecrets, error) {
+	secret, err := m.store.TOTP().Get(ctx, m.store.DB(), eid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.Code(code.ErrMFANotEnabled, err.Error())
+		}
+		return nil, errors.Code(code.ErrDatabase, err.Error())
+	}
+	return secret, nil
+}
+
+// verifyTOTP 校验动态验证码，并记录已使用的时间步防止验证码被重放。
+func (m mfaService) verifyTOTP(ctx context.Context, secret *model.TOTPSecrets, verifyCode string) error {
+	skew := config.GetConfigIns(nil).MFAOptions.Skew
+
+	step, ok := totp.Validate(secret.Secret, verifyCode, time.Now(), skew)
+	if !ok {
+		return errors.Code(code.ErrMFACodeIncorrect, "totp code incorrect")
+	}
+
+	ttl := totp.Period * time.Duration(2*skew+1)
+	fresh, err := m.storage.SetNX(ctx, fmt.Sprintf(storage.KeyMFATOTPUsedStep, secret.EID, step), true, ttl)
+	if err != nil {
+		return errors.Wrap(err, "failed to mark totp code used")
+	}
+	if !fresh {
+		return errors.Code(code.ErrMFACodeIncorrect, "totp code already used")
+	}
+
+	return nil
+}
+
+// generateRecoveryCodes 生成 n 个形如 xxxxx-xxxxx 的恢复码，同时返回它们的哈希。
+func generateRecoveryCodes(n int) ([]string, []string) {
+	codes := make([]string, 0, n)
+	hashes := make([]string, 0, n)
+
+	max := big.NewInt(int64(len(recoveryCodeAlphabet)))
+	for i := 0; i < n; i++ {
+		buf := make([]byte, 10)
+		for j := range buf {
+			idx, err := rand.Int(rand.Reader, max)
+			if err != nil {
+				panic(err)
+			}
+			buf[j] = recoveryCodeAlphabet[idx.Int64()]
+		}
+
+		c := string(buf[:5]) + "-" + string(buf[5:])
+		codes = append(codes, c)
+		hashes = append(hashes, hashToken(normalizeRecoveryCode(c)))
+	}
+
+	return codes, hashes
+}
+
+// normalizeRecoveryCode 忽略恢复码中的大小写、空格与连字符。
+func normalizeRecoveryCode(c string) string {
+	c = strings.ToLower(c)
+	c = strings.ReplaceAll(c, "-", "")
+	return strings.ReplaceAll(c, " ", "")
+}
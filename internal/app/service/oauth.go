@@ -0,0 +1,282 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/eachinchung/component-base/utils/idutil"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/app/config"
+	"github.com/eachinchung/e-service/internal/app/storage"
+	"github.com/eachinchung/e-service/internal/app/store"
+	"github.com/eachinchung/e-service/internal/app/store/model"
+	"github.com/eachinchung/e-service/internal/pkg/code"
+)
+
+// CodeChallengeMethodS256 是唯一支持的 PKCE 校验方式。
+const CodeChallengeMethodS256 = "S256"
+
+// OAuthSrv defines functions used to handle OAuth 2.0 clients, consents and authorization codes.
+type OAuthSrv interface {
+	CreateClient(ctx context.Context, client *model.OAuthClients, confidential bool) (string, error)
+	GetClient(ctx context.Context, clientID string) (*model.OAuthClients, error)
+	DeleteClient(ctx context.Context, client *model.OAuthClients) error
+	AuthenticateClient(ctx context.Context, clientID, secret string) (*model.OAuthClients, error)
+
+	ValidateScopes(client *model.OAuthClients, scope string) ([]string, error)
+	HasConsent(ctx context.Context, eid, clientID string, scopes []string) (bool, error)
+	SaveConsent(ctx context.Context, eid, clientID string, scopes []string) error
+
+	CreateAuthorizationCode(ctx context.Context, record *AuthorizationCode) (string, error)
+	ExchangeAuthorizationCode(ctx context.Context, code, clientID, redirectURI, codeVerifier string) (*AuthorizationCode, error)
+}
+
+// AuthorizationCode 是保存在 storage 中的授权码记录，授权码本身只保存哈希值。
+type AuthorizationCode struct {
+	ClientID            string `redis:"client_id"`             // 应用 ID
+	EID                 string `redis:"eid"`                   // 用户名
+	RedirectURI         string `redis:"redirect_uri"`          // 申请授权码时使用的回调地址
+	Scope               string `redis:"scope"`                 // 授予的权限
+	CodeChallenge       string `redis:"code_challenge"`        // PKCE 校验值
+	CodeChallengeMethod string `redis:"code_challenge_method"` // PKCE 校验方式
+	Nonce               string `redis:"nonce"`                 // OpenID Connect 的 nonce，原样写入 ID token
+	FamilyID            string `redis:"fid"`                   // 使用授权码签发的令牌族 ID
+	Used                int64  `redis:"used"`                  // 使用次数
+}
+
+type oauthService struct {
+	store   store.Store
+	storage storage.Storage
+}
+
+var _ OAuthSrv = &oauthService{}
+
+func newOAuth(srv *service) *oauthService {
+	return &oauthService{store: srv.store, storage: srv.storage}
+}
+
+// CreateClient 登记一个第三方应用，机密应用会生成一个密钥，密钥只在创建时返回一次。
+func (o oauthService) CreateClient(ctx context.Context, client *model.OAuthClients, confidential bool) (string, error) {
+	if err := checkSupportedScopes(strings.Fields(client.Scopes)); err != nil {
+		return "", err
+	}
+
+	var secret string
+	if confidential {
+		secret = idutil.GenSecretKey()
+		client.SecretHash = hashToken(secret)
+	}
+	client.ClientID = idutil.GenSecretID()
+
+	if err := o.store.OAuthClients().Create(ctx, o.store.DB(), client); err != nil {
+		return "", errors.Code(code.ErrDatabase, err.Error())
+	}
+
+	log.L(ctx).Infof("user %s created oauth client %s", client.OwnerEID, client.ClientID)
+	return secret, nil
+}
+
+func (o oauthService) GetClient(ctx context.Context, clientID string) (*model.OAuthClients, error) {
+	client, err := o.store.OAuthClients().Get(ctx, o.store.DB(), clientID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.Code(code.ErrOAuthClientNotExist, err.Error())
+		}
+		return nil, errors.Code(code.ErrDatabase, err.Error())
+	}
+	return client, nil
+}
+
+func (o oauthService) DeleteClient(ctx context.Context, client *model.OAuthClients) error {
+	if err := o.store.OAuthClients().Delete(ctx, o.store.DB(), client); err != nil {
+		return errors.Code(code.ErrDatabase, err.Error())
+	}
+	return nil
+}
+
+// AuthenticateClient 校验应用的身份，机密应用必须提供正确的密钥，公开应用不能提供密钥。
+func (o oauthService) AuthenticateClient(ctx context.Context, clientID, secret string) (*model.OAuthClients, error) {
+	client, err := o.GetClient(ctx, clientID)
+	if err != nil {
+		if errors.IsCode(err, code.ErrOAuthClientNotExist) {
+			return nil, errors.Code(code.ErrOAuthInvalidClient, "client not found")
+		}
+		return nil, err
+	}
+
+	if !client.Confidential() {
+		if secret != "" {
+			return nil, errors.Code(code.ErrOAuthInvalidClient, "public client must not use a secret")
+		}
+		return client, nil
+	}
+
+	if subtle.ConstantTimeCompare([]byte(client.SecretHash), []byte(hashToken(secret))) != 1 {
+		return nil, errors.Code(code.ErrOAuthInvalidClient, "client secret incorrect")
+	}
+	return client, nil
+}
+
+// ValidateScopes 校验申请的权限，权限必须是系统支持并且应用允许申请的，scope 为空时使用应用允许申请的全部权限。
+func (o oauthService) ValidateScopes(client *model.OAuthClients, scope string) ([]string, error) {
+	scopes := strings.Fields(scope)
+	if len(scopes) == 0 {
+		scopes = strings.Fields(client.Scopes)
+	}
+
+	if err := checkSupportedScopes(scopes); err != nil {
+		return nil, err
+	}
+
+	allowed := strings.Fields(client.Scopes)
+	for _, s := range scopes {
+		if !contains(allowed, s) {
+			return nil, errors.Code(code.ErrOAuthInvalidScope, fmt.Sprintf("scope not allowed for client: %s", s))
+		}
+	}
+
+	return scopes, nil
+}
+
+// HasConsent 判断用户是否已经向应用授予了全部权限。
+func (o oauthService) HasConsent(ctx context.Context, eid, clientID string, scopes []string) (bool, error) {
+	consent, err := o.store.OAuthConsents().Get(ctx, o.store.DB(), eid, clientID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, errors.Code(code.ErrDatabase, err.Error())
+	}
+
+	granted := strings.Fields(consent.Scopes)
+	for _, s := range scopes {
+		if !contains(granted, s) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// SaveConsent 记录用户向应用授予的权限，新的权限会与已经授予的权限合并。
+func (o oauthService) SaveConsent(ctx context.Context, eid, clientID string, scopes []string) error {
+	db := o.store.DB()
+
+	consent, err := o.store.OAuthConsents().Get(ctx, db, eid, clientID)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		consent = &model.OAuthConsents{EID: eid, ClientID: clientID}
+	case err != nil:
+		return errors.Code(code.ErrDatabase, err.Error())
+	}
+
+	granted := strings.Fields(consent.Scopes)
+	for _, s := range scopes {
+		if !contains(granted, s) {
+			granted = append(granted, s)
+		}
+	}
+	consent.Scopes = strings.Join(granted, " ")
+
+	if err := o.store.OAuthConsents().Save(ctx, db, consent); err != nil {
+		return errors.Code(code.ErrDatabase, err.Error())
+	}
+	return nil
+}
+
+// CreateAuthorizationCode 签发一个授权码，授权码只能使用一次。
+func (o oauthService) CreateAuthorizationCode(ctx context.Context, record *AuthorizationCode) (string, error) {
+	authCode := idutil.GenSecretKey()
+	record.FamilyID = idutil.GenSecretID()
+	record.Used = 0
+
+	key := fmt.Sprintf(storage.KeyOAuthCode, hashToken(authCode))
+	if err := o.storage.HSetAllWithExpire(ctx, key, record, config.GetConfigIns(nil).OAuthOptions.CodeExpire); err != nil {
+		return "", errors.Wrap(err, "failed to save authorization code")
+	}
+
+	return authCode, nil
+}
+
+// ExchangeAuthorizationCode 校验并消费授权码。
+// 若授权码被重复使用，说明它可能已经泄露，使用它签发的令牌族会被吊销。
+func (o oauthService) ExchangeAuthorizationCode(ctx context.Context, authCode, clientID, redirectURI, codeVerifier string) (*AuthorizationCode, error) {
+	key := fmt.Sprintf(storage.KeyOAuthCode, hashToken(authCode))
+
+	record := &AuthorizationCode{}
+	if err := o.storage.HGetAll(ctx, key, record); err != nil {
+		if errors.Is(err, storage.ErrKeyNotFound) {
+			return nil, errors.Code(code.ErrOAuthInvalidGrant, "authorization code not found")
+		}
+		return nil, errors.Wrap(err, "failed to get authorization code")
+	}
+
+	if record.ClientID != clientID {
+		return nil, errors.Code(code.ErrOAuthInvalidGrant, "authorization code was issued to another client")
+	}
+
+	if record.RedirectURI != redirectURI {
+		return nil, errors.Code(code.ErrOAuthInvalidGrant, "redirect uri mismatch")
+	}
+
+	if record.CodeChallenge != "" && !verifyCodeChallenge(record.CodeChallenge, codeVerifier) {
+		return nil, errors.Code(code.ErrOAuthInvalidGrant, "code verifier incorrect")
+	}
+
+	// 校验通过后才消费授权码，避免携带错误参数的请求使合法的授权码失效
+	used, err := o.storage.HIncrByIfExists(ctx, key, "used", 1)
+	if err != nil {
+		if errors.Is(err, storage.ErrKeyNotFound) {
+			return nil, errors.Code(code.ErrOAuthInvalidGrant, "authorization code not found")
+		}
+		return nil, errors.Wrap(err, "failed to mark authorization code used")
+	}
+	if used > 1 {
+		log.L(ctx).Warnf("authorization code reused, revoke token family: %s, eid: %s", record.FamilyID, record.EID)
+		if err := (tokenService{store: o.store, storage: o.storage}).RevokeFamily(ctx, record.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, errors.Code(code.ErrOAuthInvalidGrant, "authorization code reused")
+	}
+
+	return record, nil
+}
+
+// verifyCodeChallenge 按照 S256 方式校验 code_verifier。
+func verifyCodeChallenge(challenge, verifier string) bool {
+	sum := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
+
+// checkSupportedScopes 检查权限是否都是系统支持的。
+func checkSupportedScopes(scopes []string) error {
+	supported := config.GetConfigIns(nil).OAuthOptions.Scopes
+	for _, s := range scopes {
+		if !contains(supported, s) {
+			return errors.Code(code.ErrOAuthInvalidScope, fmt.Sprintf("unsupported scope: %s", s))
+		}
+	}
+	return nil
+}
+
+// HasScope 判断权限列表中是否包含 scope。
+func HasScope(scopes []string, scope string) bool {
+	return contains(scopes, scope)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
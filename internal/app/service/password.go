@@ -0,0 +1,325 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/eachinchung/component-base/db/options"
+	"github.com/eachinchung/component-base/utils/idutil"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/app/config"
+	"github.com/eachinchung/e-service/internal/app/storage"
+	"github.com/eachinchung/e-service/internal/app/store"
+	"github.com/eachinchung/e-service/internal/app/store/model"
+	"github.com/eachinchung/e-service/internal/pkg/code"
+	"github.com/eachinchung/e-service/internal/pkg/hasher"
+	"github.com/eachinchung/e-service/internal/pkg/pwdpolicy"
+)
+
+// PasswordSrv defines functions used to handle user passwords.
+type PasswordSrv interface {
+	SendResetCode(ctx context.Context, phone, ip string) error
+	CreateResetTicket(ctx context.Context, phone, verifyCode, ip string) (string, time.Time, error)
+	Reset(ctx context.Context, ticket, password, ip string) (string, error)
+	Change(ctx context.Context, eid, oldPassword, newPassword string) error
+	Rehash(ctx context.Context, user *model.Users, password string) error
+	CheckCompliance(ctx context.Context, user *model.Users, password string) error
+	ChangeRequired(ctx context.Context, user *model.Users) (bool, error)
+}
+
+// resetTicket 是保存在 storage 中的重置密码凭证，凭证本身只保存哈希值。
+type resetTicket struct {
+	EID  string `redis:"eid"`  // 用户名
+	Used int64  `redis:"used"` // 使用次数
+}
+
+type passwordService struct {
+	store   store.Store
+	storage storage.Storage
+}
+
+var _ PasswordSrv = &passwordService{}
+
+func newPasswords(srv *service) *passwordService {
+	return &passwordService{store: srv.store, storage: srv.storage}
+}
+
+// SendResetCode 向已注册的手机号发送找回密码的验证码。
+// 为了避免泄露手机号是否已注册，未注册的手机号同样计入发送频率限制，但不会真正发送验证码。
+func (p passwordService) SendResetCode(ctx context.Context, phone, ip string) error {
+	verifications := verificationService{store: p.store, storage: p.storage}
+	if err := verifications.checkSendLimit(ctx, phone, ip); err != nil {
+		return err
+	}
+
+	_, err := p.store.User().Get(ctx, p.store.DB(), phone, options.WithQuery("phone = ?"))
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		log.L(ctx).Infof("password reset requested for unregistered phone from ip %s", ip)
+		return nil
+	case err != nil:
+		return errors.Code(code.ErrDatabase, err.Error())
+	}
+
+	return verifications.sendSMSCode(ctx, ScenePasswordReset, phone)
+}
+
+// CreateResetTicket 校验找回密码的验证码，并签发一个短期有效、只能使用一次的重置密码凭证。
+// 未注册的手机号没有验证码，与验证码过期返回相同的错误。
+func (p passwordService) CreateResetTicket(ctx context.Context, phone, verifyCode, ip string) (string, time.Time, error) {
+	opts := config.GetConfigIns(nil).PasswordResetOptions
+
+	if err := p.checkIPLimit(ctx, ip); err != nil {
+		return "", time.Time{}, err
+	}
+
+	verifications := verificationService{store: p.store, storage: p.storage}
+	if err := verifications.VerifySMSCode(ctx, ScenePasswordReset, phone, verifyCode); err != nil {
+		return "", time.Time{}, err
+	}
+
+	user, err := p.store.User().Get(ctx, p.store.DB(), phone, options.WithQuery("phone = ?"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", time.Time{}, errors.Code(code.ErrVerificationCodeExpired, err.Error())
+		}
+		return "", time.Time{}, errors.Code(code.ErrDatabase, err.Error())
+	}
+
+	ticket := idutil.GenSecretKey()
+	key := fmt.Sprintf(storage.KeyPasswordResetTicket, hashToken(ticket))
+	if err := p.storage.HSetAllWithExpire(ctx, key, &resetTicket{EID: user.EID}, opts.TicketExpire); err != nil {
+		return "", time.Time{}, errors.Wrap(err, "failed to save password reset ticket")
+	}
+
+	return ticket, time.Now().Add(opts.TicketExpire), nil
+}
+
+// Reset 使用重置密码凭证设置新密码，成功后解除登录锁定，结束用户所有的会话，并返回重置密码的用户名。
+func (p passwordService) Reset(ctx context.Context, ticket, password, ip string) (string, error) {
+	if err := p.checkIPLimit(ctx, ip); err != nil {
+		return "", err
+	}
+
+	key := fmt.Sprintf(storage.KeyPasswordResetTicket, hashToken(ticket))
+
+	record := &resetTicket{}
+	if err := p.storage.HGetAll(ctx, key, record); err != nil {
+		if errors.Is(err, storage.ErrKeyNotFound) {
+			return "", errors.Code(code.ErrPasswordResetTicketInvalid, "password reset ticket not found")
+		}
+		return "", errors.Wrap(err, "failed to get password reset ticket")
+	}
+
+	user, err := p.store.User().Get(ctx, p.store.DB(), record.EID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", errors.Code(code.ErrPasswordResetTicketInvalid, err.Error())
+		}
+		return "", errors.Code(code.ErrDatabase, err.Error())
+	}
+
+	// 新密码不符合密码策略时凭证仍然有效，用户可以换一个密码重试
+	if err := p.checkReused(ctx, user, password); err != nil {
+		return "", err
+	}
+
+	used, err := p.storage.HIncrByIfExists(ctx, key, "used", 1)
+	if err != nil {
+		if errors.Is(err, storage.ErrKeyNotFound) {
+			return "", errors.Code(code.ErrPasswordResetTicketInvalid, "password reset ticket not found")
+		}
+		return "", errors.Wrap(err, "failed to mark password reset ticket used")
+	}
+	if used > 1 {
+		return "", errors.Code(code.ErrPasswordResetTicketInvalid, "password reset ticket has been used")
+	}
+	_ = p.storage.Del(ctx, key)
+
+	if err := p.setPassword(ctx, user, password); err != nil {
+		return "", err
+	}
+
+	if err := (loginLimitService{store: p.store, storage: p.storage}).Unlock(ctx, user.EID); err != nil {
+		log.L(ctx).Errorf("unlock user after password reset failed: %+v", err)
+	}
+
+	log.L(ctx).Infof("user %s reset password from ip %s", user.EID, ip)
+	return user.EID, sessionService{store: p.store, storage: p.storage}.EndAll(ctx, user.EID)
+}
+
+// Change 校验当前密码后修改密码，修改后凭证版本递增，用户此前签发的所有 token 立即失效。
+func (p passwordService) Change(ctx context.Context, eid, oldPassword, newPassword string) error {
+	db := p.store.DB()
+
+	user, err := p.store.User().Get(ctx, db, eid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.Code(code.ErrUserNotExist, err.Error())
+		}
+		return errors.Code(code.ErrDatabase, err.Error())
+	}
+
+	if err := user.ComparePasswordHash(oldPassword); err != nil {
+		return errors.Code(code.ErrPasswordIncorrect, err.Error())
+	}
+
+	if err := p.checkReused(ctx, user, newPassword); err != nil {
+		return err
+	}
+
+	if err := p.setPassword(ctx, user, newPassword); err != nil {
+		return err
+	}
+
+	// token 已经因为凭证版本变化而失效，这里只需要把会话标记为已结束
+	if err := p.store.Sessions().EndAll(ctx, db, eid); err != nil {
+		return errors.Code(code.ErrDatabase, err.Error())
+	}
+
+	log.L(ctx).Infof("user %s changed password", eid)
+	return nil
+}
+
+// Rehash 在密码校验成功后，使用当前配置的算法与参数重新计算过时的密码哈希，凭证版本保持不变。
+func (p passwordService) Rehash(ctx context.Context, user *model.Users, password string) error {
+	if !hasher.Client().NeedsRehash(user.PasswordHash) {
+		return nil
+	}
+
+	hash, err := hasher.Client().Hash(password)
+	if err != nil {
+		return errors.Wrap(err, "failed to hash password")
+	}
+
+	user.PasswordHash = hash
+	if err := p.store.User().Update(ctx, p.store.DB(), user); err != nil {
+		return errors.Code(code.ErrDatabase, err.Error())
+	}
+
+	if err := p.storage.Del(ctx, fmt.Sprintf(storage.KeyUser, user.EID), fmt.Sprintf(storage.KeyUserUnscoped, user.EID)); err != nil {
+		return errors.Code(code.ErrUnknown, err.Error())
+	}
+
+	log.L(ctx).Infof("user %s password rehashed", user.EID)
+	return nil
+}
+
+// CheckCompliance 在密码校验成功后检查当前密码是否符合密码策略。
+// 不符合时标记用户必须修改密码，标记在密码修改后清除，使后续的 MFA 等登录步骤同样只能签发修改密码的受限 token。
+func (p passwordService) CheckCompliance(ctx context.Context, user *model.Users, password string) error {
+	if pwdpolicy.Client().Check(password) == nil {
+		return nil
+	}
+
+	if err := p.storage.Set(ctx, fmt.Sprintf(storage.KeyPasswordChangeRequired, user.EID), true, maxTokenLifetime()); err != nil {
+		return errors.Code(code.ErrUnknown, err.Error())
+	}
+	return nil
+}
+
+// ChangeRequired 检查用户是否必须先修改密码，密码已过期或当前密码不符合密码策略时需要修改。
+func (p passwordService) ChangeRequired(ctx context.Context, user *model.Users) (bool, error) {
+	if pwdpolicy.Client().Expired(user.PasswordChangedAt) {
+		return true, nil
+	}
+
+	required, err := p.storage.GetBool(ctx, fmt.Sprintf(storage.KeyPasswordChangeRequired, user.EID))
+	switch {
+	case errors.Is(err, storage.ErrKeyNotFound):
+		return false, nil
+	case err != nil:
+		return false, errors.Code(code.ErrUnknown, err.Error())
+	}
+	return required, nil
+}
+
+// setPassword 设置用户的新密码并递增凭证版本，旧密码的哈希保存到密码历史中，同时清除用户缓存。
+func (p passwordService) setPassword(ctx context.Context, user *model.Users, password string) error {
+	hash, err := hasher.Client().Hash(password)
+	if err != nil {
+		return errors.Wrap(err, "failed to hash password")
+	}
+
+	oldHash := user.PasswordHash
+	user.PasswordHash = hash
+	user.PasswordChangedAt = time.Now()
+	user.CredentialVersion++
+
+	// 当前密码也算作最近使用过的密码，密码历史只需要保存 history - 1 个旧密码
+	keep := pwdpolicy.Client().History() - 1
+	err = p.store.DB().Transaction(func(tx *gorm.DB) error {
+		if err := p.store.User().Update(ctx, tx, user); err != nil {
+			return err
+		}
+
+		if keep <= 0 {
+			return nil
+		}
+
+		if err := p.store.PasswordHistory().Create(ctx, tx, &model.PasswordHistory{EID: user.EID, PasswordHash: oldHash}); err != nil {
+			return err
+		}
+		return p.store.PasswordHistory().Prune(ctx, tx, user.EID, keep)
+	})
+	if err != nil {
+		return errors.Code(code.ErrDatabase, err.Error())
+	}
+
+	err = p.storage.Del(ctx,
+		fmt.Sprintf(storage.KeyUser, user.EID),
+		fmt.Sprintf(storage.KeyUserUnscoped, user.EID),
+		fmt.Sprintf(storage.KeyPasswordChangeRequired, user.EID),
+	)
+	if err != nil {
+		return errors.Code(code.ErrUnknown, err.Error())
+	}
+	return nil
+}
+
+// checkReused 检查新密码是否与当前密码或密码历史中最近使用过的密码相同。
+func (p passwordService) checkReused(ctx context.Context, user *model.Users, password string) error {
+	policy := pwdpolicy.Client()
+	if policy.History() == 0 {
+		return nil
+	}
+
+	hashes := []string{user.PasswordHash}
+	if policy.History() > 1 {
+		histories, err := p.store.PasswordHistory().ListRecent(ctx, p.store.DB(), user.EID, policy.History()-1)
+		if err != nil {
+			return errors.Code(code.ErrDatabase, err.Error())
+		}
+		for _, history := range histories {
+			hashes = append(hashes, history.PasswordHash)
+		}
+	}
+
+	for _, hash := range hashes {
+		if hasher.Client().Compare(hash, password) == nil {
+			return errors.WithCode(policy.Reused(), code.ErrValidation, "password has been used recently")
+		}
+	}
+	return nil
+}
+
+// checkIPLimit 限制同一 IP 校验找回密码验证码与重置密码的频率。
+func (p passwordService) checkIPLimit(ctx context.Context, ip string) error {
+	opts := config.GetConfigIns(nil).PasswordResetOptions
+
+	count, err := p.storage.IncrWithExpire(ctx, fmt.Sprintf(storage.KeyPasswordResetIPCount, ip), time.Hour)
+	if err != nil {
+		return errors.Wrap(err, "failed to count password reset by ip")
+	}
+	if count > opts.IPHourlyLimit {
+		log.L(ctx).Warnf("ip %s reset password too frequently", ip)
+		return errors.Code(code.ErrPasswordResetTooFrequently, "ip reset password too frequently")
+	}
+
+	return nil
+}
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"time"
+
+	"github.com/eachinchung/component-base/utils/idutil"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/app/config"
+	"github.com/eachinchung/e-service/internal/app/storage"
+	"github.com/eachinchung/e-service/internal/app/store"
+	"github.com/eachinchung/e-service/internal/pkg/code"
+)
+
+// 二维码的状态，只能按照 pending -> scanned -> confirmed 或 pending -> scanned -> denied 的顺序变化。
+const (
+	QRLoginPending   = "pending"
+	QRLoginScanned   = "scanned"
+	QRLoginConfirmed = "confirmed"
+	QRLoginDenied    = "denied"
+)
+
+// QRLoginSrv defines functions used to login by scanning a QR code with a logged-in mobile app.
+type QRLoginSrv interface {
+	Create(ctx context.Context, ip, userAgent string) (*QRLogin, error)
+	Status(ctx context.Context, qrCode, ticket string) (*QRLogin, error)
+	Scan(ctx context.Context, qrCode, eid string) (*QRLogin, error)
+	Confirm(ctx context.Context, qrCode, eid string) error
+	Deny(ctx context.Context, qrCode, eid string) error
+	Consume(ctx context.Context, qrCode, ticket string) (string, error)
+}
+
+// QRLogin 是登录二维码的信息。
+// Code 是二维码的内容，Ticket 只返回给创建二维码的浏览器，用于查询状态与领取 token，避免二维码被偷拍后抢先登录。
+type QRLogin struct {
+	Code      string
+	Ticket    string
+	Expire    time.Time
+	State     string // 二维码状态
+	EID       string // 扫码的用户
+	IP        string // 创建二维码的浏览器 IP
+	UserAgent string // 创建二维码的浏览器 User-Agent
+}
+
+// qrLoginRecord 是保存在 storage 中的二维码，ticket 只保存哈希值。
+type qrLoginRecord struct {
+	TicketHash string `redis:"ticket"`     // 浏览器凭证的哈希值
+	State      string `redis:"state"`      // 二维码状态
+	EID        string `redis:"eid"`        // 扫码的用户
+	IP         string `redis:"ip"`         // 创建二维码的浏览器 IP
+	UserAgent  string `redis:"user_agent"` // 创建二维码的浏览器 User-Agent
+	Used       int64  `redis:"used"`       // 使用次数
+}
+
+func (r *qrLoginRecord) login(qrCode string) *QRLogin {
+	return &QRLogin{Code: qrCode, State: r.State, EID: r.EID, IP: r.IP, UserAgent: r.UserAgent}
+}
+
+type qrLoginService struct {
+	store   store.Store
+	storage storage.Storage
+}
+
+var _ QRLoginSrv = &qrLoginService{}
+
+func newQRLogins(srv *service) *qrLoginService {
+	return &qrLoginService{store: srv.store, storage: srv.storage}
+}
+
+// Create 创建一个等待扫码的登录二维码。
+func (q qrLoginService) Create(ctx context.Context, ip, userAgent string) (*QRLogin, error) {
+	opts := config.GetConfigIns(nil).QRLoginOptions
+
+	login := QRLogin{
+		Code:      idutil.GenSecretID(),
+		Ticket:    idutil.GenSecretKey(),
+		Expire:    time.Now().Add(opts.Expire),
+		State:     QRLoginPending,
+		IP:        ip,
+		UserAgent: userAgent,
+	}
+
+	record := &qrLoginRecord{TicketHash: hashToken(login.Ticket), State: login.State, IP: ip, UserAgent: userAgent}
+	if err := q.storage.HSetAllWithExpire(ctx, fmt.Sprintf(storage.KeyQRLogin, login.Code), record, opts.Expire); err != nil {
+		return nil, errors.Wrap(err, "failed to save qr login")
+	}
+
+	return &login, nil
+}
+
+// Status 返回二维码的当前状态，只有持有 ticket 的浏览器可以查询。
+func (q qrLoginService) Status(ctx context.Context, qrCode, ticket string) (*QRLogin, error) {
+	record, err := q.get(ctx, qrCode)
+	if err != nil {
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(record.TicketHash), []byte(hashToken(ticket))) != 1 {
+		return nil, errors.Code(code.ErrQRLoginInvalid, "qr login ticket mismatch")
+	}
+
+	return record.login(qrCode), nil
+}
+
+// Scan 记录扫码的用户，同一用户可以重复扫码，二维码被其他用户扫码后不能再扫。
+// 返回的二维码信息用于在手机上展示登录的浏览器，便于用户判断是否本人操作。
+func (q qrLoginService) Scan(ctx context.Context, qrCode, eid string) (*QRLogin, error) {
+	record, err := q.get(ctx, qrCode)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case record.State == QRLoginScanned && record.EID == eid:
+		return record.login(qrCode), nil
+	case record.State != QRLoginPending:
+		return nil, errors.Code(code.ErrQRLoginStateInvalid, "qr login has been scanned")
+	}
+
+	// 多个用户同时扫码时只有第一个用户可以扫码成功
+	ok, err := q.storage.HCompareAndSet(
+		ctx,
+		fmt.Sprintf(storage.KeyQRLogin, qrCode),
+		"state", QRLoginPending,
+		"state", QRLoginScanned, "eid", eid,
+	)
+	if err != nil {
+		if errors.Is(err, storage.ErrKeyNotFound) {
+			return nil, errors.Code(code.ErrQRLoginInvalid, "qr login not found")
+		}
+		return nil, errors.Wrap(err, "failed to update qr login")
+	}
+	if !ok {
+		return nil, errors.Code(code.ErrQRLoginStateInvalid, "qr login has been scanned")
+	}
+
+	record.State, record.EID = QRLoginScanned, eid
+	return record.login(qrCode), nil
+}
+
+// Confirm 扫码的用户确认登录，浏览器随后可以领取 token。
+func (q qrLoginService) Confirm(ctx context.Context, qrCode, eid string) error {
+	return q.decide(ctx, qrCode, eid, QRLoginConfirmed)
+}
+
+// Deny 扫码的用户拒绝登录。
+func (q qrLoginService) Deny(ctx context.Context, qrCode, eid string) error {
+	return q.decide(ctx, qrCode, eid, QRLoginDenied)
+}
+
+// Consume 为已确认的二维码领取登录用户，二维码只能使用一次。
+func (q qrLoginService) Consume(ctx context.Context, qrCode, ticket string) (string, error) {
+	login, err := q.Status(ctx, qrCode, ticket)
+	if err != nil {
+		return "", err
+	}
+	if login.State != QRLoginConfirmed {
+		return "", errors.Code(code.ErrQRLoginStateInvalid, "qr login has not been confirmed")
+	}
+
+	key := fmt.Sprintf(storage.KeyQRLogin, qrCode)
+	used, err := q.storage.HIncrByIfExists(ctx, key, "used", 1)
+	if err != nil {
+		if errors.Is(err, storage.ErrKeyNotFound) {
+			return "", errors.Code(code.ErrQRLoginInvalid, "qr login not found")
+		}
+		return "", errors.Wrap(err, "failed to mark qr login used")
+	}
+	_ = q.storage.Del(ctx, key)
+
+	if used > 1 {
+		return "", errors.Code(code.ErrQRLoginInvalid, "qr login has been used")
+	}
+
+	log.L(ctx).Infof("user %s login by qr code from ip %s", login.EID, login.IP)
+	return login.EID, nil
+}
+
+func (q qrLoginService) decide(ctx context.Context, qrCode, eid, state string) error {
+	record, err := q.get(ctx, qrCode)
+	if err != nil {
+		return err
+	}
+
+	if record.State != QRLoginScanned || record.EID != eid {
+		return errors.Code(code.ErrQRLoginStateInvalid, "qr login has not been scanned by the user")
+	}
+
+	// 扫码后的二维码只能被确认或拒绝一次
+	ok, err := q.storage.HCompareAndSet(ctx, fmt.Sprintf(storage.KeyQRLogin, qrCode), "state", QRLoginScanned, "state", state)
+	if err != nil {
+		if errors.Is(err, storage.ErrKeyNotFound) {
+			return errors.Code(code.ErrQRLoginInvalid, "qr login not found")
+		}
+		return errors.Wrap(err, "failed to update qr login")
+	}
+	if !ok {
+		return errors.Code(code.ErrQRLoginStateInvalid, "qr login has been decided")
+	}
+	return nil
+}
+
+func (q qrLoginService) get(ctx context.Context, qrCode string) (*qrLoginRecord, error) {
+	record := &qrLoginRecord{}
+	if err := q.storage.HGetAll(ctx, fmt.Sprintf(storage.KeyQRLogin, qrCode), record); err != nil {
+		if errors.Is(err, storage.ErrKeyNotFound) {
+			return nil, errors.Code(code.ErrQRLoginInvalid, "qr login not found")
+		}
+		return nil, errors.Wrap(err, "failed to get qr login")
+	}
+
+	return record, nil
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/eachinchung/component-base/db/options"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/app/storage"
+	"github.com/eachinchung/e-service/internal/app/store"
+	"github.com/eachinchung/e-service/internal/app/store/model"
+	"github.com/eachinchung/e-service/internal/pkg/code"
+)
+
+// SecurityEventSrv defines functions used to record and query the security events of users.
+type SecurityEventSrv interface {
+	Record(ctx context.Context, event *model.SecurityEvents)
+	List(ctx context.Context, filter *SecurityEventFilter, page, pageSize int) ([]*model.SecurityEvents, int64, error)
+}
+
+// SecurityEventFilter 是查询安全事件的条件，字段为空时不按该字段过滤。
+type SecurityEventFilter struct {
+	EID  string
+	Type string
+	IP   string
+}
+
+type securityEventService struct {
+	store   store.Store
+	storage storage.Storage
+}
+
+var _ SecurityEventSrv = &securityEventService{}
+
+func newSecurityEvents(srv *service) *securityEventService {
+	return &securityEventService{store: srv.store, storage: srv.storage}
+}
+
+// Record 记录一个安全事件，记录失败只打印日志，不影响触发事件的请求。
+func (s securityEventService) Record(ctx context.Context, event *model.SecurityEvents) {
+	event.CreatedAt = time.Now()
+
+	if err := s.store.SecurityEvents().Create(ctx, s.store.DB(), event); err != nil {
+		log.L(ctx).Errorf("record security event %s of user %s failed: %+v", event.Type, event.EID, err)
+	}
+}
+
+// List 按发生时间倒序分页返回符合条件的安全事件，以及符合条件的事件总数。
+func (s securityEventService) List(
+	ctx context.Context,
+	filter *SecurityEventFilter,
+	page, pageSize int,
+) ([]*model.SecurityEvents, int64, error) {
+	var conditions []string
+	var args []any
+
+	if filter.EID != "" {
+		conditions = append(conditions, "eid = ?")
+		args = append(args, filter.EID)
+	}
+	if filter.Type != "" {
+		conditions = append(conditions, "type = ?")
+		args = append(args, filter.Type)
+	}
+	if filter.IP != "" {
+		conditions = append(conditions, "ip = ?")
+		args = append(args, filter.IP)
+	}
+
+	opts := []options.Opt{options.WithPaginate(page, pageSize)}
+	if len(conditions) > 0 {
+		opts = append(opts, options.WithWhere(strings.Join(conditions, " AND "), args...))
+	}
+
+	events, total, err := s.store.SecurityEvents().List(ctx, s.store.DB(), opts...)
+	if err != nil {
+		return nil, 0, errors.Code(code.ErrDatabase, err.Error())
+	}
+	return events, total, nil
+}
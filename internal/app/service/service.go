@@ -9,6 +9,20 @@ import (
 type Service interface {
 	Users() UserSrv
 	SuperUser() SuperUsersSrv
+	Tokens() TokenSrv
+	Verifications() VerificationSrv
+	LoginLimits() LoginLimitSrv
+	MFA() MFASrv
+	OAuth() OAuthSrv
+	APIKeys() APIKeySrv
+	Sessions() SessionSrv
+	Passwords() PasswordSrv
+	Identities() IdentitySrv
+	Impersonations() ImpersonationSrv
+	QRLogins() QRLoginSrv
+	DeviceAuthorizations() DeviceAuthorizationSrv
+	ServiceAccounts() ServiceAccountSrv
+	SecurityEvents() SecurityEventSrv
 }
 
 type service struct {
@@ -31,3 +45,59 @@ func (s *service) Users() UserSrv {
 func (s *service) SuperUser() SuperUsersSrv {
 	return newSuperUsers(s)
 }
+
+func (s *service) Tokens() TokenSrv {
+	return newTokens(s)
+}
+
+func (s *service) Verifications() VerificationSrv {
+	return newVerifications(s)
+}
+
+func (s *service) LoginLimits() LoginLimitSrv {
+	return newLoginLimits(s)
+}
+
+func (s *service) MFA() MFASrv {
+	return newMFA(s)
+}
+
+func (s *service) OAuth() OAuthSrv {
+	return newOAuth(s)
+}
+
+func (s *service) APIKeys() APIKeySrv {
+	return newAPIKeys(s)
+}
+
+func (s *service) Sessions() SessionSrv {
+	return newSessions(s)
+}
+
+func (s *service) Passwords() PasswordSrv {
+	return newPasswords(s)
+}
+
+func (s *service) Identities() IdentitySrv {
+	return newIdentities(s)
+}
+
+func (s *service) Impersonations() ImpersonationSrv {
+	return newImpersonations(s)
+}
+
+func (s *service) QRLogins() QRLoginSrv {
+	return newQRLogins(s)
+}
+
+func (s *service) DeviceAuthorizations() DeviceAuthorizationSrv {
+	return newDeviceAuthorizations(s)
+}
+
+func (s *service) ServiceAccounts() ServiceAccountSrv {
+	return newServiceAccounts(s)
+}
+
+func (s *service) SecurityEvents() SecurityEventSrv {
+	return newSecurityEvents(s)
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"crypto"
+	"crypto/subtle"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"gorm.io/gorm"
+
+	"github.com/eachinchung/component-base/utils/idutil"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/app/storage"
+	"github.com/eachinchung/e-service/internal/app/store"
+	"github.com/eachinchung/e-service/internal/app/store/model"
+	"github.com/eachinchung/e-service/internal/pkg/code"
+)
+
+// maxAssertionLifetime 是服务账号签名的 client assertion 的最长有效期。
+const maxAssertionLifetime = 5 * time.Minute
+
+// assertionMethods 是 client assertion 允许使用的签名算法，只允许非对称算法。
+var assertionMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// ServiceAccountSrv defines functions used to manage and authenticate service accounts.
+type ServiceAccountSrv interface {
+	Create(ctx context.Context, account *model.ServiceAccounts, withSecret bool) (string, error)
+	Get(ctx context.Context, clientID string) (*model.ServiceAccounts, error)
+	List(ctx context.Context) ([]*model.ServiceAccounts, error)
+	Delete(ctx context.Context, account *model.ServiceAccounts) error
+	Authenticate(ctx context.Context, clientID, secret string) (*model.ServiceAccounts, error)
+	AuthenticateAssertion(ctx context.Context, assertion, audience string) (*model.ServiceAccounts, error)
+}
+
+type serviceAccountService struct {
+	store   store.Store
+	storage storage.Storage
+}
+
+var _ ServiceAccountSrv = &serviceAccountService{}
+
+func newServiceAccounts(srv *service) *serviceAccountService {
+	return &serviceAccountService{store: srv.store, storage: srv.storage}
+}
+
+// Create 创建一个服务账号，withSecret 为 true 时生成一个密钥，密钥只在创建时返回一次。
+func (s serviceAccountService) Create(ctx context.Context, account *model.ServiceAccounts, withSecret bool) (string, error) {
+	if account.PublicKey != "" {
+		if _, err := parsePublicKey(account.PublicKey); err != nil {
+			return "", err
+		}
+	}
+
+	var secret string
+	if withSecret {
+		secret = idutil.GenSecretKey()
+		account.SecretHash = hashToken(secret)
+	}
+	account.ClientID = idutil.GenSecretID()
+
+	if err := s.store.ServiceAccounts().Create(ctx, s.store.DB(), account); err != nil {
+		return "", errors.Code(code.ErrDatabase, err.Error())
+	}
+
+	log.L(ctx).Infof("user %s created service account %s", account.OwnerEID, account.ClientID)
+	return secret, nil
+}
+
+func (s serviceAccountService) Get(ctx context.Context, clientID string) (*model.ServiceAccounts, error) {
+	account, err := s.store.ServiceAccounts().Get(ctx, s.store.DB(), clientID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.Code(code.ErrServiceAccountNotExist, err.Error())
+		}
+		return nil, errors.Code(code.ErrDatabase, err.Error())
+	}
+	return account, nil
+}
+
+func (s serviceAccountService) List(ctx context.Context) ([]*model.ServiceAccounts, error) {
+	accounts, err := s.store.ServiceAccounts().List(ctx, s.store.DB())
+	if err != nil {
+		return nil, errors.Code(code.ErrDatabase, err.Error())
+	}
+	return accounts, nil
+}
+
+func (s serviceAccountService) Delete(ctx context.Context, account *model.ServiceAccounts) error {
+	if err := s.store.ServiceAccounts().Delete(ctx, s.store.DB(), account); err != nil {
+		return errors.Code(code.ErrDatabase, err.Error())
+	}
+	return nil
+}
+
+// Authenticate 使用密钥校验服务账号的身份。
+func (s serviceAccountService) Authenticate(ctx context.Context, clientID, secret string) (*model.ServiceAccounts, error) {
+	account, err := s.client(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	if account.SecretHash == "" {
+		return nil, errors.Code(code.ErrOAuthInvalidClient, "service account does not use a secret")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(account.SecretHash), []byte(hashToken(secret))) != 1 {
+		return nil, errors.Code(code.ErrOAuthInvalidClient, "service account secret incorrect")
+	}
+	return account, nil
+}
+
+// AuthenticateAssertion 使用服务账号私钥签名的 client assertion 校验服务账号的身份（RFC 7523）。
+// assertion 的 iss 与 sub 必须是服务账号 ID，aud 必须包含 audience，并且 jti 只能使用一次。
+func (s serviceAccountService) AuthenticateAssertion(ctx context.Context, assertion, audience string) (*model.ServiceAccounts, error) {
+	unverified := &jwt.RegisteredClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(assertion, unverified); err != nil {
+		return nil, errors.Code(code.ErrOAuthInvalidClient, err.Error())
+	}
+
+	account, err := s.client(ctx, unverified.Issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	if account.PublicKey == "" {
+		return nil, errors.Code(code.ErrOAuthInvalidClient, "service account does not use a public key")
+	}
+
+	key, err := parsePublicKey(account.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &jwt.RegisteredClaims{}
+	parser := jwt.NewParser(jwt.WithValidMethods(assertionMethods))
+	if _, err := parser.ParseWithClaims(assertion, claims, func(*jwt.Token) (any, error) { return key, nil }); err != nil {
+		return nil, errors.Code(code.ErrOAuthInvalidClient, err.Error())
+	}
+
+	switch {
+	case claims.Issuer != account.ClientID || claims.Subject != account.ClientID:
+		return nil, errors.Code(code.ErrOAuthInvalidClient, "assertion iss and sub must be the service account id")
+	case !claims.VerifyAudience(audience, true):
+		return nil, errors.Code(code.ErrOAuthInvalidClient, "assertion audience mismatch")
+	case claims.ExpiresAt == nil || time.Until(claims.ExpiresAt.Time) > maxAssertionLifetime:
+		return nil, errors.Code(code.ErrOAuthInvalidClient, "assertion must expire within 5 minutes")
+	case claims.ID == "":
+		return nil, errors.Code(code.ErrOAuthInvalidClient, "assertion jti is required")
+	}
+
+	jtiKey := fmt.Sprintf(storage.KeyServiceAccountAssertion, hashToken(account.ClientID+":"+claims.ID))
+	ok, err := s.storage.SetNX(ctx, jtiKey, 1, time.Until(claims.ExpiresAt.Time))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to save assertion jti")
+	}
+	if !ok {
+		return nil, errors.Code(code.ErrOAuthInvalidClient, "assertion has been used")
+	}
+
+	return account, nil
+}
+
+// client 返回 clientID 对应的服务账号，服务账号不存在时返回应用认证失败。
+func (s serviceAccountService) client(ctx context.Context, clientID string) (*model.ServiceAccounts, error) {
+	account, err := s.Get(ctx, clientID)
+	if err != nil {
+		if errors.IsCode(err, code.ErrServiceAccountNotExist) {
+			return nil, errors.Code(code.ErrOAuthInvalidClient, "service account not found")
+		}
+		return nil, err
+	}
+	return account, nil
+}
+
+// parsePublicKey 解析 PEM 格式的 RSA、ECDSA 或 Ed25519 公钥。
+func parsePublicKey(data string) (crypto.PublicKey, error) {
+	if key, err := jwt.ParseRSAPublicKeyFromPEM([]byte(data)); err == nil {
+		return key, nil
+	}
+	if key, err := jwt.ParseECPublicKeyFromPEM([]byte(data)); err == nil {
+		return key, nil
+	}
+	if key, err := jwt.ParseEdPublicKeyFromPEM([]byte(data)); err == nil {
+		return key, nil
+	}
+	return nil, errors.Code(code.ErrServiceAccountKeyInvalid, "public key must be a PEM encoded RSA, ECDSA or Ed25519 key")
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/app/storage"
+	"github.com/eachinchung/e-service/internal/app/store"
+	"github.com/eachinchung/e-service/internal/app/store/model"
+	"github.com/eachinchung/e-service/internal/pkg/code"
+)
+
+// sessionTouchInterval 会话最近活跃时间的更新间隔，同一个会话在间隔内只更新一次数据库。
+const sessionTouchInterval = time.Minute
+
+// SessionSrv defines functions used to handle login sessions.
+type SessionSrv interface {
+	Create(ctx context.Context, session *model.Sessions) error
+	List(ctx context.Context, eid string) ([]*model.Sessions, error)
+	End(ctx context.Context, eid, sid string) error
+	EndAll(ctx context.Context, eid string) error
+	Touch(ctx context.Context, sid string)
+}
+
+type sessionService struct {
+	store   store.Store
+	storage storage.Storage
+}
+
+var _ SessionSrv = &sessionService{}
+
+func newSessions(srv *service) *sessionService {
+	return &sessionService{store: srv.store, storage: srv.storage}
+}
+
+// Create 记录一次登录创建的会话，会话 ID 与该次登录签发的 token 的令牌族 ID 相同。
+func (s sessionService) Create(ctx context.Context, session *model.Sessions) error {
+	now := time.Now()
+	session.CreatedAt = now
+	session.LastSeenAt = now
+
+	if err := s.store.Sessions().Create(ctx, s.store.DB(), session); err != nil {
+		return errors.Code(code.ErrDatabase, err.Error())
+	}
+	return nil
+}
+
+// List 返回用户仍然有效的会话，超过 token 最长有效期没有活跃的会话视为已失效。
+func (s sessionService) List(ctx context.Context, eid string) ([]*model.Sessions, error) {
+	sessions, err := s.store.Sessions().ListActive(ctx, s.store.DB(), eid, time.Now().Add(-maxTokenLifetime()))
+	if err != nil {
+		return nil, errors.Code(code.ErrDatabase, err.Error())
+	}
+	return sessions, nil
+}
+
+// End 结束用户的一个会话，会话中签发的 access token 与 refresh token 立即失效。
+func (s sessionService) End(ctx context.Context, eid, sid string) error {
+	session, err := s.store.Sessions().Get(ctx, s.store.DB(), sid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.Code(code.ErrSessionNotExist, err.Error())
+		}
+		return errors.Code(code.ErrDatabase, err.Error())
+	}
+
+	if session.EID != eid || session.EndedAt.Valid {
+		return errors.Code(code.ErrSessionNotExist, "session not found")
+	}
+
+	if err := (tokenService{store: s.store, storage: s.storage}).RevokeFamily(ctx, sid); err != nil {
+		return errors.Code(code.ErrUnknown, err.Error())
+	}
+
+	if err := s.store.Sessions().End(ctx, s.store.DB(), sid); err != nil {
+		return errors.Code(code.ErrDatabase, err.Error())
+	}
+	return nil
+}
+
+// EndAll 结束用户所有的会话，用户此前签发的所有 token 立即失效。
+func (s sessionService) EndAll(ctx context.Context, eid string) error {
+	if err := (tokenService{store: s.store, storage: s.storage}).RevokeAll(ctx, eid); err != nil {
+		return errors.Code(code.ErrUnknown, err.Error())
+	}
+
+	if err := s.store.Sessions().EndAll(ctx, s.store.DB(), eid); err != nil {
+		return errors.Code(code.ErrDatabase, err.Error())
+	}
+	return nil
+}
+
+// Touch 更新会话的最近活跃时间，为了减少数据库写入，同一个会话在 sessionTouchInterval 内只更新一次。
+func (s sessionService) Touch(ctx context.Context, sid string) {
+	fresh, err := s.storage.SetNX(ctx, fmt.Sprintf(storage.KeySessionSeen, sid), true, sessionTouchInterval)
+	if err != nil || !fresh {
+		return
+	}
+
+	if err := s.store.Sessions().Touch(ctx, s.store.DB(), sid); err != nil {
+		log.L(ctx).Errorf("touch session failed: %+v", err)
+	}
+}
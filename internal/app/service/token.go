@@ -0,0 +1,303 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/eachinchung/component-base/middleware/auth"
+	"github.com/eachinchung/component-base/utils/idutil"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/app/config"
+	"github.com/eachinchung/e-service/internal/app/storage"
+	"github.com/eachinchung/e-service/internal/app/store"
+	"github.com/eachinchung/e-service/internal/app/store/model"
+	"github.com/eachinchung/e-service/internal/pkg/code"
+)
+
+// TokenSrv defines functions used to handle token revocation and refresh tokens.
+type TokenSrv interface {
+	Revoke(ctx context.Context, claims auth.MapClaims) error
+	RevokeFamily(ctx context.Context, fid string) error
+	RevokeAll(ctx context.Context, eid string) error
+	IsRevoked(ctx context.Context, claims auth.MapClaims) (bool, error)
+	Introspect(ctx context.Context, token string, claims auth.MapClaims) (*Introspection, error)
+
+	CreateRefreshToken(ctx context.Context, record *RefreshToken) (string, time.Time, error)
+	RotateRefreshToken(ctx context.Context, token, clientID string) (*RefreshToken, string, time.Time, error)
+}
+
+// RefreshToken 是保存在 storage 中的 refresh token 记录，token 本身只保存哈希值。
+type RefreshToken struct {
+	EID      string `redis:"eid"`       // 用户名
+	FamilyID string `redis:"fid"`       // 令牌族 ID，同一次登录轮换出的 token 属于同一个令牌族
+	ClientID string `redis:"client_id"` // 第三方应用 ID，用户直接登录时为空
+	Scope    string `redis:"scope"`     // 第三方应用获得的权限
+	IssuedAt int64  `redis:"iat"`       // 签发时间
+	IssuedMs int64  `redis:"iat_ms"`    // 以毫秒为单位的签发时间
+	Version  int64  `redis:"cv"`        // 签发时用户的凭证版本
+	AuthTime int64  `redis:"auth_time"` // 用户完成身份验证的时间，轮换时保持不变
+	AMR      string `redis:"amr"`       // 用户完成身份验证使用的认证方式，以空格分隔
+	Used     int64  `redis:"used"`      // 使用次数
+}
+
+type tokenService struct {
+	store   store.Store
+	storage storage.Storage
+}
+
+var _ TokenSrv = &tokenService{}
+
+func newTokens(srv *service) *tokenService {
+	return &tokenService{store: srv.store, storage: srv.storage}
+}
+
+// Revoke 将 token 的 jti 加入黑名单，黑名单的过期时间与 token 的过期时间一致。
+func (t tokenService) Revoke(ctx context.Context, claims auth.MapClaims) error {
+	jti, ok := claims["jti"].(string)
+	if !ok || jti == "" {
+		return errors.New("token missing jti field")
+	}
+
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return errors.New("token missing exp field")
+	}
+
+	ttl := time.Until(time.Unix(int64(exp), 0))
+	if ttl <= 0 {
+		return nil
+	}
+
+	return t.storage.Set(ctx, fmt.Sprintf(storage.KeyRevokedToken, jti), true, ttl)
+}
+
+// RevokeFamily 吊销令牌族中所有的 refresh token 与 access token。
+func (t tokenService) RevokeFamily(ctx context.Context, fid string) error {
+	return t.storage.Set(ctx, fmt.Sprintf(storage.KeyRevokedTokenFamily, fid), true, maxTokenLifetime())
+}
+
+// RevokeAll 吊销用户在此刻之前签发的所有 token，吊销时间精确到毫秒。
+func (t tokenService) RevokeAll(ctx context.Context, eid string) error {
+	return t.storage.Set(ctx, fmt.Sprintf(storage.KeyUserTokensRevokedAt, eid), time.Now().UnixMilli(), maxTokenLifetime())
+}
+
+// IsRevoked 检查 token 是否已被单独吊销、所属令牌族已被吊销、签发于用户修改密码之前，或签发于用户最近一次全部吊销之前。
+func (t tokenService) IsRevoked(ctx context.Context, claims auth.MapClaims) (bool, error) {
+	if jti, ok := claims["jti"].(string); ok {
+		revoked, err := t.storage.GetBool(ctx, fmt.Sprintf(storage.KeyRevokedToken, jti))
+		switch {
+		case err == nil && revoked:
+			return true, nil
+		case err != nil && !errors.Is(err, storage.ErrKeyNotFound):
+			return false, err
+		}
+	}
+
+	if fid, ok := claims["fid"].(string); ok {
+		if revoked, err := t.isFamilyRevoked(ctx, fid); err != nil || revoked {
+			return revoked, err
+		}
+	}
+
+	sub, ok := claims["sub"].(string)
+	if !ok {
+		return false, nil
+	}
+
+	// 服务账号没有凭证版本，删除服务账号后由 casbin.RBACMiddleWare 拒绝它的 token
+	if _, ok := model.ServiceAccountClientID(sub); ok {
+		return false, nil
+	}
+
+	if outdated, err := t.isCredentialOutdated(ctx, sub, credentialVersion(claims)); err != nil || outdated {
+		return outdated, err
+	}
+
+	return t.isRevokedForUser(ctx, sub, issuedAtMilli(claims))
+}
+
+// CreateRefreshToken 为 record 所属的令牌族签发一个新的 refresh token。
+func (t tokenService) CreateRefreshToken(ctx context.Context, record *RefreshToken) (string, time.Time, error) {
+	ttl := config.GetConfigIns(nil).JWTOptions.MaxRefresh
+	now := time.Now()
+
+	user, err := (userService{store: t.store, storage: t.storage}).GetByEID(ctx, record.EID)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	token := idutil.GenSecretKey()
+	record = &RefreshToken{
+		EID:      record.EID,
+		FamilyID: record.FamilyID,
+		ClientID: record.ClientID,
+		Scope:    record.Scope,
+		IssuedAt: now.Unix(),
+		IssuedMs: now.UnixMilli(),
+		Version:  user.CredentialVersion,
+		AuthTime: record.AuthTime,
+		AMR:      record.AMR,
+	}
+
+	if err := t.storage.HSetAllWithExpire(ctx, fmt.Sprintf(storage.KeyRefreshToken, hashToken(token)), record, ttl); err != nil {
+		return "", time.Time{}, errors.Wrap(err, "failed to save refresh token")
+	}
+
+	return token, now.Add(ttl), nil
+}
+
+// RotateRefreshToken 使用 refresh token 换取一个新的 refresh token，旧的 refresh token 随即失效。
+// 若一个已经使用过的 refresh token 被再次使用，说明它可能已经泄露，整个令牌族都会被吊销。
+// refresh token 只能由签发时的第三方应用使用，用户直接登录获得的 refresh token 对应的 clientID 为空。
+func (t tokenService) RotateRefreshToken(ctx context.Context, token, clientID string) (*RefreshToken, string, time.Time, error) {
+	key := fmt.Sprintf(storage.KeyRefreshToken, hashToken(token))
+
+	record := &RefreshToken{}
+	if err := t.storage.HGetAll(ctx, key, record); err != nil {
+		if errors.Is(err, storage.ErrKeyNotFound) {
+			return nil, "", time.Time{}, errors.Code(code.ErrRefreshTokenInvalid, "refresh token not found")
+		}
+		return nil, "", time.Time{}, errors.Wrap(err, "failed to get refresh token")
+	}
+
+	if record.ClientID != clientID {
+		return nil, "", time.Time{}, errors.Code(code.ErrRefreshTokenInvalid, "refresh token was issued to another client")
+	}
+
+	revoked, err := t.isFamilyRevoked(ctx, record.FamilyID)
+	if err == nil && !revoked {
+		revoked, err = t.isCredentialOutdated(ctx, record.EID, record.Version)
+	}
+	if err == nil && !revoked {
+		revoked, err = t.isRevokedForUser(ctx, record.EID, record.issuedAtMilli())
+	}
+	if err != nil {
+		return nil, "", time.Time{}, err
+	}
+	if revoked {
+		return nil, "", time.Time{}, errors.Code(code.ErrRefreshTokenInvalid, "refresh token has been revoked")
+	}
+
+	used, err := t.storage.HIncrByIfExists(ctx, key, "used", 1)
+	if err != nil {
+		if errors.Is(err, storage.ErrKeyNotFound) {
+			return nil, "", time.Time{}, errors.Code(code.ErrRefreshTokenInvalid, "refresh token not found")
+		}
+		return nil, "", time.Time{}, errors.Wrap(err, "failed to mark refresh token used")
+	}
+
+	if used > 1 {
+		log.L(ctx).Warnf("refresh token reused, revoke token family: %s, eid: %s", record.FamilyID, record.EID)
+		if err := t.RevokeFamily(ctx, record.FamilyID); err != nil {
+			return nil, "", time.Time{}, err
+		}
+		return nil, "", time.Time{}, errors.Code(code.ErrRefreshTokenReused, "refresh token reused")
+	}
+
+	newToken, expire, err := t.CreateRefreshToken(ctx, record)
+	if err != nil {
+		return nil, "", time.Time{}, err
+	}
+
+	return record, newToken, expire, nil
+}
+
+func (t tokenService) isFamilyRevoked(ctx context.Context, fid string) (bool, error) {
+	revoked, err := t.storage.GetBool(ctx, fmt.Sprintf(storage.KeyRevokedTokenFamily, fid))
+	switch {
+	case errors.Is(err, storage.ErrKeyNotFound):
+		return false, nil
+	case err != nil:
+		return false, err
+	}
+	return revoked, nil
+}
+
+// isRevokedForUser 检查以毫秒为单位的签发时间 iatMs 是否不晚于用户最近一次全部吊销的时间。
+func (t tokenService) isRevokedForUser(ctx context.Context, eid string, iatMs int64) (bool, error) {
+	val, err := t.storage.Get(ctx, fmt.Sprintf(storage.KeyUserTokensRevokedAt, eid))
+	switch {
+	case errors.Is(err, storage.ErrKeyNotFound):
+		return false, nil
+	case err != nil:
+		return false, err
+	}
+
+	revokedAt, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to parse revoked time")
+	}
+
+	// 旧版本以秒为单位保存吊销时间，视为在这一秒的最后一毫秒吊销
+	if revokedAt < 1e12 {
+		revokedAt = revokedAt*1000 + 999
+	}
+
+	return iatMs <= revokedAt, nil
+}
+
+// isCredentialOutdated 检查 token 签发时的凭证版本是否已经过时，用户不存在时同样视为过时。
+func (t tokenService) isCredentialOutdated(ctx context.Context, eid string, version int64) (bool, error) {
+	user, err := (userService{store: t.store, storage: t.storage}).GetByEID(ctx, eid)
+	if err != nil {
+		if errors.IsCode(err, code.ErrUserNotExist) {
+			return true, nil
+		}
+		return false, err
+	}
+
+	return user.CredentialVersion != version, nil
+}
+
+// maxTokenLifetime 返回任意 token 从签发到失效的最长时间，吊销记录至少需要保留这么久。
+func maxTokenLifetime() time.Duration {
+	jwtOpts := config.GetConfigIns(nil).JWTOptions
+	return jwtOpts.Timeout + jwtOpts.MaxRefresh
+}
+
+// issuedAt 返回 token 的签发时间，旧版本的 token 没有 iat 字段，使用 orig_iat 代替。
+func issuedAt(claims auth.MapClaims) int64 {
+	if iat, ok := claims["iat"].(float64); ok {
+		return int64(iat)
+	}
+	if iat, ok := claims["orig_iat"].(float64); ok {
+		return int64(iat)
+	}
+	return 0
+}
+
+// issuedAtMilli 返回以毫秒为单位的 token 签发时间，旧版本的 token 没有 iat_ms 字段，使用 iat 所在秒的第一毫秒代替。
+func issuedAtMilli(claims auth.MapClaims) int64 {
+	if ms, ok := claims["iat_ms"].(float64); ok {
+		return int64(ms)
+	}
+	return issuedAt(claims) * 1000
+}
+
+// issuedAtMilli 返回以毫秒为单位的 refresh token 签发时间，旧版本的记录没有 iat_ms 字段，使用 iat 代替。
+func (r *RefreshToken) issuedAtMilli() int64 {
+	if r.IssuedMs > 0 {
+		return r.IssuedMs
+	}
+	return r.IssuedAt * 1000
+}
+
+// credentialVersion 返回 token 签发时用户的凭证版本，旧版本的 token 没有 cv 字段，视为初始版本。
+func credentialVersion(claims auth.MapClaims) int64 {
+	if cv, ok := claims["cv"].(float64); ok {
+		return int64(cv)
+	}
+	return 0
+}
+
+// hashToken 返回 token 的 sha256 哈希，storage 中不保存 token 明文。
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
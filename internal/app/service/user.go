@@ -2,8 +2,10 @@ package service
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"regexp"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -23,6 +25,10 @@ type UserSrv interface {
 	Create(ctx context.Context, user *model.Users) error
 	GetByEID(ctx context.Context, eid string) (*model.Users, error)
 	GetByEIDUnscoped(ctx context.Context, eid string) (*model.Users, error)
+	UpdateState(ctx context.Context, eid string, state model.Status) error
+	UpdateEmail(ctx context.Context, eid, email string) error
+	SendEmailCode(ctx context.Context, eid, ip string) error
+	VerifyEmail(ctx context.Context, eid, verifyCode string) error
 }
 
 type userService struct {
@@ -47,6 +53,18 @@ func (u userService) Create(ctx context.Context, user *model.Users) error {
 		return errors.Code(code.ErrUsernameAlreadyExist, "eid already exists")
 	}
 
+	if user.Email.Valid {
+		user.Email.String = strings.ToLower(user.Email.String)
+		if _, err := u.store.User().Get(ctx, db, user.Email.String, options.WithQuery("email = ?")); err == nil {
+			return errors.Code(code.ErrEmailAlreadyExist, "email already exists")
+		}
+	}
+
+	// 所有创建账号的路径都会设置初始密码，密码有效期从创建账号时开始计算
+	if user.PasswordChangedAt.IsZero() {
+		user.PasswordChangedAt = time.Now()
+	}
+
 	if err := u.store.User().Create(ctx, db, user); err != nil {
 		if match, _ := regexp.MatchString("duplicate key value violates unique constraint .*", err.Error()); match {
 			return errors.Code(code.ErrUserAlreadyExist, err.Error())
@@ -99,3 +117,122 @@ func (u userService) GetByEIDUnscoped(ctx context.Context, eid string) (*model.U
 	_ = u.storage.HSetAllWithExpire(ctx, fmt.Sprintf(storage.KeyUserUnscoped, eid), user, time.Hour)
 	return user, nil
 }
+
+// UpdateState 修改用户状态，并清除用户缓存、结束用户所有的会话，使用户已签发的 token 立即失效。
+func (u userService) UpdateState(ctx context.Context, eid string, state model.Status) error {
+	db := u.store.DB()
+
+	user, err := u.store.User().Get(ctx, db, eid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.Code(code.ErrUserNotExist, err.Error())
+		}
+		return errors.Code(code.ErrDatabase, err.Error())
+	}
+
+	if user.State == state {
+		return nil
+	}
+
+	user.State = state
+	if err := u.store.User().Update(ctx, db, user); err != nil {
+		return errors.Code(code.ErrDatabase, err.Error())
+	}
+
+	if err := u.storage.Del(ctx, fmt.Sprintf(storage.KeyUser, eid), fmt.Sprintf(storage.KeyUserUnscoped, eid)); err != nil {
+		return errors.Code(code.ErrUnknown, err.Error())
+	}
+
+	return sessionService{store: u.store, storage: u.storage}.EndAll(ctx, eid)
+}
+
+// UpdateEmail 修改用户的邮箱，新邮箱需要重新验证后才能用于登录。
+func (u userService) UpdateEmail(ctx context.Context, eid, email string) error {
+	db := u.store.DB()
+	email = strings.ToLower(email)
+
+	user, err := u.store.User().Get(ctx, db, eid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.Code(code.ErrUserNotExist, err.Error())
+		}
+		return errors.Code(code.ErrDatabase, err.Error())
+	}
+
+	if user.Email.Valid && user.Email.String == email {
+		return nil
+	}
+
+	if _, err := u.store.User().Get(ctx, db, email, options.WithQuery("email = ?")); err == nil {
+		return errors.Code(code.ErrEmailAlreadyExist, "email already exists")
+	}
+
+	user.Email = sql.NullString{String: email, Valid: true}
+	user.EmailVerified = false
+	if err := u.store.User().Update(ctx, db, user); err != nil {
+		if match, _ := regexp.MatchString("duplicate key value violates unique constraint .*", err.Error()); match {
+			return errors.Code(code.ErrEmailAlreadyExist, err.Error())
+		}
+		return errors.Code(code.ErrDatabase, err.Error())
+	}
+
+	return u.clearCache(ctx, eid)
+}
+
+// SendEmailCode 向用户尚未验证的邮箱发送验证码。
+func (u userService) SendEmailCode(ctx context.Context, eid, ip string) error {
+	user, err := u.unverifiedEmailUser(ctx, eid)
+	if err != nil {
+		return err
+	}
+
+	return verificationService{store: u.store, storage: u.storage}.SendEmailCode(ctx, SceneEmailVerify, user.Email.String, ip)
+}
+
+// VerifyEmail 校验邮件验证码，校验成功后邮箱标记为已验证，可以用于登录。
+func (u userService) VerifyEmail(ctx context.Context, eid, verifyCode string) error {
+	user, err := u.unverifiedEmailUser(ctx, eid)
+	if err != nil {
+		return err
+	}
+
+	verifications := verificationService{store: u.store, storage: u.storage}
+	if err := verifications.VerifyEmailCode(ctx, SceneEmailVerify, user.Email.String, verifyCode); err != nil {
+		return err
+	}
+
+	user.EmailVerified = true
+	if err := u.store.User().Update(ctx, u.store.DB(), user); err != nil {
+		return errors.Code(code.ErrDatabase, err.Error())
+	}
+
+	log.L(ctx).Infof("user %s verified email", eid)
+	return u.clearCache(ctx, eid)
+}
+
+// unverifiedEmailUser 获取已设置邮箱但邮箱尚未验证的用户。
+func (u userService) unverifiedEmailUser(ctx context.Context, eid string) (*model.Users, error) {
+	user, err := u.store.User().Get(ctx, u.store.DB(), eid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.Code(code.ErrUserNotExist, err.Error())
+		}
+		return nil, errors.Code(code.ErrDatabase, err.Error())
+	}
+
+	if !user.Email.Valid {
+		return nil, errors.Code(code.ErrEmailNotSet, "email not set")
+	}
+	if user.EmailVerified {
+		return nil, errors.Code(code.ErrEmailAlreadyVerified, "email already verified")
+	}
+
+	return user, nil
+}
+
+func (u userService) clearCache(ctx context.Context, eid string) error {
+	if err := u.storage.Del(ctx, fmt.Sprintf(storage.KeyUser, eid), fmt.Sprintf(storage.KeyUserUnscoped, eid)); err != nil {
+		return errors.Code(code.ErrUnknown, err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/app/config"
+	"github.com/eachinchung/e-service/internal/app/storage"
+	"github.com/eachinchung/e-service/internal/app/store"
+	"github.com/eachinchung/e-service/internal/pkg/code"
+	"github.com/eachinchung/e-service/internal/pkg/mail"
+	"github.com/eachinchung/e-service/internal/pkg/sms"
+)
+
+// 验证码的使用场景，不同场景的验证码互不通用。
+const (
+	SceneLogin         = "login"
+	ScenePasswordReset = "password_reset"
+	SceneEmailVerify   = "email_verify"
+)
+
+// VerificationSrv defines functions used to send and verify verification codes.
+type VerificationSrv interface {
+	SendSMSCode(ctx context.Context, scene, phone, ip string) error
+	VerifySMSCode(ctx context.Context, scene, phone, verifyCode string) error
+	SendEmailCode(ctx context.Context, scene, email, ip string) error
+	VerifyEmailCode(ctx context.Context, scene, email, verifyCode string) error
+}
+
+type verificationCode struct {
+	Code     string `redis:"code"`     // 验证码
+	Attempts int64  `redis:"attempts"` // 已校验次数
+}
+
+type verificationService struct {
+	store   store.Store
+	storage storage.Storage
+}
+
+var _ VerificationSrv = &verificationService{}
+
+func newVerifications(srv *service) *verificationService {
+	return &verificationService{store: srv.store, storage: srv.storage}
+}
+
+// SendSMSCode 向手机号发送验证码，同一手机号有发送间隔与每日上限，同一 IP 有每小时上限。
+func (v verificationService) SendSMSCode(ctx context.Context, scene, phone, ip string) error {
+	if err := v.checkSendLimit(ctx, phone, ip); err != nil {
+		return err
+	}
+
+	return v.sendSMSCode(ctx, scene, phone)
+}
+
+// VerifySMSCode 校验验证码，验证码校验成功后立即失效，错误次数过多时同样失效。
+func (v verificationService) VerifySMSCode(ctx context.Context, scene, phone, verifyCode string) error {
+	opts := config.GetConfigIns(nil).SMSOptions
+	return v.verifyCode(ctx, fmt.Sprintf(storage.KeySMSCode, scene, phone), verifyCode, opts.MaxVerifyAttempts)
+}
+
+// SendEmailCode 向邮箱发送验证码，同一邮箱有发送间隔，同一 IP 有每小时上限。
+func (v verificationService) SendEmailCode(ctx context.Context, scene, email, ip string) error {
+	opts := config.GetConfigIns(nil).MailOptions
+
+	count, err := v.storage.IncrWithExpire(ctx, fmt.Sprintf(storage.KeyEmailIPCount, ip), time.Hour)
+	if err != nil {
+		return errors.Wrap(err, "failed to count email by ip")
+	}
+	if count > opts.IPHourlyLimit {
+		log.L(ctx).Warnf("ip %s send email too frequently", ip)
+		return errors.Code(code.ErrSendTooFrequently, "ip send email too frequently")
+	}
+
+	ok, err := v.storage.SetNX(ctx, fmt.Sprintf(storage.KeyEmailSendLock, email), true, opts.SendInterval)
+	if err != nil {
+		return errors.Wrap(err, "failed to lock email sending")
+	}
+	if !ok {
+		return errors.Code(code.ErrSendTooFrequently, "email send too frequently")
+	}
+
+	key := fmt.Sprintf(storage.KeyEmailCode, scene, email)
+	record := &verificationCode{Code: randomCode()}
+	if err := v.storage.HSetAllWithExpire(ctx, key, record, opts.CodeExpire); err != nil {
+		return errors.Wrap(err, "failed to save email code")
+	}
+
+	body := fmt.Sprintf("您的验证码是 %s，%d 分钟内有效。如非本人操作，请忽略本邮件。", record.Code, int(opts.CodeExpire.Minutes()))
+	if err := mail.Client().Send(ctx, email, "邮箱验证码", body); err != nil {
+		log.L(ctx).Errorf("send email code failed: %+v", err)
+		_ = v.storage.Del(ctx, key)
+		return errors.Code(code.ErrSendCodeFailed, err.Error())
+	}
+
+	return nil
+}
+
+// VerifyEmailCode 校验邮件验证码，规则与短信验证码相同。
+func (v verificationService) VerifyEmailCode(ctx context.Context, scene, email, verifyCode string) error {
+	opts := config.GetConfigIns(nil).MailOptions
+	return v.verifyCode(ctx, fmt.Sprintf(storage.KeyEmailCode, scene, email), verifyCode, opts.MaxVerifyAttempts)
+}
+
+// verifyCode 校验 key 中保存的验证码，校验成功或错误次数超过 maxAttempts 后删除验证码。
+func (v verificationService) verifyCode(ctx context.Context, key, verifyCode string, maxAttempts int64) error {
+	record := &verificationCode{}
+	if err := v.storage.HGetAll(ctx, key, record); err != nil {
+		if errors.Is(err, storage.ErrKeyNotFound) {
+			return errors.Code(code.ErrVerificationCodeExpired, "verification code not found")
+		}
+		return errors.Wrap(err, "failed to get verification code")
+	}
+
+	attempts, err := v.storage.HIncrByIfExists(ctx, key, "attempts", 1)
+	if err != nil {
+		if errors.Is(err, storage.ErrKeyNotFound) {
+			return errors.Code(code.ErrVerificationCodeExpired, "verification code not found")
+		}
+		return errors.Wrap(err, "failed to count verification code attempts")
+	}
+	if attempts > maxAttempts {
+		_ = v.storage.Del(ctx, key)
+		return errors.Code(code.ErrVerificationCodeAttemptsExceeded, "too many verification code attempts")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(record.Code), []byte(verifyCode)) != 1 {
+		return errors.Code(code.ErrVerificationCodeIncorrect, "verification code incorrect")
+	}
+
+	// 同一个验证码被并发提交时只有第一次校验成功
+	used, err := v.storage.HIncrByIfExists(ctx, key, "used", 1)
+	switch {
+	case errors.Is(err, storage.ErrKeyNotFound), err == nil && used > 1:
+		return errors.Code(code.ErrVerificationCodeExpired, "verification code has been used")
+	case err != nil:
+		return errors.Wrap(err, "failed to mark verification code used")
+	}
+
+	_ = v.storage.Del(ctx, key)
+	return nil
+}
+
+// checkSendLimit 检查并记录一次验证码发送，超过发送频率限制时返回 code.ErrSendTooFrequently。
+func (v verificationService) checkSendLimit(ctx context.Context, phone, ip string) error {
+	opts := config.GetConfigIns(nil).SMSOptions
+
+	count, err := v.storage.IncrWithExpire(ctx, fmt.Sprintf(storage.KeySMSIPCount, ip), time.Hour)
+	if err != nil {
+		return errors.Wrap(err, "failed to count sms by ip")
+	}
+	if count > opts.IPHourlyLimit {
+		log.L(ctx).Warnf("ip %s send sms too frequently", ip)
+		return errors.Code(code.ErrSendTooFrequently, "ip send sms too frequently")
+	}
+
+	ok, err := v.storage.SetNX(ctx, fmt.Sprintf(storage.KeySMSSendLock, phone), true, opts.SendInterval)
+	if err != nil {
+		return errors.Wrap(err, "failed to lock sms sending")
+	}
+	if !ok {
+		return errors.Code(code.ErrSendTooFrequently, "phone send sms too frequently")
+	}
+
+	count, err = v.storage.IncrWithExpire(ctx, fmt.Sprintf(storage.KeySMSPhoneCount, phone), 24*time.Hour)
+	if err != nil {
+		return errors.Wrap(err, "failed to count sms by phone")
+	}
+	if count > opts.PhoneDailyLimit {
+		return errors.Code(code.ErrSendTooFrequently, "phone reached the daily sms limit")
+	}
+
+	return nil
+}
+
+// sendSMSCode 生成并发送 scene 场景的验证码，不检查发送频率。
+func (v verificationService) sendSMSCode(ctx context.Context, scene, phone string) error {
+	opts := config.GetConfigIns(nil).SMSOptions
+
+	key := fmt.Sprintf(storage.KeySMSCode, scene, phone)
+	record := &verificationCode{Code: randomCode()}
+	if err := v.storage.HSetAllWithExpire(ctx, key, record, opts.CodeExpire); err != nil {
+		return errors.Wrap(err, "failed to save sms code")
+	}
+
+	if err := sms.Client().SendCode(ctx, phone, record.Code, opts.CodeExpire); err != nil {
+		log.L(ctx).Errorf("send sms code failed: %+v", err)
+		_ = v.storage.Del(ctx, key)
+		return errors.Code(code.ErrSendCodeFailed, err.Error())
+	}
+
+	return nil
+}
+
+// randomCode 生成 6 位数字验证码。
+func randomCode() string {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		panic(err)
+	}
+	return fmt.Sprintf("%06d", n)
+}
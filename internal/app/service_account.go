@@ -0,0 +1,65 @@
+package app
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/eachinchung/component-base/core"
+	"github.com/eachinchung/component-base/middleware/auth"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/app/service"
+	"github.com/eachinchung/e-service/internal/app/store/model"
+	"github.com/eachinchung/e-service/internal/pkg/code"
+)
+
+// clientAssertionTypeJWTBearer 是 RFC 7523 定义的使用 jwt 作为 client assertion 的认证方式。
+const clientAssertionTypeJWTBearer = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+// clientCredentialsGrant 使用服务账号的密钥或私钥签名的 client assertion 签发 access token。
+// 服务账号不能交互式登录，也不签发 refresh token，token 过期后需要重新申请。
+func clientCredentialsGrant(c *gin.Context, mw *jwtAuth, srv service.Service, req *tokenRequest) {
+	var account *model.ServiceAccounts
+	var err error
+
+	switch req.ClientAssertionType {
+	case "":
+		clientID, clientSecret := clientCredentials(c, req.ClientID, req.ClientSecret)
+		account, err = srv.ServiceAccounts().Authenticate(c, clientID, clientSecret)
+	case clientAssertionTypeJWTBearer:
+		account, err = srv.ServiceAccounts().AuthenticateAssertion(c, req.ClientAssertion, oidcIssuer()+"/oauth/token")
+	default:
+		err = errors.Code(code.ErrOAuthInvalidClient, "unsupported client assertion type")
+	}
+	if err != nil {
+		oauthError(c, err)
+		return
+	}
+
+	claims := mw.PayloadFunc(nil)
+	claims["sub"] = account.Subject()
+
+	token, expire, err := mw.SignToken(claims)
+	if err != nil {
+		oauthError(c, err)
+		return
+	}
+
+	log.L(c).Infof("service account %s issued a token", account.ClientID)
+	oauthTokenResponse(c, &tokenPair{Token: token, Expire: expire}, "", "")
+}
+
+// noServiceAccount 拒绝服务账号的 token 访问只对用户本人开放的接口。
+func noServiceAccount() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sub, _ := auth.ExtractClaimsFromContext(c)["sub"].(string)
+		if _, ok := model.ServiceAccountClientID(sub); ok {
+			core.WriteResponse(
+				c,
+				nil,
+				core.WithError(errors.Code(code.ErrServiceAccountForbidden, "token issued to a service account")),
+				core.WithAbort(),
+			)
+		}
+	}
+}
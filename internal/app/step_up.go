@@ -0,0 +1,180 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/eachinchung/component-base/core"
+	"github.com/eachinchung/component-base/middleware/auth"
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+
+	"github.com/eachinchung/e-service/internal/app/service"
+	"github.com/eachinchung/e-service/internal/app/storage"
+	"github.com/eachinchung/e-service/internal/app/store"
+	"github.com/eachinchung/e-service/internal/pkg/code"
+	"github.com/eachinchung/e-service/internal/pkg/validator"
+)
+
+// 用户完成身份验证使用的认证方式，写入 token 的 amr 字段，取值参考 RFC 8176。
+const (
+	amrPassword     = "pwd"
+	amrSMS          = "sms"
+	amrOTP          = "otp"
+	amrMFA          = "mfa"
+	amrFederated    = "fed"
+	amrMultiChannel = "mca" // 扫码登录与设备授权，由另一台已登录的设备确认
+)
+
+// stepUpMaxAge 是敏感操作要求用户最近一次验证身份距今的最长时间。
+const stepUpMaxAge = 5 * time.Minute
+
+// stepUpInfo 是用户重新验证身份时提交的信息，启用了两步验证的用户必须提交动态验证码或恢复码。
+type stepUpInfo struct {
+	Password string `json:"password" binding:"omitempty,max=255"`
+	Code     string `json:"code"     binding:"omitempty,min=6,max=16"`
+}
+
+// stepUp 要求 token 在 maxAge 内完成过身份验证，并且使用过 methods 中的全部认证方式。
+// 不满足时返回 ErrStepUpRequired，客户端需要调用 POST /auth/step-up 重新验证身份后重试。
+// 服务账号、API Key、模拟登录与第三方应用的 token 没有 auth_time 字段，不能通过检查。
+func stepUp(maxAge time.Duration, methods ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := auth.ExtractClaimsFromContext(c)
+
+		authTime, _ := claims["auth_time"].(float64)
+		if authTime > 0 && time.Since(time.Unix(int64(authTime), 0)) <= maxAge && hasAMR(claims, methods) {
+			return
+		}
+
+		c.Header(
+			"WWW-Authenticate",
+			fmt.Sprintf(`Bearer error="insufficient_user_authentication", max_age=%d`, int64(maxAge.Seconds())),
+		)
+		core.WriteResponse(
+			c,
+			gin.H{"max_age": int64(maxAge.Seconds()), "amr": methods},
+			core.WithError(errors.Code(code.ErrStepUpRequired, "step-up authentication required")),
+			core.WithAbort(),
+		)
+	}
+}
+
+// hasAMR 检查 token 的 amr 字段是否包含 methods 中的全部认证方式。
+func hasAMR(claims auth.MapClaims, methods []string) bool {
+	amr, _ := claims["amr"].([]any)
+
+	for _, method := range methods {
+		found := false
+		for _, v := range amr {
+			if v == method {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// stepUpHandler 使用密码或两步验证重新验证当前用户的身份，签发一个属于同一令牌族、验证时间为当前时间的 access token。
+// 启用了两步验证的用户必须提交动态验证码或恢复码，否则必须提交密码，失败次数与登录共享同一个限制。
+func stepUpHandler(mw *jwtAuth) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body stepUpInfo
+		if err := c.ShouldBindJSON(&body); err != nil {
+			core.WriteResponse(
+				c,
+				validator.ParseValidationError(err),
+				core.WithError(errors.Code(code.ErrValidation, err.Error())),
+			)
+			return
+		}
+
+		claims := auth.ExtractClaimsFromContext(c)
+		eid, _ := claims["sub"].(string)
+		fid, _ := claims["fid"].(string)
+
+		srv := service.NewService(store.Client(), storage.Client())
+		user, err := srv.Users().GetByEID(c, eid)
+		if err != nil {
+			log.L(c).Errorf("get user information failed: %+v", err)
+			core.WriteResponse(c, nil, core.WithError(err))
+			return
+		}
+
+		enabled, err := srv.MFA().Enabled(c, eid)
+		if err != nil {
+			log.L(c).Errorf("get mfa status failed: %+v", err)
+			core.WriteResponse(c, nil, core.WithError(err))
+			return
+		}
+
+		switch {
+		case enabled && body.Code == "":
+			core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrValidation, "code is required")))
+			return
+		case !enabled && body.Password == "":
+			core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrValidation, "password is required")))
+			return
+		}
+
+		if err := checkLoginLimit(c, srv, eid); err != nil {
+			core.WriteResponse(c, nil, core.WithError(err))
+			return
+		}
+
+		var amr []string
+		if body.Password != "" {
+			if err := user.ComparePasswordHash(body.Password); err != nil {
+				loginFailed(c, srv, eid, eid, amrPassword)
+				core.WriteResponse(c, nil, core.WithError(errors.Code(code.ErrPasswordIncorrect, err.Error())))
+				return
+			}
+			amr = append(amr, amrPassword)
+		}
+
+		if body.Code != "" {
+			if err := srv.MFA().Verify(c, eid, body.Code); err != nil {
+				if !errors.IsCode(err, code.ErrMFACodeIncorrect) && !errors.IsCode(err, code.ErrMFANotEnabled) {
+					log.L(c).Errorf("verify mfa code failed: %+v", err)
+				}
+
+				loginFailed(c, srv, eid, eid, amrOTP)
+				core.WriteResponse(c, nil, core.WithError(err))
+				return
+			}
+			amr = append(amr, amrOTP)
+		}
+
+		if len(amr) > 1 {
+			amr = append(amr, amrMFA)
+		}
+
+		loginSucceeded(c, srv, eid)
+
+		token, expire, err := signToken(mw, user, &service.RefreshToken{
+			EID:      eid,
+			FamilyID: fid,
+			AuthTime: time.Now().Unix(),
+			AMR:      strings.Join(amr, " "),
+		})
+		if err != nil {
+			mw.Unauthorized(c, http.StatusUnauthorized, auth.ErrFailedTokenCreation)
+			return
+		}
+
+		log.L(c).Infof("user %s re-authenticated with %s", eid, strings.Join(amr, ", "))
+		c.JSON(http.StatusOK, gin.H{
+			"token":  token,
+			"expire": expire.Format(time.RFC3339),
+		})
+	}
+}
@@ -4,4 +4,49 @@ const (
 	KeyUser         = "user:%s"
 	KeyUserUnscoped = "user:%s:unscoped"
 	KeyIsSuperUser  = "user:%s:super"
+
+	KeyRevokedToken        = "token:%s:revoked"
+	KeyUserTokensRevokedAt = "user:%s:tokens:revoked_at"
+	KeyRefreshToken        = "refresh_token:%s"
+	KeyRevokedTokenFamily  = "token_family:%s:revoked"
+
+	KeyLoginFailures   = "login:%s:failures"
+	KeyLoginLocked     = "login:%s:locked"
+	KeyLoginIPFailures = "login:ip:%s:failures"
+	KeyLoginIPLocked   = "login:ip:%s:locked"
+	KeyLoginFailedIPs  = "login:%s:failed_ips"
+
+	KeySMSCode       = "sms:%s:%s:code"
+	KeySMSSendLock   = "sms:%s:lock"
+	KeySMSPhoneCount = "sms:%s:count"
+	KeySMSIPCount    = "sms:ip:%s:count"
+
+	KeyEmailCode     = "email:%s:%s:code"
+	KeyEmailSendLock = "email:%s:lock"
+	KeyEmailIPCount  = "email:ip:%s:count"
+
+	KeyMFAChallenge    = "mfa:challenge:%s"
+	KeyMFATOTPUsedStep = "mfa:%s:totp:%d"
+
+	KeyOAuthCode          = "oauth:code:%s"
+	KeyOAuthIntrospection = "oauth:introspection:%s"
+	KeyOAuthDeviceCode    = "oauth:device_code:%s"
+	KeyOAuthDevicePoll    = "oauth:device_code:%s:poll"
+	KeyOAuthUserCode      = "oauth:user_code:%s"
+	KeyOAuthUserCodeTries = "oauth:user_code:%s:tries"
+
+	KeyAPIKeyUsed = "api_key:%s:used"
+
+	KeySessionSeen = "session:%s:seen"
+
+	KeyPasswordResetTicket    = "password_reset:ticket:%s"
+	KeyPasswordResetIPCount   = "password_reset:ip:%s:count"
+	KeyPasswordChangeRequired = "password:%s:change_required"
+
+	KeyIDPState  = "idp:state:%s"
+	KeyIDPTicket = "idp:ticket:%s"
+
+	KeyQRLogin = "qr_login:%s"
+
+	KeyServiceAccountAssertion = "service_account:assertion:%s"
 )
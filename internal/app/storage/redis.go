@@ -20,6 +20,35 @@ var (
 	rs   Storage
 )
 
+// incrWithExpireScript 对 key 自增，key 第一次被创建时设置以毫秒为单位的过期时间。
+var incrWithExpireScript = redis.NewScript(`
+local val = redis.call("INCR", KEYS[1])
+if val == 1 and tonumber(ARGV[1]) > 0 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return val
+`)
+
+// hIncrByIfExistsScript 仅在 key 存在时对 hash 字段进行自增，避免 key 过期后 HINCRBY 重新创建一个没有过期时间的 key。
+var hIncrByIfExistsScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return nil
+end
+return redis.call("HINCRBY", KEYS[1], ARGV[1], ARGV[2])
+`)
+
+// hCompareAndSetScript 仅在 hash 字段的值与期望值相同时写入新的字段，key 不存在时返回 nil，写入成功返回 1，否则返回 0。
+var hCompareAndSetScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return nil
+end
+if redis.call("HGET", KEYS[1], ARGV[1]) ~= ARGV[2] then
+	return 0
+end
+redis.call("HSET", KEYS[1], unpack(ARGV, 3))
+return 1
+`)
+
 func GetRedisClientOr(opts *options.RedisOptions) (Storage, error) {
 	var err error
 
@@ -76,6 +105,16 @@ func (r *redisStorage) Set(ctx context.Context, key string, value any, expiratio
 	return err
 }
 
+func (r *redisStorage) SetNX(ctx context.Context, key string, value any, expiration time.Duration) (bool, error) {
+	log.L(ctx).Debugf("[STORE] SETNX key is: %s", key)
+	ok, err := r.client.SetNX(ctx, key, value, expiration).Result()
+	if err != nil {
+		log.L(ctx).Errorf("[STORE] SETNX key is: %s, err: %+v", key, err)
+		return ok, err
+	}
+	return ok, nil
+}
+
 func (r *redisStorage) GetBool(ctx context.Context, key string) (bool, error) {
 	log.L(ctx).Debugf("[STORE] GET bool key is: %s", key)
 	val, err := r.client.Get(ctx, key).Bool()
@@ -89,6 +128,28 @@ func (r *redisStorage) GetBool(ctx context.Context, key string) (bool, error) {
 	return val, err
 }
 
+func (r *redisStorage) Del(ctx context.Context, keys ...string) error {
+	log.L(ctx).Debugf("[STORE] DEL keys is: %v", keys)
+	err := r.client.Del(ctx, keys...).Err()
+	if err != nil {
+		log.L(ctx).Errorf("[STORE] DEL keys is: %v, err: %+v", keys, err)
+		return err
+	}
+	return nil
+}
+
+// IncrWithExpire 将 key 的值加一，key 第一次被创建时设置过期时间。
+// INCR 与 EXPIRE 在同一个脚本中执行，不会留下没有过期时间的计数器。
+func (r *redisStorage) IncrWithExpire(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	log.L(ctx).Debugf("[STORE] INCR key is: %s, Expire: %v", key, expiration)
+	val, err := incrWithExpireScript.Run(ctx, r.client, []string{key}, expiration.Milliseconds()).Int64()
+	if err != nil {
+		log.L(ctx).Errorf("[STORE] INCR key is: %s, err: %+v", key, err)
+		return val, err
+	}
+	return val, nil
+}
+
 func (r *redisStorage) HSet(ctx context.Context, key string, values ...any) error {
 	log.L(ctx).Debugf("[STORE] HSET key is: %s", key)
 	err := r.client.HSet(ctx, key, values...).Err()
@@ -181,6 +242,16 @@ func (r *redisStorage) HMGet(ctx context.Context, key string, fields ...string)
 	return result, nil
 }
 
+func (r *redisStorage) HKeys(ctx context.Context, key string) ([]string, error) {
+	log.L(ctx).Debugf("[STORE] HKEYS key is: %s", key)
+	result, err := r.client.HKeys(ctx, key).Result()
+	if err != nil {
+		log.L(ctx).Errorf("[STORE] HKEYS key is: %s, err: %+v", key, err)
+		return nil, err
+	}
+	return result, nil
+}
+
 func (r *redisStorage) HGetAll(ctx context.Context, key string, model any) error {
 	log.L(ctx).Debugf("[STORE] HGETALL key is: %s", key)
 
@@ -283,6 +354,37 @@ func (r *redisStorage) HGetAll(ctx context.Context, key string, model any) error
 	return nil
 }
 
+// HIncrByIfExists 对 hash 字段进行自增，key 不存在时返回 ErrKeyNotFound，不会创建新的 key。
+// 读取一次性记录后使用它标记记录已被使用，即使记录在读取之后过期也不会留下没有过期时间的 key。
+func (r *redisStorage) HIncrByIfExists(ctx context.Context, key, field string, incr int64) (int64, error) {
+	log.L(ctx).Debugf("[STORE] HINCRBY IF EXISTS key is: %s, field: %s", key, field)
+	val, err := hIncrByIfExistsScript.Run(ctx, r.client, []string{key}, field, incr).Int64()
+	switch {
+	case err == redis.Nil:
+		return val, ErrKeyNotFound
+	case err != nil:
+		log.L(ctx).Errorf("[STORE] HINCRBY IF EXISTS key is: %s, field: %s, err: %+v", key, field, err)
+		return val, err
+	}
+	return val, nil
+}
+
+// HCompareAndSet 仅在 hash 字段 field 的值等于 expected 时写入 values，返回是否写入成功。
+// key 不存在时返回 ErrKeyNotFound。
+func (r *redisStorage) HCompareAndSet(ctx context.Context, key, field string, expected any, values ...any) (bool, error) {
+	log.L(ctx).Debugf("[STORE] HCAS key is: %s, field: %s", key, field)
+	args := append([]any{field, expected}, values...)
+	val, err := hCompareAndSetScript.Run(ctx, r.client, []string{key}, args...).Int64()
+	switch {
+	case err == redis.Nil:
+		return false, ErrKeyNotFound
+	case err != nil:
+		log.L(ctx).Errorf("[STORE] HCAS key is: %s, field: %s, err: %+v", key, field, err)
+		return false, err
+	}
+	return val == 1, nil
+}
+
 func (r *redisStorage) Expire(ctx context.Context, key string, expiration time.Duration) error {
 	log.L(ctx).Debugf("[STORE] EXPIRE key is: %s", key)
 	err := r.client.Expire(ctx, key, expiration).Err()
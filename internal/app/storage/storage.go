@@ -14,12 +14,18 @@ type Storage interface {
 	RDB() *redis.Client
 	Get(ctx context.Context, key string) (string, error)
 	Set(ctx context.Context, key string, value any, expiration time.Duration) error
+	SetNX(ctx context.Context, key string, value any, expiration time.Duration) (bool, error)
 	GetBool(ctx context.Context, key string) (bool, error)
+	Del(ctx context.Context, keys ...string) error
+	IncrWithExpire(ctx context.Context, key string, expiration time.Duration) (int64, error)
 
 	HSet(ctx context.Context, key string, values ...any) error
 	HSetAllWithExpire(ctx context.Context, key string, model any, expiration time.Duration) error
 	HMGet(ctx context.Context, key string, fields ...string) ([]any, error)
+	HKeys(ctx context.Context, key string) ([]string, error)
 	HGetAll(ctx context.Context, key string, model any) error
+	HIncrByIfExists(ctx context.Context, key, field string, incr int64) (int64, error)
+	HCompareAndSet(ctx context.Context, key, field string, expected any, values ...any) (bool, error)
 
 	Expire(ctx context.Context, key string, expiration time.Duration) error
 }
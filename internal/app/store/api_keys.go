@@ -0,0 +1,18 @@
+package store
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/eachinchung/e-service/internal/app/store/model"
+)
+
+type APIKeyStore interface {
+	Create(ctx context.Context, db *gorm.DB, key *model.APIKeys) error
+	Get(ctx context.Context, db *gorm.DB, keyID string) (*model.APIKeys, error)
+	List(ctx context.Context, db *gorm.DB, eid string) ([]*model.APIKeys, error)
+	CountActive(ctx context.Context, db *gorm.DB, eid string) (int64, error)
+	Update(ctx context.Context, db *gorm.DB, key *model.APIKeys) error
+	Touch(ctx context.Context, db *gorm.DB, keyID string) error
+}
@@ -0,0 +1,16 @@
+package store
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/eachinchung/e-service/internal/app/store/model"
+)
+
+type IdentityStore interface {
+	Create(ctx context.Context, db *gorm.DB, identity *model.Identities) error
+	Get(ctx context.Context, db *gorm.DB, provider, subject string) (*model.Identities, error)
+	List(ctx context.Context, db *gorm.DB, eid string) ([]*model.Identities, error)
+	Delete(ctx context.Context, db *gorm.DB, eid, provider string) error
+}
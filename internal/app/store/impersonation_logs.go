@@ -0,0 +1,13 @@
+package store
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/eachinchung/e-service/internal/app/store/model"
+)
+
+type ImpersonationLogStore interface {
+	Create(ctx context.Context, db *gorm.DB, log *model.ImpersonationLogs) error
+}
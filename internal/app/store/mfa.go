@@ -0,0 +1,21 @@
+package store
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/eachinchung/e-service/internal/app/store/model"
+)
+
+type TOTPStore interface {
+	Get(ctx context.Context, db *gorm.DB, eid string) (*model.TOTPSecrets, error)
+	Save(ctx context.Context, db *gorm.DB, secret *model.TOTPSecrets) error
+	Delete(ctx context.Context, db *gorm.DB, eid string) error
+}
+
+type RecoveryCodeStore interface {
+	Replace(ctx context.Context, db *gorm.DB, eid string, codeHashes []string) error
+	Use(ctx context.Context, db *gorm.DB, eid, codeHash string) (bool, error)
+	Delete(ctx context.Context, db *gorm.DB, eid string) error
+}
@@ -0,0 +1,105 @@
+package model
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/casbin/casbin/v2/util"
+	"github.com/gin-gonic/gin"
+)
+
+const apiKeyCtxKey = "API_KEY"
+
+// APIKeyScope 是 API key 允许访问的接口，格式与 casbin 的策略相同。
+type APIKeyScope struct {
+	Obj string `json:"obj"` // 路径，支持 keyMatch2 格式
+	Act string `json:"act"` // 请求方法，支持正则表达式
+}
+
+// APIKeys API key 表
+type APIKeys struct {
+	ID         uint         `gorm:"primaryKey;column:id" json:"-"`
+	KeyID      string       `gorm:"column:key_id" json:"key_id"`             // key 的公开部分
+	SecretHash string       `gorm:"column:secret_hash" json:"-"`             // key 的哈希
+	EID        string       `gorm:"column:eid" json:"eid"`                   // 所属用户
+	Name       string       `gorm:"column:name" json:"name"`                 // 名称
+	Scopes     string       `gorm:"column:scopes" json:"-"`                  // 允许访问的接口，每行一个 "obj act"
+	ExpiresAt  sql.NullTime `gorm:"column:expires_at" json:"expires_at"`     // 过期时间，为空表示永不过期
+	LastUsedAt sql.NullTime `gorm:"column:last_used_at" json:"last_used_at"` // 最近一次使用时间
+	RevokedAt  sql.NullTime `gorm:"column:revoked_at" json:"revoked_at"`     // 吊销时间
+	CreatedAt  time.Time    `gorm:"column:created_at" json:"created_at"`     // 创建时间
+}
+
+func (APIKeys) TableName() string {
+	return "api_keys"
+}
+
+// ScopeList 返回 API key 允许访问的接口。
+func (k *APIKeys) ScopeList() []APIKeyScope {
+	var scopes []APIKeyScope
+	for _, line := range strings.Split(k.Scopes, "\n") {
+		parts := strings.SplitN(line, " ", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		scopes = append(scopes, APIKeyScope{Obj: parts[0], Act: parts[1]})
+	}
+	return scopes
+}
+
+// SetScopes 设置 API key 允许访问的接口。
+func (k *APIKeys) SetScopes(scopes []APIKeyScope) {
+	lines := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		lines = append(lines, scope.Obj+" "+scope.Act)
+	}
+	k.Scopes = strings.Join(lines, "\n")
+}
+
+// Active 返回 API key 是否仍然有效。
+func (k *APIKeys) Active(now time.Time) bool {
+	if k.RevokedAt.Valid {
+		return false
+	}
+	return !k.ExpiresAt.Valid || k.ExpiresAt.Time.After(now)
+}
+
+// Allows 判断 API key 是否允许访问 obj，匹配规则与 casbin 的 model.conf 一致。
+func (k *APIKeys) Allows(obj, act string) bool {
+	for _, scope := range k.ScopeList() {
+		if util.KeyMatch2(obj, scope.Obj) && util.RegexMatch(act, scope.Act) {
+			return true
+		}
+	}
+	return false
+}
+
+func (k *APIKeys) Response() map[string]any {
+	r := map[string]any{
+		"key_id":     k.KeyID,
+		"name":       k.Name,
+		"scopes":     k.ScopeList(),
+		"created_at": k.CreatedAt,
+	}
+	if k.ExpiresAt.Valid {
+		r["expires_at"] = k.ExpiresAt.Time
+	}
+	if k.LastUsedAt.Valid {
+		r["last_used_at"] = k.LastUsedAt.Time
+	}
+	return r
+}
+
+func (k *APIKeys) SaveToContext(c *gin.Context) {
+	c.Set(apiKeyCtxKey, k)
+}
+
+func ExtractAPIKeysFromContext(c *gin.Context) *APIKeys {
+	k, exists := c.Get(apiKeyCtxKey)
+	if !exists {
+		return nil
+	}
+
+	return k.(*APIKeys)
+}
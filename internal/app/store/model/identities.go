@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// Identities 第三方账号表，记录第三方登录平台的用户与本站用户的绑定关系
+type Identities struct {
+	ID        uint      `gorm:"primaryKey;column:id" json:"-"`
+	EID       string    `gorm:"column:eid" json:"-"`                 // 用户名
+	Provider  string    `gorm:"column:provider" json:"provider"`     // 第三方登录平台
+	Subject   string    `gorm:"column:subject" json:"-"`             // 第三方平台的用户 ID
+	Nickname  string    `gorm:"column:nickname" json:"nickname"`     // 第三方平台的昵称
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"` // 绑定时间
+}
+
+func (Identities) TableName() string {
+	return "identities"
+}
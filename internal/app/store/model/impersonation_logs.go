@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// ImpersonationLogs 模拟登录审计日志表，记录模拟登录 token 的签发以及使用它发起的每一个请求
+type ImpersonationLogs struct {
+	ID          uint      `gorm:"primaryKey;column:id" json:"-"`
+	OperatorEID string    `gorm:"column:operator_eid" json:"operator_eid"` // 实际操作的超级用户
+	EID         string    `gorm:"column:eid" json:"eid"`                   // 被模拟的用户
+	JTI         string    `gorm:"column:jti" json:"jti"`                   // 模拟登录 token 的 ID
+	Method      string    `gorm:"column:method" json:"method"`             // 请求方法
+	Path        string    `gorm:"column:path" json:"path"`                 // 请求路径
+	Status      int       `gorm:"column:status" json:"status"`             // 响应状态码
+	IP          string    `gorm:"column:ip" json:"ip"`                     // 请求 IP
+	Reason      string    `gorm:"column:reason" json:"reason"`             // 模拟登录的原因，只在签发 token 时记录
+	CreatedAt   time.Time `gorm:"column:created_at" json:"created_at"`     // 创建时间
+}
+
+func (ImpersonationLogs) TableName() string {
+	return "impersonation_logs"
+}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"database/sql"
+	"time"
+)
+
+// TOTPSecrets 两步验证密钥表
+type TOTPSecrets struct {
+	ID        uint         `gorm:"primaryKey;column:id" json:"-"`
+	EID       string       `gorm:"column:eid" json:"eid"`               // 用户名
+	Secret    string       `gorm:"column:secret" json:"-"`              // base32 编码的密钥
+	EnabledAt sql.NullTime `gorm:"column:enabled_at" json:"enabled_at"` // 启用时间，为空表示尚未完成绑定
+	CreatedAt time.Time    `gorm:"column:created_at" json:"created_at"` // 创建时间
+}
+
+// Enabled 返回两步验证是否已经启用。
+func (t *TOTPSecrets) Enabled() bool {
+	return t.EnabledAt.Valid
+}
+
+// RecoveryCodes 两步验证恢复码表
+type RecoveryCodes struct {
+	ID        uint         `gorm:"primaryKey;column:id" json:"-"`
+	EID       string       `gorm:"column:eid" json:"eid"`               // 用户名
+	CodeHash  string       `gorm:"column:code_hash" json:"-"`           // 恢复码的 sha256 哈希
+	UsedAt    sql.NullTime `gorm:"column:used_at" json:"used_at"`       // 使用时间
+	CreatedAt time.Time    `gorm:"column:created_at" json:"created_at"` // 创建时间
+}
@@ -0,0 +1,68 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OAuthClients 第三方应用表
+type OAuthClients struct {
+	ID           uint           `gorm:"primaryKey;column:id" json:"-"`
+	ClientID     string         `gorm:"column:client_id" json:"client_id"`         // 应用 ID
+	SecretHash   string         `gorm:"column:secret_hash" json:"-"`               // 应用密钥的哈希，公开应用没有密钥
+	Name         string         `gorm:"column:name" json:"name"`                   // 应用名称
+	RedirectURIs string         `gorm:"column:redirect_uris" json:"redirect_uris"` // 回调地址，多个地址使用空格分隔
+	Scopes       string         `gorm:"column:scopes" json:"scopes"`               // 允许申请的权限，多个权限使用空格分隔
+	OwnerEID     string         `gorm:"column:owner_eid" json:"owner_eid"`         // 创建者
+	CreatedAt    time.Time      `gorm:"column:created_at" json:"created_at"`       // 创建时间
+	UpdatedAt    time.Time      `gorm:"column:updated_at" json:"updated_at"`       // 更新时间
+	DeletedAt    gorm.DeletedAt `gorm:"column:deleted_at" json:"-"`                // 删除时间
+}
+
+func (OAuthClients) TableName() string {
+	return "oauth_clients"
+}
+
+// Confidential 返回应用是否为持有密钥的机密应用。
+func (o *OAuthClients) Confidential() bool {
+	return o.SecretHash != ""
+}
+
+// HasRedirectURI 判断回调地址是否已登记，回调地址必须完全一致。
+func (o *OAuthClients) HasRedirectURI(uri string) bool {
+	for _, u := range strings.Fields(o.RedirectURIs) {
+		if u == uri {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *OAuthClients) Response() map[string]any {
+	return map[string]any{
+		"client_id":     o.ClientID,
+		"name":          o.Name,
+		"redirect_uris": strings.Fields(o.RedirectURIs),
+		"scopes":        strings.Fields(o.Scopes),
+		"confidential":  o.Confidential(),
+		"owner_eid":     o.OwnerEID,
+		"created_at":    o.CreatedAt,
+		"updated_at":    o.UpdatedAt,
+	}
+}
+
+// OAuthConsents 用户对第三方应用的授权记录表
+type OAuthConsents struct {
+	ID        uint      `gorm:"primaryKey;column:id" json:"-"`
+	EID       string    `gorm:"column:eid" json:"eid"`               // 用户名
+	ClientID  string    `gorm:"column:client_id" json:"client_id"`   // 应用 ID
+	Scopes    string    `gorm:"column:scopes" json:"scopes"`         // 已授予的权限，多个权限使用空格分隔
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"` // 创建时间
+	UpdatedAt time.Time `gorm:"column:updated_at" json:"updated_at"` // 更新时间
+}
+
+func (OAuthConsents) TableName() string {
+	return "oauth_consents"
+}
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// PasswordHistory 密码历史表，保存用户以前使用过的密码哈希
+type PasswordHistory struct {
+	ID           uint      `gorm:"primaryKey;column:id" json:"-"`
+	EID          string    `gorm:"column:eid" json:"eid"`               // 用户名
+	PasswordHash string    `gorm:"column:password_hash" json:"-"`       // 密码哈希
+	CreatedAt    time.Time `gorm:"column:created_at" json:"created_at"` // 创建时间
+}
+
+func (PasswordHistory) TableName() string {
+	return "password_history"
+}
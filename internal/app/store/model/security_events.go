@@ -0,0 +1,61 @@
+package model
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/eachinchung/component-base/middleware"
+)
+
+// 安全事件的类型。
+const (
+	SecurityEventLoginSucceeded           = "login_succeeded"
+	SecurityEventLoginFailed              = "login_failed"
+	SecurityEventTokenRefreshed           = "token_refreshed"
+	SecurityEventPasswordChanged          = "password_changed"
+	SecurityEventPasswordReset            = "password_reset"
+	SecurityEventAccountLocked            = "account_locked"
+	SecurityEventAccountUnlocked          = "account_unlocked"
+	SecurityEventMFAEnabled               = "mfa_enabled"
+	SecurityEventMFADisabled              = "mfa_disabled"
+	SecurityEventRecoveryCodesRegenerated = "recovery_codes_regenerated"
+)
+
+// SecurityEvents 安全事件表，记录用户的登录、刷新 token、修改密码、锁定与两步验证变更等事件
+type SecurityEvents struct {
+	ID        uint      `gorm:"primaryKey;column:id" json:"-"`
+	EID       string    `gorm:"column:eid" json:"eid"`               // 用户名
+	Type      string    `gorm:"column:type" json:"type"`             // 事件类型
+	Detail    string    `gorm:"column:detail" json:"detail"`         // 事件详情，例如登录使用的认证方式
+	IP        string    `gorm:"column:ip" json:"ip"`                 // 请求 IP
+	UserAgent string    `gorm:"column:user_agent" json:"user_agent"` // 请求的 User-Agent
+	RequestID string    `gorm:"column:request_id" json:"request_id"` // 请求 ID，用于关联日志
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"` // 发生时间
+}
+
+func (SecurityEvents) TableName() string {
+	return "security_events"
+}
+
+// NewSecurityEvent 使用请求的 IP、User-Agent 与请求 ID 创建一个安全事件。
+// 请求 ID 可能由客户端通过 X-Request-ID 请求头传入，超过字段长度时截断。
+func NewSecurityEvent(c *gin.Context, eid, eventType, detail string) *SecurityEvents {
+	return &SecurityEvents{
+		EID:       eid,
+		Type:      eventType,
+		Detail:    Truncate(detail, 255),
+		IP:        c.ClientIP(),
+		UserAgent: Truncate(c.Request.UserAgent(), 255),
+		RequestID: Truncate(middleware.GetRequestIDFromContext(c), 64),
+	}
+}
+
+// Truncate 截断超过数据库字段长度的字符串。
+func Truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
@@ -0,0 +1,24 @@
+package model
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Sessions 登录会话表，每次登录创建一个会话，会话 ID 即 token 中的令牌族 ID (fid)
+type Sessions struct {
+	ID         uint         `gorm:"primaryKey;column:id" json:"-"`
+	SID        string       `gorm:"column:sid" json:"sid"`                   // 会话 ID
+	EID        string       `gorm:"column:eid" json:"eid"`                   // 用户名
+	ClientID   string       `gorm:"column:client_id" json:"client_id"`       // 第三方应用 ID，用户直接登录时为空
+	Device     string       `gorm:"column:device" json:"device"`             // 设备名称
+	UserAgent  string       `gorm:"column:user_agent" json:"user_agent"`     // 登录时的 User-Agent
+	IP         string       `gorm:"column:ip" json:"ip"`                     // 登录时的 IP
+	CreatedAt  time.Time    `gorm:"column:created_at" json:"created_at"`     // 创建时间
+	LastSeenAt time.Time    `gorm:"column:last_seen_at" json:"last_seen_at"` // 最近一次活跃时间
+	EndedAt    sql.NullTime `gorm:"column:ended_at" json:"-"`                // 结束时间
+}
+
+func (Sessions) TableName() string {
+	return "sessions"
+}
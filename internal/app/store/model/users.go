@@ -8,29 +8,34 @@ import (
 
 	"gorm.io/gorm"
 
-	"github.com/eachinchung/component-base/auth"
 	"github.com/eachinchung/errors"
+
+	"github.com/eachinchung/e-service/internal/pkg/hasher"
 )
 
 const ctxKey = "USER"
 
 // Users 用户表
 type Users struct {
-	ID           uint           `gorm:"primaryKey;column:id" json:"-" redis:"id"`
-	Phone        string         `gorm:"column:phone" json:"phone" redis:"phone"`                // 手机号
-	EID          string         `gorm:"column:eid" json:"eid" redis:"eid"`                      // 用户名
-	PasswordHash string         `gorm:"column:password_hash" json:"-" redis:"password_hash"`    // 密码
-	Nickname     string         `gorm:"column:nickname" json:"nickname" redis:"nickname"`       // 昵称
-	Avatar       sql.NullString `gorm:"column:avatar" json:"avatar,omitempty" redis:"avatar"`   // 头像
-	State        Status         `gorm:"column:state" json:"state" redis:"state"`                // 状态
-	CreatedAt    time.Time      `gorm:"column:created_at" json:"created_at" redis:"created_at"` // 创建时间
-	UpdatedAt    time.Time      `gorm:"column:updated_at" json:"updated_at" redis:"updated_at"` // 更新时间
-	DeletedAt    gorm.DeletedAt `gorm:"column:deleted_at" json:"-" redis:"deleted_at"`          // 删除时间
+	ID                uint           `gorm:"primaryKey;column:id" json:"-" redis:"id"`
+	Phone             string         `gorm:"column:phone" json:"phone" redis:"phone"`                            // 手机号
+	Email             sql.NullString `gorm:"column:email" json:"email,omitempty" redis:"email"`                  // 邮箱，统一保存为小写
+	EmailVerified     bool           `gorm:"column:email_verified" json:"email_verified" redis:"email_verified"` // 邮箱是否已验证
+	EID               string         `gorm:"column:eid" json:"eid" redis:"eid"`                                  // 用户名
+	PasswordHash      string         `gorm:"column:password_hash" json:"-" redis:"password_hash"`                // 密码
+	Nickname          string         `gorm:"column:nickname" json:"nickname" redis:"nickname"`                   // 昵称
+	Avatar            sql.NullString `gorm:"column:avatar" json:"avatar,omitempty" redis:"avatar"`               // 头像
+	State             Status         `gorm:"column:state" json:"state" redis:"state"`                            // 状态
+	CredentialVersion int64          `gorm:"column:credential_version" json:"-" redis:"credential_version"`      // 凭证版本，修改密码后递增，旧版本签发的 token 全部失效
+	PasswordChangedAt time.Time      `gorm:"column:password_changed_at" json:"-" redis:"password_changed_at"`    // 最近一次设置密码的时间
+	CreatedAt         time.Time      `gorm:"column:created_at" json:"created_at" redis:"created_at"`             // 创建时间
+	UpdatedAt         time.Time      `gorm:"column:updated_at" json:"updated_at" redis:"updated_at"`             // 更新时间
+	DeletedAt         gorm.DeletedAt `gorm:"column:deleted_at" json:"-" redis:"deleted_at"`                      // 删除时间
 }
 
-// ComparePasswordHash with the plain text password. Returns true if it's the same as the encrypted one (in the `Users` struct).
+// ComparePasswordHash with the plain text password. Returns nil if it's the same as the encrypted one (in the `Users` struct).
 func (u *Users) ComparePasswordHash(pwd string) error {
-	if err := auth.ComparePasswordHash(u.PasswordHash, pwd); err != nil {
+	if err := hasher.Client().Compare(u.PasswordHash, pwd); err != nil {
 		return errors.Wrap(err, "failed to compile password")
 	}
 
@@ -39,13 +44,17 @@ func (u *Users) ComparePasswordHash(pwd string) error {
 
 func (u *Users) AdminResponse() map[string]any {
 	r := map[string]any{
-		"id":         u.ID,
-		"phone":      u.Phone,
-		"eid":        u.EID,
-		"nickname":   u.Nickname,
-		"state":      u.State,
-		"created_at": u.CreatedAt,
-		"updated_at": u.UpdatedAt,
+		"id":             u.ID,
+		"phone":          u.Phone,
+		"eid":            u.EID,
+		"email_verified": u.EmailVerified,
+		"nickname":       u.Nickname,
+		"state":          u.State,
+		"created_at":     u.CreatedAt,
+		"updated_at":     u.UpdatedAt,
+	}
+	if u.Email.Valid {
+		r["email"] = u.Email.String
 	}
 	if u.Avatar.Valid {
 		r["avatar"] = u.Avatar.String
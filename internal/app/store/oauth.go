@@ -0,0 +1,20 @@
+package store
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/eachinchung/e-service/internal/app/store/model"
+)
+
+type OAuthClientStore interface {
+	Create(ctx context.Context, db *gorm.DB, client *model.OAuthClients) error
+	Get(ctx context.Context, db *gorm.DB, clientID string) (*model.OAuthClients, error)
+	Delete(ctx context.Context, db *gorm.DB, client *model.OAuthClients) error
+}
+
+type OAuthConsentStore interface {
+	Get(ctx context.Context, db *gorm.DB, eid, clientID string) (*model.OAuthConsents, error)
+	Save(ctx context.Context, db *gorm.DB, consent *model.OAuthConsents) error
+}
@@ -0,0 +1,15 @@
+package store
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/eachinchung/e-service/internal/app/store/model"
+)
+
+type PasswordHistoryStore interface {
+	Create(ctx context.Context, db *gorm.DB, history *model.PasswordHistory) error
+	ListRecent(ctx context.Context, db *gorm.DB, eid string, limit int) ([]*model.PasswordHistory, error)
+	Prune(ctx context.Context, db *gorm.DB, eid string, keep int) error
+}
@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/eachinchung/errors"
+
+	"github.com/eachinchung/e-service/internal/app/store"
+	"github.com/eachinchung/e-service/internal/app/store/model"
+)
+
+type apiKey struct{}
+
+func newAPIKey() *apiKey {
+	return &apiKey{}
+}
+
+var _ store.APIKeyStore = &apiKey{}
+
+func (a apiKey) Create(ctx context.Context, db *gorm.DB, key *model.APIKeys) error {
+	if err := db.Create(key).Error; err != nil {
+		return errors.Wrap(err, "failed to create api key")
+	}
+	return nil
+}
+
+func (a apiKey) Get(ctx context.Context, db *gorm.DB, keyID string) (*model.APIKeys, error) {
+	var key model.APIKeys
+	if err := db.Where("key_id = ?", keyID).First(&key).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to get api key")
+	}
+	return &key, nil
+}
+
+func (a apiKey) List(ctx context.Context, db *gorm.DB, eid string) ([]*model.APIKeys, error) {
+	var keys []*model.APIKeys
+	if err := db.Where("eid = ? AND revoked_at IS NULL", eid).Order("id desc").Find(&keys).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to list api keys")
+	}
+	return keys, nil
+}
+
+func (a apiKey) CountActive(ctx context.Context, db *gorm.DB, eid string) (int64, error) {
+	var count int64
+	err := db.Model(&model.APIKeys{}).
+		Where("eid = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", eid, time.Now()).
+		Count(&count).Error
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to count api keys")
+	}
+	return count, nil
+}
+
+func (a apiKey) Update(ctx context.Context, db *gorm.DB, key *model.APIKeys) error {
+	if err := db.Save(key).Error; err != nil {
+		return errors.Wrap(err, "failed to update api key")
+	}
+	return nil
+}
+
+func (a apiKey) Touch(ctx context.Context, db *gorm.DB, keyID string) error {
+	err := db.Model(&model.APIKeys{}).Where("key_id = ?", keyID).Update("last_used_at", time.Now()).Error
+	if err != nil {
+		return errors.Wrap(err, "failed to update api key last used time")
+	}
+	return nil
+}
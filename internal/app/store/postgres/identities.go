@@ -0,0 +1,55 @@
+package postgres
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/eachinchung/errors"
+
+	"github.com/eachinchung/e-service/internal/app/store"
+	"github.com/eachinchung/e-service/internal/app/store/model"
+)
+
+type identity struct{}
+
+func newIdentity() *identity {
+	return &identity{}
+}
+
+var _ store.IdentityStore = &identity{}
+
+func (i identity) Create(ctx context.Context, db *gorm.DB, identity *model.Identities) error {
+	if err := db.Create(identity).Error; err != nil {
+		return errors.Wrap(err, "failed to create identity")
+	}
+	return nil
+}
+
+func (i identity) Get(ctx context.Context, db *gorm.DB, provider, subject string) (*model.Identities, error) {
+	var identity model.Identities
+	if err := db.Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to get identity")
+	}
+	return &identity, nil
+}
+
+func (i identity) List(ctx context.Context, db *gorm.DB, eid string) ([]*model.Identities, error) {
+	var identities []*model.Identities
+	if err := db.Where("eid = ?", eid).Order("id").Find(&identities).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to list identities")
+	}
+	return identities, nil
+}
+
+// Delete 删除用户绑定的第三方账号，没有绑定时返回 gorm.ErrRecordNotFound。
+func (i identity) Delete(ctx context.Context, db *gorm.DB, eid, provider string) error {
+	result := db.Where("eid = ? AND provider = ?", eid, provider).Delete(&model.Identities{})
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "failed to delete identity")
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
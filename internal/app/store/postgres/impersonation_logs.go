@@ -0,0 +1,27 @@
+package postgres
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/eachinchung/errors"
+
+	"github.com/eachinchung/e-service/internal/app/store"
+	"github.com/eachinchung/e-service/internal/app/store/model"
+)
+
+type impersonationLog struct{}
+
+func newImpersonationLog() *impersonationLog {
+	return &impersonationLog{}
+}
+
+var _ store.ImpersonationLogStore = &impersonationLog{}
+
+func (i impersonationLog) Create(ctx context.Context, db *gorm.DB, log *model.ImpersonationLogs) error {
+	if err := db.Create(log).Error; err != nil {
+		return errors.Wrap(err, "failed to create impersonation log")
+	}
+	return nil
+}
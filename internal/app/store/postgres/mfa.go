@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/eachinchung/errors"
+
+	"github.com/eachinchung/e-service/internal/app/store"
+	"github.com/eachinchung/e-service/internal/app/store/model"
+)
+
+type totp struct{}
+
+func newTOTP() *totp {
+	return &totp{}
+}
+
+var _ store.TOTPStore = &totp{}
+
+func (t totp) Get(ctx context.Context, db *gorm.DB, eid string) (*model.TOTPSecrets, error) {
+	var secret model.TOTPSecrets
+	if err := db.Where("eid = ?", eid).First(&secret).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to get totp secret")
+	}
+	return &secret, nil
+}
+
+func (t totp) Save(ctx context.Context, db *gorm.DB, secret *model.TOTPSecrets) error {
+	if err := db.Save(secret).Error; err != nil {
+		return errors.Wrap(err, "failed to save totp secret")
+	}
+	return nil
+}
+
+func (t totp) Delete(ctx context.Context, db *gorm.DB, eid string) error {
+	if err := db.Where("eid = ?", eid).Delete(&model.TOTPSecrets{}).Error; err != nil {
+		return errors.Wrap(err, "failed to delete totp secret")
+	}
+	return nil
+}
+
+type recoveryCode struct{}
+
+func newRecoveryCode() *recoveryCode {
+	return &recoveryCode{}
+}
+
+var _ store.RecoveryCodeStore = &recoveryCode{}
+
+func (r recoveryCode) Replace(ctx context.Context, db *gorm.DB, eid string, codeHashes []string) error {
+	codes := make([]model.RecoveryCodes, 0, len(codeHashes))
+	for _, hash := range codeHashes {
+		codes = append(codes, model.RecoveryCodes{EID: eid, CodeHash: hash})
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("eid = ?", eid).Delete(&model.RecoveryCodes{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&codes).Error
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to replace recovery codes")
+	}
+	return nil
+}
+
+func (r recoveryCode) Use(ctx context.Context, db *gorm.DB, eid, codeHash string) (bool, error) {
+	result := db.Model(&model.RecoveryCodes{}).
+		Where("eid = ? AND code_hash = ? AND used_at IS NULL", eid, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, errors.Wrap(result.Error, "failed to use recovery code")
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r recoveryCode) Delete(ctx context.Context, db *gorm.DB, eid string) error {
+	if err := db.Where("eid = ?", eid).Delete(&model.RecoveryCodes{}).Error; err != nil {
+		return errors.Wrap(err, "failed to delete recovery codes")
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package postgres
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/eachinchung/errors"
+
+	"github.com/eachinchung/e-service/internal/app/store"
+	"github.com/eachinchung/e-service/internal/app/store/model"
+)
+
+type oauthClient struct{}
+
+func newOAuthClient() *oauthClient {
+	return &oauthClient{}
+}
+
+var _ store.OAuthClientStore = &oauthClient{}
+
+func (o oauthClient) Create(ctx context.Context, db *gorm.DB, client *model.OAuthClients) error {
+	if err := db.Create(client).Error; err != nil {
+		return errors.Wrap(err, "failed to create oauth client")
+	}
+	return nil
+}
+
+func (o oauthClient) Get(ctx context.Context, db *gorm.DB, clientID string) (*model.OAuthClients, error) {
+	var client model.OAuthClients
+	if err := db.Where("client_id = ?", clientID).First(&client).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to get oauth client")
+	}
+	return &client, nil
+}
+
+func (o oauthClient) Delete(ctx context.Context, db *gorm.DB, client *model.OAuthClients) error {
+	if err := db.Delete(client).Error; err != nil {
+		return errors.Wrap(err, "failed to delete oauth client")
+	}
+	return nil
+}
+
+type oauthConsent struct{}
+
+func newOAuthConsent() *oauthConsent {
+	return &oauthConsent{}
+}
+
+var _ store.OAuthConsentStore = &oauthConsent{}
+
+func (o oauthConsent) Get(ctx context.Context, db *gorm.DB, eid, clientID string) (*model.OAuthConsents, error) {
+	var consent model.OAuthConsents
+	if err := db.Where("eid = ? AND client_id = ?", eid, clientID).First(&consent).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to get oauth consent")
+	}
+	return &consent, nil
+}
+
+func (o oauthConsent) Save(ctx context.Context, db *gorm.DB, consent *model.OAuthConsents) error {
+	if err := db.Save(consent).Error; err != nil {
+		return errors.Wrap(err, "failed to save oauth consent")
+	}
+	return nil
+}
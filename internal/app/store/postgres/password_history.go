@@ -0,0 +1,50 @@
+package postgres
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/eachinchung/errors"
+
+	"github.com/eachinchung/e-service/internal/app/store"
+	"github.com/eachinchung/e-service/internal/app/store/model"
+)
+
+type passwordHistory struct{}
+
+func newPasswordHistory() *passwordHistory {
+	return &passwordHistory{}
+}
+
+var _ store.PasswordHistoryStore = &passwordHistory{}
+
+func (p passwordHistory) Create(ctx context.Context, db *gorm.DB, history *model.PasswordHistory) error {
+	if err := db.Create(history).Error; err != nil {
+		return errors.Wrap(err, "failed to create password history")
+	}
+	return nil
+}
+
+func (p passwordHistory) ListRecent(ctx context.Context, db *gorm.DB, eid string, limit int) ([]*model.PasswordHistory, error) {
+	var histories []*model.PasswordHistory
+	err := db.Where("eid = ?", eid).Order("created_at desc, id desc").Limit(limit).Find(&histories).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list password history")
+	}
+	return histories, nil
+}
+
+func (p passwordHistory) Prune(ctx context.Context, db *gorm.DB, eid string, keep int) error {
+	recent := db.Model(&model.PasswordHistory{}).
+		Select("id").
+		Where("eid = ?", eid).
+		Order("created_at desc, id desc").
+		Limit(keep)
+
+	err := db.Where("eid = ? AND id NOT IN (?)", eid, recent).Delete(&model.PasswordHistory{}).Error
+	if err != nil {
+		return errors.Wrap(err, "failed to prune password history")
+	}
+	return nil
+}
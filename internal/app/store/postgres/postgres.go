@@ -37,6 +37,50 @@ func (ds *datastore) SuperUsers() store.SuperUsersStore {
 	return newSuperUser()
 }
 
+func (ds *datastore) TOTP() store.TOTPStore {
+	return newTOTP()
+}
+
+func (ds *datastore) RecoveryCodes() store.RecoveryCodeStore {
+	return newRecoveryCode()
+}
+
+func (ds *datastore) OAuthClients() store.OAuthClientStore {
+	return newOAuthClient()
+}
+
+func (ds *datastore) OAuthConsents() store.OAuthConsentStore {
+	return newOAuthConsent()
+}
+
+func (ds *datastore) APIKeys() store.APIKeyStore {
+	return newAPIKey()
+}
+
+func (ds *datastore) Sessions() store.SessionStore {
+	return newSession()
+}
+
+func (ds *datastore) PasswordHistory() store.PasswordHistoryStore {
+	return newPasswordHistory()
+}
+
+func (ds *datastore) Identities() store.IdentityStore {
+	return newIdentity()
+}
+
+func (ds *datastore) ImpersonationLogs() store.ImpersonationLogStore {
+	return newImpersonationLog()
+}
+
+func (ds *datastore) ServiceAccounts() store.ServiceAccountStore {
+	return newServiceAccount()
+}
+
+func (ds *datastore) SecurityEvents() store.SecurityEventStore {
+	return newSecurityEvent()
+}
+
 var (
 	factory store.Store
 	once    sync.Once
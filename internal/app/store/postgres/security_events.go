@@ -0,0 +1,55 @@
+package postgres
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/eachinchung/component-base/db/options"
+	"github.com/eachinchung/errors"
+
+	"github.com/eachinchung/e-service/internal/app/store"
+	"github.com/eachinchung/e-service/internal/app/store/model"
+)
+
+type securityEvent struct{}
+
+func newSecurityEvent() *securityEvent {
+	return &securityEvent{}
+}
+
+var _ store.SecurityEventStore = &securityEvent{}
+
+func (s securityEvent) Create(ctx context.Context, db *gorm.DB, event *model.SecurityEvents) error {
+	if err := db.Create(event).Error; err != nil {
+		return errors.Wrap(err, "failed to create security event")
+	}
+	return nil
+}
+
+// List 按发生时间倒序分页返回安全事件，同时返回符合条件的事件总数。
+func (s securityEvent) List(ctx context.Context, db *gorm.DB, opts ...options.Opt) ([]*model.SecurityEvents, int64, error) {
+	o := &options.Option{}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	db = db.Model(&model.SecurityEvents{})
+	if o.Where.Query != nil {
+		db = db.Where(o.Where.Query, o.Where.Args...)
+	}
+	db = db.Session(&gorm.Session{})
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, errors.Wrap(err, "failed to count security events")
+	}
+
+	var events []*model.SecurityEvents
+	err := db.Scopes(options.ScopesPaginate(o)).Order("created_at desc, id desc").Find(&events).Error
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "failed to list security events")
+	}
+	return events, total, nil
+}
@@ -0,0 +1,50 @@
+package postgres
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/eachinchung/errors"
+
+	"github.com/eachinchung/e-service/internal/app/store"
+	"github.com/eachinchung/e-service/internal/app/store/model"
+)
+
+type serviceAccount struct{}
+
+func newServiceAccount() *serviceAccount {
+	return &serviceAccount{}
+}
+
+var _ store.ServiceAccountStore = &serviceAccount{}
+
+func (s serviceAccount) Create(ctx context.Context, db *gorm.DB, account *model.ServiceAccounts) error {
+	if err := db.Create(account).Error; err != nil {
+		return errors.Wrap(err, "failed to create service account")
+	}
+	return nil
+}
+
+func (s serviceAccount) Get(ctx context.Context, db *gorm.DB, clientID string) (*model.ServiceAccounts, error) {
+	var account model.ServiceAccounts
+	if err := db.Where("client_id = ?", clientID).First(&account).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to get service account")
+	}
+	return &account, nil
+}
+
+func (s serviceAccount) List(ctx context.Context, db *gorm.DB) ([]*model.ServiceAccounts, error) {
+	var accounts []*model.ServiceAccounts
+	if err := db.Order("id desc").Find(&accounts).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to list service accounts")
+	}
+	return accounts, nil
+}
+
+func (s serviceAccount) Delete(ctx context.Context, db *gorm.DB, account *model.ServiceAccounts) error {
+	if err := db.Delete(account).Error; err != nil {
+		return errors.Wrap(err, "failed to delete service account")
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/eachinchung/errors"
+
+	"github.com/eachinchung/e-service/internal/app/store"
+	"github.com/eachinchung/e-service/internal/app/store/model"
+)
+
+type session struct{}
+
+func newSession() *session {
+	return &session{}
+}
+
+var _ store.SessionStore = &session{}
+
+func (s session) Create(ctx context.Context, db *gorm.DB, session *model.Sessions) error {
+	if err := db.Create(session).Error; err != nil {
+		return errors.Wrap(err, "failed to create session")
+	}
+	return nil
+}
+
+func (s session) Get(ctx context.Context, db *gorm.DB, sid string) (*model.Sessions, error) {
+	var session model.Sessions
+	if err := db.Where("sid = ?", sid).First(&session).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to get session")
+	}
+	return &session, nil
+}
+
+func (s session) ListActive(ctx context.Context, db *gorm.DB, eid string, seenAfter time.Time) ([]*model.Sessions, error) {
+	var sessions []*model.Sessions
+	err := db.Where("eid = ? AND ended_at IS NULL AND last_seen_at > ?", eid, seenAfter).
+		Order("last_seen_at desc").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list sessions")
+	}
+	return sessions, nil
+}
+
+func (s session) End(ctx context.Context, db *gorm.DB, sid string) error {
+	err := db.Model(&model.Sessions{}).Where("sid = ? AND ended_at IS NULL", sid).Update("ended_at", time.Now()).Error
+	if err != nil {
+		return errors.Wrap(err, "failed to end session")
+	}
+	return nil
+}
+
+func (s session) EndAll(ctx context.Context, db *gorm.DB, eid string) error {
+	err := db.Model(&model.Sessions{}).Where("eid = ? AND ended_at IS NULL", eid).Update("ended_at", time.Now()).Error
+	if err != nil {
+		return errors.Wrap(err, "failed to end sessions")
+	}
+	return nil
+}
+
+func (s session) Touch(ctx context.Context, db *gorm.DB, sid string) error {
+	err := db.Model(&model.Sessions{}).Where("sid = ? AND ended_at IS NULL", sid).Update("last_seen_at", time.Now()).Error
+	if err != nil {
+		return errors.Wrap(err, "failed to update session last seen time")
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+package store
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/eachinchung/component-base/db/options"
+
+	"github.com/eachinchung/e-service/internal/app/store/model"
+)
+
+type SecurityEventStore interface {
+	Create(ctx context.Context, db *gorm.DB, event *model.SecurityEvents) error
+	List(ctx context.Context, db *gorm.DB, opts ...options.Opt) ([]*model.SecurityEvents, int64, error)
+}
@@ -0,0 +1,16 @@
+package store
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/eachinchung/e-service/internal/app/store/model"
+)
+
+type ServiceAccountStore interface {
+	Create(ctx context.Context, db *gorm.DB, account *model.ServiceAccounts) error
+	Get(ctx context.Context, db *gorm.DB, clientID string) (*model.ServiceAccounts, error)
+	List(ctx context.Context, db *gorm.DB) ([]*model.ServiceAccounts, error)
+	Delete(ctx context.Context, db *gorm.DB, account *model.ServiceAccounts) error
+}
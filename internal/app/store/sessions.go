@@ -0,0 +1,19 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/eachinchung/e-service/internal/app/store/model"
+)
+
+type SessionStore interface {
+	Create(ctx context.Context, db *gorm.DB, session *model.Sessions) error
+	Get(ctx context.Context, db *gorm.DB, sid string) (*model.Sessions, error)
+	ListActive(ctx context.Context, db *gorm.DB, eid string, seenAfter time.Time) ([]*model.Sessions, error)
+	End(ctx context.Context, db *gorm.DB, sid string) error
+	EndAll(ctx context.Context, db *gorm.DB, eid string) error
+	Touch(ctx context.Context, db *gorm.DB, sid string) error
+}
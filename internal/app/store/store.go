@@ -17,6 +17,17 @@ type Store interface {
 
 	User() UserStore
 	SuperUsers() SuperUsersStore
+	TOTP() TOTPStore
+	RecoveryCodes() RecoveryCodeStore
+	OAuthClients() OAuthClientStore
+	OAuthConsents() OAuthConsentStore
+	APIKeys() APIKeyStore
+	Sessions() SessionStore
+	PasswordHistory() PasswordHistoryStore
+	Identities() IdentityStore
+	ImpersonationLogs() ImpersonationLogStore
+	ServiceAccounts() ServiceAccountStore
+	SecurityEvents() SecurityEventStore
 }
 
 // Client 返回 store 客户端实例。
@@ -3,6 +3,7 @@ package casbin
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 
 	"github.com/casbin/casbin/v2"
@@ -22,6 +23,10 @@ import (
 	"github.com/eachinchung/e-service/internal/pkg/code"
 )
 
+// RolePrefix 是角色在 casbin 中的前缀，角色与用户、服务账号使用不同的命名空间，
+// 直接拥有权限的用户不会被当作角色，用户名也不会与角色名冲突。
+const RolePrefix = "role:"
+
 var (
 	enforcer *casbin.Enforcer
 	once     sync.Once
@@ -44,6 +49,9 @@ func GetEnforcerOr(opts *options.CasbinOptions) (*casbin.Enforcer, error) {
 		if err = enforcer.LoadPolicy(); err != nil {
 			return
 		}
+		if err = migrateLegacyRoles(s); err != nil {
+			return
+		}
 
 		enforcer.AddFunction("isSuperUser", func(arguments ...any) (any, error) {
 			rSub := arguments[0].(string)
@@ -63,6 +71,37 @@ func RBACMiddleWare() gin.HandlerFunc {
 		claims := auth.ExtractClaimsFromContext(c)
 		eid := claims["sub"].(string)
 		srv := service.NewService(store.Client(), storage.Client())
+
+		// 服务账号不是用户，只需要确认服务账号仍然存在
+		if clientID, ok := model.ServiceAccountClientID(eid); ok {
+			account, err := srv.ServiceAccounts().Get(c, clientID)
+			if err != nil {
+				if errors.IsCode(err, code.ErrServiceAccountNotExist) {
+					core.WriteResponse(
+						c,
+						nil,
+						core.WithError(errors.Code(code.ErrServiceAccountInvalid, "服务账号不存在")),
+						core.WithAbort(),
+					)
+					return
+				}
+
+				log.L(c).Errorf("获取服务账号信息失败: %+v", err)
+				core.WriteResponse(
+					c,
+					nil,
+					core.WithError(errors.Code(code.ErrDatabase, "获取服务账号信息失败")),
+					core.WithAbort(),
+				)
+				return
+			}
+
+			if enforceRequest(c, account.Subject()) {
+				account.SaveToContext(c)
+			}
+			return
+		}
+
 		user, err := srv.Users().GetByEID(c, eid)
 		if err != nil {
 			log.L(c).Errorf("获取用户信息失败: %+v", err)
@@ -86,24 +125,17 @@ func RBACMiddleWare() gin.HandlerFunc {
 			return
 		}
 
-		ok, err := Enforce(c, user.EID, c.Request.URL.Path, c.Request.Method)
-		if err != nil {
-			log.L(c).Errorf("获取用户权限失败: %+v", err)
-			core.WriteResponse(
-				c,
-				nil,
-				core.WithError(errors.Code(code.ErrDatabase, "获取用户权限失败")),
-				core.WithAbort(),
-			)
+		if !enforceRequest(c, user.EID) {
 			return
 		}
 
-		if !ok {
-			log.L(c).Warnf("用户 %s 没有权限: %+v", user.EID, c.Request.URL.Path)
+		// 使用 API key 访问时，只允许访问用户权限与 API key 权限的交集
+		if key := model.ExtractAPIKeysFromContext(c); key != nil && !key.Allows(c.Request.URL.Path, c.Request.Method) {
+			log.L(c).Warnf("API key %s 没有权限: %+v", key.KeyID, c.Request.URL.Path)
 			core.WriteResponse(
 				c,
 				nil,
-				core.WithError(errors.Code(code.ErrPermissionDenied, "用户没有权限")),
+				core.WithError(errors.Code(code.ErrPermissionDenied, "API key 没有权限")),
 				core.WithAbort(),
 			)
 			return
@@ -113,6 +145,34 @@ func RBACMiddleWare() gin.HandlerFunc {
 	}
 }
 
+// enforceRequest 校验 sub 是否有权限访问当前请求的接口，没有权限时写入响应并返回 false。
+func enforceRequest(c *gin.Context, sub string) bool {
+	ok, err := Enforce(c, sub, c.Request.URL.Path, c.Request.Method)
+	if err != nil {
+		log.L(c).Errorf("获取用户权限失败: %+v", err)
+		core.WriteResponse(
+			c,
+			nil,
+			core.WithError(errors.Code(code.ErrDatabase, "获取用户权限失败")),
+			core.WithAbort(),
+		)
+		return false
+	}
+
+	if !ok {
+		log.L(c).Warnf("用户 %s 没有权限: %+v", sub, c.Request.URL.Path)
+		core.WriteResponse(
+			c,
+			nil,
+			core.WithError(errors.Code(code.ErrPermissionDenied, "用户没有权限")),
+			core.WithAbort(),
+		)
+		return false
+	}
+
+	return true
+}
+
 //goland:noinspection SpellCheckingInspection
 func Enforce(ctx context.Context, user any, permission ...any) (bool, error) {
 	ok, err := enforcer.Enforce(joinSlice(user, permission...)...)
@@ -158,6 +218,210 @@ func HasPermissionForUser(ctx context.Context, user string, permission ...string
 	return ok
 }
 
+// AddRolesForUser 为用户添加角色
+func AddRolesForUser(ctx context.Context, user string, roles ...string) error {
+	if len(roles) == 0 {
+		return nil
+	}
+
+	subjects := make([]string, 0, len(roles))
+	for _, role := range roles {
+		subjects = append(subjects, RoleSubject(role))
+	}
+
+	if _, err := enforcer.AddRolesForUser(user, subjects); err != nil {
+		return errors.Wrap(err, "添加用户角色失败")
+	}
+	log.L(ctx).Infof("用户 %s 添加角色: %+v", user, roles)
+	return nil
+}
+
+// GetRolesForUser 获取用户的角色
+func GetRolesForUser(ctx context.Context, user string) ([]string, error) {
+	subjects, err := enforcer.GetRolesForUser(user)
+	if err != nil {
+		return nil, errors.Wrap(err, "获取用户角色失败")
+	}
+	return roleNames(subjects), nil
+}
+
+// DeleteUser 删除用户的全部角色与权限
+func DeleteUser(ctx context.Context, user string) error {
+	if _, err := enforcer.DeleteUser(user); err != nil {
+		return errors.Wrap(err, "删除用户角色与权限失败")
+	}
+	log.L(ctx).Infof("用户 %s 的角色与权限已删除", user)
+	return nil
+}
+
+// RoleSubject 返回角色在 casbin 中的主体
+func RoleSubject(role string) string {
+	return RolePrefix + role
+}
+
+// IsRole 判断 role 是否为拥有权限的角色
+func IsRole(ctx context.Context, role string) bool {
+	return len(GetPermissionsForRole(ctx, role)) > 0
+}
+
+// GetAllRoles 获取所有拥有权限的角色
+func GetAllRoles(ctx context.Context) []string {
+	return roleNames(enforcer.GetAllSubjects())
+}
+
+// AddRole 创建拥有 permissions 的角色，权限在同一个事务中添加，任意一条权限已存在时不添加任何权限并返回 false
+func AddRole(ctx context.Context, role string, permissions ...[]string) (bool, error) {
+	rules := make([][]string, 0, len(permissions))
+	for _, p := range permissions {
+		rules = append(rules, append([]string{RoleSubject(role)}, p...))
+	}
+
+	ok, err := enforcer.AddPolicies(rules)
+	if err != nil {
+		return false, errors.Wrap(err, "添加角色失败")
+	}
+	log.L(ctx).Infof("角色 %s 添加权限: %+v 结果: %+v", role, permissions, ok)
+	return ok, nil
+}
+
+// AddPermissionForRole 添加角色权限
+func AddPermissionForRole(ctx context.Context, role string, permission ...string) error {
+	return AddPermissionForUser(ctx, RoleSubject(role), permission...)
+}
+
+// DeletePermissionForRole 删除角色权限
+func DeletePermissionForRole(ctx context.Context, role string, permission ...string) error {
+	return DeletePermissionForUser(ctx, RoleSubject(role), permission...)
+}
+
+// GetPermissionsForRole 获取角色的权限
+func GetPermissionsForRole(ctx context.Context, role string) [][]string {
+	return GetPermissionsForUser(ctx, RoleSubject(role))
+}
+
+// HasPermissionForRole 确定角色是否具有权限
+func HasPermissionForRole(ctx context.Context, role string, permission ...string) bool {
+	return HasPermissionForUser(ctx, RoleSubject(role), permission...)
+}
+
+// GetUsersForRole 获取拥有角色的用户
+func GetUsersForRole(ctx context.Context, role string) ([]string, error) {
+	users, err := enforcer.GetUsersForRole(RoleSubject(role))
+	if err != nil {
+		return nil, errors.Wrap(err, "获取角色用户失败")
+	}
+	return users, nil
+}
+
+// DeleteRoleForUser 删除用户的角色
+func DeleteRoleForUser(ctx context.Context, user, role string) error {
+	if _, err := enforcer.DeleteRoleForUser(user, RoleSubject(role)); err != nil {
+		return errors.Wrap(err, "删除用户角色失败")
+	}
+	log.L(ctx).Infof("用户 %s 删除角色: %s", user, role)
+	return nil
+}
+
+// DeleteRole 删除角色的全部权限以及所有用户的该角色
+func DeleteRole(ctx context.Context, role string) error {
+	if _, err := enforcer.DeleteRole(RoleSubject(role)); err != nil {
+		return errors.Wrap(err, "删除角色失败")
+	}
+	log.L(ctx).Infof("角色 %s 已删除", role)
+	return nil
+}
+
+// HasPolicy 判断主体是否拥有权限，或被当作角色分配给了其他主体，用于拒绝与旧版未加前缀的角色同名的用户名
+func HasPolicy(ctx context.Context, sub string) bool {
+	return len(enforcer.GetFilteredPolicy(0, sub)) > 0 || len(enforcer.GetFilteredGroupingPolicy(1, sub)) > 0
+}
+
+// migrateLegacyRoles 为旧版未加前缀的角色补上角色前缀。
+// 被分配给其他主体的主体，以及拥有权限但不是用户、服务账号的主体都视为旧版角色，
+// 其权限与分配关系在同一个事务中改写为带前缀的形式。
+func migrateLegacyRoles(s store.Store) error {
+	candidates := make(map[string]bool)
+	for _, sub := range enforcer.GetAllRoles() {
+		if isLegacySubject(sub) {
+			candidates[sub] = true
+		}
+	}
+
+	var subjects []string
+	for _, sub := range enforcer.GetAllSubjects() {
+		if isLegacySubject(sub) && !candidates[sub] {
+			subjects = append(subjects, sub)
+		}
+	}
+
+	if len(subjects) > 0 {
+		var eids []string
+		err := s.DB().Unscoped().Model(&model.Users{}).Where("eid IN ?", subjects).Pluck("eid", &eids).Error
+		if err != nil {
+			return errors.Wrap(err, "查询旧版角色对应的用户失败")
+		}
+
+		users := make(map[string]bool, len(eids))
+		for _, eid := range eids {
+			users[eid] = true
+		}
+		for _, sub := range subjects {
+			if !users[sub] {
+				candidates[sub] = true
+			}
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	var oldPolicies, newPolicies, oldGroupings, newGroupings [][]string
+	for role := range candidates {
+		for _, rule := range enforcer.GetFilteredPolicy(0, role) {
+			oldPolicies = append(oldPolicies, rule)
+			newPolicies = append(newPolicies, append([]string{RoleSubject(role)}, rule[1:]...))
+		}
+		for _, rule := range enforcer.GetFilteredGroupingPolicy(1, role) {
+			oldGroupings = append(oldGroupings, rule)
+			newGroupings = append(newGroupings, append([]string{rule[0], RoleSubject(role)}, rule[2:]...))
+		}
+	}
+
+	if len(oldPolicies) > 0 {
+		if _, err := enforcer.UpdatePolicies(oldPolicies, newPolicies); err != nil {
+			return errors.Wrap(err, "迁移旧版角色权限失败")
+		}
+	}
+	if len(oldGroupings) > 0 {
+		if _, err := enforcer.UpdateGroupingPolicies(oldGroupings, newGroupings); err != nil {
+			return errors.Wrap(err, "迁移旧版角色分配失败")
+		}
+		if err := enforcer.BuildRoleLinks(); err != nil {
+			return errors.Wrap(err, "重建角色关系失败")
+		}
+	}
+
+	log.Infof("已为 %d 个旧版角色添加角色前缀", len(candidates))
+	return nil
+}
+
+// isLegacySubject 判断主体是否既不是带前缀的角色，也不是服务账号
+func isLegacySubject(sub string) bool {
+	return !strings.HasPrefix(sub, RolePrefix) && !strings.HasPrefix(sub, model.ServiceAccountPrefix)
+}
+
+// roleNames 从 casbin 主体中筛选出角色，并去掉角色前缀
+func roleNames(subjects []string) []string {
+	roles := make([]string, 0, len(subjects))
+	for _, sub := range subjects {
+		if strings.HasPrefix(sub, RolePrefix) {
+			roles = append(roles, strings.TrimPrefix(sub, RolePrefix))
+		}
+	}
+	return roles
+}
+
 // joinSlice joins an any and a slice into a new slice.
 func joinSlice(a any, b ...any) []any {
 	res := make([]any, 0, len(b)+1)
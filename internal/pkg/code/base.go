@@ -54,6 +54,18 @@ const (
 
 	// ErrFailedAuthentication - 401: 用户名或密码不正确.
 	ErrFailedAuthentication
+
+	// ErrTokenRevoked - 401: token 已被吊销.
+	ErrTokenRevoked
+
+	// ErrRefreshTokenInvalid - 401: refresh token 无效或已过期.
+	ErrRefreshTokenInvalid
+
+	// ErrRefreshTokenReused - 401: refresh token 被重复使用, 已吊销该登录的所有 token.
+	ErrRefreshTokenReused
+
+	// ErrLoginLocked - 403: 登录失败次数过多, 请稍后再试.
+	ErrLoginLocked
 )
 
 // common: database errors.
@@ -82,3 +94,201 @@ const (
 	// ErrUserStatusIsAbnormal - 403: 用户状态异常.
 	ErrUserStatusIsAbnormal
 )
+
+// common: 验证码相关错误
+const (
+	// ErrVerificationCodeIncorrect - 400: 验证码错误.
+	ErrVerificationCodeIncorrect int = iota + 100401
+
+	// ErrVerificationCodeExpired - 400: 验证码不存在或已过期.
+	ErrVerificationCodeExpired
+
+	// ErrVerificationCodeAttemptsExceeded - 403: 验证码错误次数过多, 请重新获取.
+	ErrVerificationCodeAttemptsExceeded
+
+	// ErrSendTooFrequently - 403: 发送过于频繁, 请稍后再试.
+	ErrSendTooFrequently
+
+	// ErrSendCodeFailed - 500: 验证码发送失败.
+	ErrSendCodeFailed
+)
+
+// common: 两步验证相关错误
+const (
+	// ErrMFACodeIncorrect - 400: 动态验证码或恢复码错误.
+	ErrMFACodeIncorrect int = iota + 100501
+
+	// ErrMFAChallengeInvalid - 401: 两步验证已过期, 请重新登录.
+	ErrMFAChallengeInvalid
+
+	// ErrMFAAlreadyEnabled - 400: 已启用两步验证.
+	ErrMFAAlreadyEnabled
+
+	// ErrMFANotEnabled - 400: 尚未启用两步验证.
+	ErrMFANotEnabled
+
+	// ErrMFAEnrollRequired - 403: 请先启用两步验证.
+	ErrMFAEnrollRequired
+)
+
+// common: OAuth 相关错误
+const (
+	// ErrOAuthClientNotExist - 404: 应用不存在.
+	ErrOAuthClientNotExist int = iota + 100601
+
+	// ErrOAuthInvalidClient - 401: 应用认证失败.
+	ErrOAuthInvalidClient
+
+	// ErrOAuthInvalidRedirectURI - 400: 回调地址未登记.
+	ErrOAuthInvalidRedirectURI
+
+	// ErrOAuthInvalidScope - 400: 申请的权限不合法.
+	ErrOAuthInvalidScope
+
+	// ErrOAuthInvalidGrant - 400: 授权码或 refresh token 无效.
+	ErrOAuthInvalidGrant
+
+	// ErrOAuthInvalidRequest - 400: 授权请求不合法.
+	ErrOAuthInvalidRequest
+
+	// ErrOAuthUnsupportedGrantType - 400: 不支持的授权类型.
+	ErrOAuthUnsupportedGrantType
+
+	// ErrOAuthUnsupportedResponseType - 400: 不支持的授权方式.
+	ErrOAuthUnsupportedResponseType
+
+	// ErrOAuthAccessDenied - 403: 用户拒绝了授权.
+	ErrOAuthAccessDenied
+)
+
+// common: API key 相关错误
+const (
+	// ErrAPIKeyInvalid - 401: API key 无效或已过期.
+	ErrAPIKeyInvalid int = iota + 100701
+
+	// ErrAPIKeyNotExist - 404: API key 不存在.
+	ErrAPIKeyNotExist
+
+	// ErrAPIKeyLimitExceeded - 403: API key 数量已达上限.
+	ErrAPIKeyLimitExceeded
+)
+
+// common: 会话相关错误
+const (
+	// ErrSessionNotExist - 404: 会话不存在或已结束.
+	ErrSessionNotExist int = iota + 100801
+)
+
+// common: 密码相关错误
+const (
+	// ErrPasswordResetTicketInvalid - 400: 重置密码凭证无效或已过期.
+	ErrPasswordResetTicketInvalid int = iota + 100901
+
+	// ErrPasswordResetTooFrequently - 403: 操作过于频繁, 请稍后再试.
+	ErrPasswordResetTooFrequently
+
+	// ErrPasswordIncorrect - 400: 当前密码错误.
+	ErrPasswordIncorrect
+
+	// ErrPasswordExpired - 403: 密码已过期, 请修改密码.
+	ErrPasswordExpired
+)
+
+// common: 邮箱相关错误
+const (
+	// ErrEmailNotSet - 400: 尚未设置邮箱.
+	ErrEmailNotSet int = iota + 101001
+
+	// ErrEmailAlreadyVerified - 400: 邮箱已验证.
+	ErrEmailAlreadyVerified
+)
+
+// common: 第三方登录相关错误
+const (
+	// ErrIdentityProviderNotExist - 404: 不支持该第三方登录方式.
+	ErrIdentityProviderNotExist int = iota + 101101
+
+	// ErrIdentityStateInvalid - 400: 第三方登录已过期, 请重新登录.
+	ErrIdentityStateInvalid
+
+	// ErrIdentityExchangeFailed - 401: 第三方登录失败.
+	ErrIdentityExchangeFailed
+
+	// ErrIdentityTicketInvalid - 400: 第三方账号绑定凭证无效或已过期.
+	ErrIdentityTicketInvalid
+
+	// ErrIdentityAlreadyLinked - 400: 该第三方账号已绑定其他用户.
+	ErrIdentityAlreadyLinked
+
+	// ErrIdentityProviderAlreadyLinked - 400: 已绑定该平台的第三方账号.
+	ErrIdentityProviderAlreadyLinked
+
+	// ErrIdentityNotExist - 404: 未绑定该平台的第三方账号.
+	ErrIdentityNotExist
+)
+
+// common: 模拟登录相关错误
+const (
+	// ErrImpersonationNotAllowed - 403: 不允许模拟登录该用户.
+	ErrImpersonationNotAllowed int = iota + 101201
+
+	// ErrImpersonationForbidden - 403: 模拟登录时不能执行此操作.
+	ErrImpersonationForbidden
+)
+
+// common: 扫码登录相关错误
+const (
+	// ErrQRLoginInvalid - 400: 二维码已失效, 请刷新二维码.
+	ErrQRLoginInvalid int = iota + 101301
+
+	// ErrQRLoginStateInvalid - 400: 二维码状态异常, 请重新扫码.
+	ErrQRLoginStateInvalid
+)
+
+// common: 设备授权相关错误
+const (
+	// ErrDeviceAuthorizationPending - 400: 等待用户授权.
+	ErrDeviceAuthorizationPending int = iota + 101401
+
+	// ErrDeviceSlowDown - 400: 轮询过于频繁, 请降低轮询频率.
+	ErrDeviceSlowDown
+
+	// ErrDeviceCodeExpired - 400: 设备码已失效, 请重新发起授权.
+	ErrDeviceCodeExpired
+
+	// ErrDeviceUserCodeInvalid - 400: 用户码错误或已失效.
+	ErrDeviceUserCodeInvalid
+
+	// ErrDeviceClientUnauthorized - 400: 该应用不允许使用设备授权.
+	ErrDeviceClientUnauthorized
+)
+
+// common: 服务账号相关错误
+const (
+	// ErrServiceAccountNotExist - 404: 服务账号不存在.
+	ErrServiceAccountNotExist int = iota + 101501
+
+	// ErrServiceAccountInvalid - 401: 服务账号不存在或已被删除.
+	ErrServiceAccountInvalid
+
+	// ErrServiceAccountForbidden - 403: 服务账号不能执行此操作.
+	ErrServiceAccountForbidden
+
+	// ErrServiceAccountKeyInvalid - 400: 公钥格式错误.
+	ErrServiceAccountKeyInvalid
+)
+
+// common: 身份验证相关错误
+const (
+	// ErrStepUpRequired - 401: 请重新验证身份后再进行此操作.
+	ErrStepUpRequired int = iota + 101601
+)
+
+// common: 角色相关错误
+const (
+	// ErrRoleNotExist - 404: 角色不存在.
+	ErrRoleNotExist int = iota + 101701
+
+	// ErrRoleAlreadyExist - 400: 角色名已被使用.
+	ErrRoleAlreadyExist
+)
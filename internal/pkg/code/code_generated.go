@@ -19,6 +19,10 @@ func init() {
 	register(ErrEmptyToken, 401, "没有携带 token")
 	register(ErrInvalidSigningAlgorithm, 400, "无效签名算法")
 	register(ErrFailedAuthentication, 401, "用户名或密码不正确")
+	register(ErrTokenRevoked, 401, "token 已被吊销")
+	register(ErrRefreshTokenInvalid, 401, "refresh token 无效或已过期")
+	register(ErrRefreshTokenReused, 401, "refresh token 被重复使用, 已吊销该登录的所有 token")
+	register(ErrLoginLocked, 403, "登录失败次数过多, 请稍后再试")
 	register(ErrDatabase, 500, "数据库错误")
 	register(ErrUserAlreadyExist, 400, "用户已存在")
 	register(ErrUserNotExist, 404, "用户不存在")
@@ -26,4 +30,56 @@ func init() {
 	register(ErrPhoneAlreadyExist, 400, "该手机号码已注册")
 	register(ErrEmailAlreadyExist, 400, "该邮箱已注册")
 	register(ErrUserStatusIsAbnormal, 403, "用户状态异常")
+	register(ErrVerificationCodeIncorrect, 400, "验证码错误")
+	register(ErrVerificationCodeExpired, 400, "验证码不存在或已过期")
+	register(ErrVerificationCodeAttemptsExceeded, 403, "验证码错误次数过多, 请重新获取")
+	register(ErrSendTooFrequently, 403, "发送过于频繁, 请稍后再试")
+	register(ErrSendCodeFailed, 500, "验证码发送失败")
+	register(ErrMFACodeIncorrect, 400, "动态验证码或恢复码错误")
+	register(ErrMFAChallengeInvalid, 401, "两步验证已过期, 请重新登录")
+	register(ErrMFAAlreadyEnabled, 400, "已启用两步验证")
+	register(ErrMFANotEnabled, 400, "尚未启用两步验证")
+	register(ErrMFAEnrollRequired, 403, "请先启用两步验证")
+	register(ErrOAuthClientNotExist, 404, "应用不存在")
+	register(ErrOAuthInvalidClient, 401, "应用认证失败")
+	register(ErrOAuthInvalidRedirectURI, 400, "回调地址未登记")
+	register(ErrOAuthInvalidScope, 400, "申请的权限不合法")
+	register(ErrOAuthInvalidGrant, 400, "授权码或 refresh token 无效")
+	register(ErrOAuthInvalidRequest, 400, "授权请求不合法")
+	register(ErrOAuthUnsupportedGrantType, 400, "不支持的授权类型")
+	register(ErrOAuthUnsupportedResponseType, 400, "不支持的授权方式")
+	register(ErrOAuthAccessDenied, 403, "用户拒绝了授权")
+	register(ErrAPIKeyInvalid, 401, "API key 无效或已过期")
+	register(ErrAPIKeyNotExist, 404, "API key 不存在")
+	register(ErrAPIKeyLimitExceeded, 403, "API key 数量已达上限")
+	register(ErrSessionNotExist, 404, "会话不存在或已结束")
+	register(ErrPasswordResetTicketInvalid, 400, "重置密码凭证无效或已过期")
+	register(ErrPasswordResetTooFrequently, 403, "操作过于频繁, 请稍后再试")
+	register(ErrPasswordIncorrect, 400, "当前密码错误")
+	register(ErrPasswordExpired, 403, "密码已过期, 请修改密码")
+	register(ErrEmailNotSet, 400, "尚未设置邮箱")
+	register(ErrEmailAlreadyVerified, 400, "邮箱已验证")
+	register(ErrIdentityProviderNotExist, 404, "不支持该第三方登录方式")
+	register(ErrIdentityStateInvalid, 400, "第三方登录已过期, 请重新登录")
+	register(ErrIdentityExchangeFailed, 401, "第三方登录失败")
+	register(ErrIdentityTicketInvalid, 400, "第三方账号绑定凭证无效或已过期")
+	register(ErrIdentityAlreadyLinked, 400, "该第三方账号已绑定其他用户")
+	register(ErrIdentityProviderAlreadyLinked, 400, "已绑定该平台的第三方账号")
+	register(ErrIdentityNotExist, 404, "未绑定该平台的第三方账号")
+	register(ErrImpersonationNotAllowed, 403, "不允许模拟登录该用户")
+	register(ErrImpersonationForbidden, 403, "模拟登录时不能执行此操作")
+	register(ErrQRLoginInvalid, 400, "二维码已失效, 请刷新二维码")
+	register(ErrQRLoginStateInvalid, 400, "二维码状态异常, 请重新扫码")
+	register(ErrDeviceAuthorizationPending, 400, "等待用户授权")
+	register(ErrDeviceSlowDown, 400, "轮询过于频繁, 请降低轮询频率")
+	register(ErrDeviceCodeExpired, 400, "设备码已失效, 请重新发起授权")
+	register(ErrDeviceUserCodeInvalid, 400, "用户码错误或已失效")
+	register(ErrDeviceClientUnauthorized, 400, "该应用不允许使用设备授权")
+	register(ErrServiceAccountNotExist, 404, "服务账号不存在")
+	register(ErrServiceAccountInvalid, 401, "服务账号不存在或已被删除")
+	register(ErrServiceAccountForbidden, 403, "服务账号不能执行此操作")
+	register(ErrServiceAccountKeyInvalid, 400, "公钥格式错误")
+	register(ErrStepUpRequired, 401, "请重新验证身份后再进行此操作")
+	register(ErrRoleNotExist, 404, "角色不存在")
+	register(ErrRoleAlreadyExist, 400, "角色名已被使用")
 }
@@ -0,0 +1,122 @@
+package hasher
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+
+	"github.com/eachinchung/errors"
+
+	"github.com/eachinchung/e-service/internal/pkg/options"
+)
+
+const argon2idPrefix = "$argon2id$"
+
+// argon2Params 是 argon2id 的参数。
+type argon2Params struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	saltLength  uint32
+	keyLength   uint32
+}
+
+// argon2idHasher 使用 argon2id 计算哈希，编码格式为 $argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<hash>，
+// salt 与 hash 使用不带填充的 base64 编码。
+type argon2idHasher struct {
+	params argon2Params
+}
+
+func newArgon2id(opts *options.PasswordHashOptions) *argon2idHasher {
+	return &argon2idHasher{params: argon2Params{
+		memory:      opts.Argon2Memory,
+		iterations:  opts.Argon2Iterations,
+		parallelism: opts.Argon2Parallelism,
+		saltLength:  opts.Argon2SaltLength,
+		keyLength:   opts.Argon2KeyLength,
+	}}
+}
+
+func (a *argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, a.params.saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", errors.Wrap(err, "failed to generate salt")
+	}
+
+	p := a.params
+	key := argon2.IDKey([]byte(password), salt, p.iterations, p.memory, p.parallelism, p.keyLength)
+
+	return fmt.Sprintf(
+		"%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix,
+		argon2.Version,
+		p.memory,
+		p.iterations,
+		p.parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (a *argon2idHasher) Compare(encoded, password string) error {
+	p, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return err
+	}
+
+	other := argon2.IDKey([]byte(password), salt, p.iterations, p.memory, p.parallelism, p.keyLength)
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return ErrMismatchedPassword
+	}
+	return nil
+}
+
+func (a *argon2idHasher) Identify(encoded string) bool {
+	return strings.HasPrefix(encoded, argon2idPrefix)
+}
+
+func (a *argon2idHasher) Outdated(encoded string) bool {
+	p, _, _, err := decodeArgon2id(encoded)
+	return err != nil || p != a.params
+}
+
+// decodeArgon2id 解析 argon2id 哈希的参数、盐与哈希值。
+func decodeArgon2id(encoded string) (argon2Params, []byte, []byte, error) {
+	var p argon2Params
+
+	// "", "argon2id", "v=19", "m=65536,t=3,p=2", salt, hash
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return p, nil, nil, errors.New("invalid argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return p, nil, nil, errors.Wrap(err, "invalid argon2id version")
+	}
+	if version != argon2.Version {
+		return p, nil, nil, errors.Errorf("unsupported argon2id version: %d", version)
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.iterations, &p.parallelism); err != nil {
+		return p, nil, nil, errors.Wrap(err, "invalid argon2id parameters")
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, errors.Wrap(err, "invalid argon2id salt")
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return p, nil, nil, errors.Wrap(err, "invalid argon2id hash")
+	}
+
+	p.saltLength = uint32(len(salt))
+	p.keyLength = uint32(len(key))
+	return p, salt, key, nil
+}
@@ -0,0 +1,46 @@
+package hasher
+
+import (
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/eachinchung/errors"
+)
+
+// bcryptHasher 使用 bcrypt 计算哈希，编码格式为 $2a$<cost>$<salt><hash>。
+type bcryptHasher struct {
+	cost int
+}
+
+func newBcrypt(cost int) *bcryptHasher {
+	return &bcryptHasher{cost: cost}
+}
+
+func (b *bcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), b.cost)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to hash password with bcrypt")
+	}
+	return string(hash), nil
+}
+
+func (b *bcryptHasher) Compare(encoded, password string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	switch {
+	case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
+		return ErrMismatchedPassword
+	case err != nil:
+		return errors.Wrap(err, "failed to compare bcrypt hash")
+	}
+	return nil
+}
+
+func (b *bcryptHasher) Identify(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+func (b *bcryptHasher) Outdated(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost != b.cost
+}
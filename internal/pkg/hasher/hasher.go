@@ -0,0 +1,106 @@
+package hasher
+
+import (
+	"sync"
+
+	"github.com/eachinchung/errors"
+
+	"github.com/eachinchung/e-service/internal/pkg/options"
+)
+
+var (
+	// ErrMismatchedPassword 表示密码与哈希不匹配。
+	ErrMismatchedPassword = errors.New("password does not match the hash")
+
+	// ErrUnknownAlgorithm 表示无法识别哈希使用的算法。
+	ErrUnknownAlgorithm = errors.New("unknown password hash algorithm")
+)
+
+// Hasher 密码哈希器。
+// 新密码使用配置的算法计算哈希，校验时根据哈希的编码识别算法，因此切换算法后旧哈希仍然可以校验。
+type Hasher interface {
+	// Hash 使用配置的算法计算密码的哈希，返回 PHC 格式的编码字符串。
+	Hash(password string) (string, error)
+	// Compare 校验密码与哈希是否匹配，不匹配时返回 ErrMismatchedPassword。
+	Compare(encoded, password string) error
+	// NeedsRehash 判断哈希是否使用了配置以外的算法或参数，需要在下次校验成功后重新计算。
+	NeedsRehash(encoded string) bool
+}
+
+// algorithm 是一种密码哈希算法。
+type algorithm interface {
+	Hash(password string) (string, error)
+	Compare(encoded, password string) error
+	// Identify 判断哈希是否由该算法生成。
+	Identify(encoded string) bool
+	// Outdated 判断哈希的参数是否与当前配置不同。
+	Outdated(encoded string) bool
+}
+
+type hasher struct {
+	preferred  algorithm
+	algorithms []algorithm
+}
+
+var (
+	h    Hasher
+	once sync.Once
+)
+
+// GetHasherOr 根据配置创建密码哈希器。
+func GetHasherOr(opts *options.PasswordHashOptions) (Hasher, error) {
+	var err error
+
+	once.Do(func() {
+		h, err = New(opts)
+	})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "获取密码哈希器失败")
+	}
+
+	return h, nil
+}
+
+// Client 返回密码哈希器实例。
+func Client() Hasher {
+	if h == nil {
+		panic("password hasher is not set")
+	}
+	return h
+}
+
+// New 根据配置创建密码哈希器。
+func New(opts *options.PasswordHashOptions) (Hasher, error) {
+	bcrypt := newBcrypt(opts.BcryptCost)
+	argon2id := newArgon2id(opts)
+
+	hs := &hasher{algorithms: []algorithm{bcrypt, argon2id}}
+	switch opts.Algorithm {
+	case "bcrypt":
+		hs.preferred = bcrypt
+	case "argon2id":
+		hs.preferred = argon2id
+	default:
+		return nil, errors.Errorf("unsupported password hash algorithm: %s", opts.Algorithm)
+	}
+
+	return hs, nil
+}
+
+func (hs *hasher) Hash(password string) (string, error) {
+	return hs.preferred.Hash(password)
+}
+
+func (hs *hasher) Compare(encoded, password string) error {
+	for _, alg := range hs.algorithms {
+		if alg.Identify(encoded) {
+			return alg.Compare(encoded, password)
+		}
+	}
+	return ErrUnknownAlgorithm
+}
+
+func (hs *hasher) NeedsRehash(encoded string) bool {
+	return !hs.preferred.Identify(encoded) || hs.preferred.Outdated(encoded)
+}
@@ -0,0 +1,43 @@
+package idp
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/eachinchung/errors"
+)
+
+// fake 是用于开发与测试的第三方登录平台。
+// 授权页面直接跳转回 redirectURI，code 即为第三方用户 ID，不需要访问任何外部服务。
+type fake struct{}
+
+var _ Provider = fake{}
+
+func newFake() fake {
+	return fake{}
+}
+
+func (fake) Name() string {
+	return "fake"
+}
+
+func (fake) AuthCodeURL(state, redirectURI string) string {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return redirectURI
+	}
+
+	q := u.Query()
+	q.Set("code", "fake-user")
+	q.Set("state", state)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+func (fake) Exchange(_ context.Context, code, _ string) (*Identity, error) {
+	if code == "" {
+		return nil, errors.New("fake code is empty")
+	}
+
+	return &Identity{Subject: code, Nickname: code}, nil
+}
@@ -0,0 +1,86 @@
+package idp
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/eachinchung/e-service/internal/pkg/options"
+)
+
+// Identity 是第三方登录平台返回的用户信息。
+type Identity struct {
+	Subject  string // 第三方平台的用户 ID，同一个平台内唯一且不会改变
+	Nickname string // 第三方平台的昵称
+}
+
+// Provider 第三方登录平台。
+type Provider interface {
+	// Name 返回平台名称，用于路由与 identities 表中的 provider 字段。
+	Name() string
+	// AuthCodeURL 返回跳转到第三方平台授权页面的地址，授权完成后平台携带 code 与 state 跳转回 redirectURI。
+	AuthCodeURL(state, redirectURI string) string
+	// Exchange 使用授权码换取第三方平台的用户信息。
+	Exchange(ctx context.Context, code, redirectURI string) (*Identity, error)
+}
+
+// Registry 保存所有已启用的第三方登录平台。
+type Registry struct {
+	providers map[string]Provider
+}
+
+var (
+	registry *Registry
+	once     sync.Once
+)
+
+// GetRegistryOr 根据配置创建已启用的第三方登录平台。
+func GetRegistryOr(opts *options.IDPOptions) (*Registry, error) {
+	once.Do(func() {
+		registry = New(opts)
+	})
+
+	return registry, nil
+}
+
+// Client 返回第三方登录平台实例。
+func Client() *Registry {
+	if registry == nil {
+		panic("identity provider registry is not set")
+	}
+	return registry
+}
+
+// New 创建一个新的 Registry，微信登录在设置了 AppID 后启用，fake 登录只在开发与测试时启用。
+func New(opts *options.IDPOptions) *Registry {
+	r := &Registry{providers: map[string]Provider{}}
+
+	if opts.WeChatAppID != "" {
+		r.register(newWeChat(opts.WeChatAppID, opts.WeChatAppSecret))
+	}
+	if opts.Fake {
+		r.register(newFake())
+	}
+
+	return r
+}
+
+func (r *Registry) register(p Provider) {
+	r.providers[p.Name()] = p
+}
+
+// Get 返回名称为 name 的第三方登录平台，平台不存在或未启用时 ok 为 false。
+func (r *Registry) Get(name string) (p Provider, ok bool) {
+	p, ok = r.providers[name]
+	return p, ok
+}
+
+// Names 返回所有已启用的第三方登录平台名称。
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,127 @@
+package idp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/eachinchung/errors"
+)
+
+const (
+	wechatAuthorizeURL   = "https://open.weixin.qq.com/connect/qrconnect"
+	wechatAccessTokenURL = "https://api.weixin.qq.com/sns/oauth2/access_token"
+	wechatUserInfoURL    = "https://api.weixin.qq.com/sns/userinfo"
+)
+
+// wechat 通过微信开放平台的网站应用扫码登录。
+// 开放平台账号下的应用共享 unionid，因此优先使用 unionid 作为第三方用户 ID，没有时使用 openid。
+type wechat struct {
+	appID     string
+	appSecret string
+	client    *http.Client
+}
+
+var _ Provider = &wechat{}
+
+func newWeChat(appID, appSecret string) *wechat {
+	return &wechat{
+		appID:     appID,
+		appSecret: appSecret,
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type wechatError struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+type wechatAccessToken struct {
+	wechatError
+	AccessToken string `json:"access_token"`
+	OpenID      string `json:"openid"`
+	UnionID     string `json:"unionid"`
+}
+
+type wechatUserInfo struct {
+	wechatError
+	Nickname string `json:"nickname"`
+	UnionID  string `json:"unionid"`
+}
+
+func (w *wechat) Name() string {
+	return "wechat"
+}
+
+func (w *wechat) AuthCodeURL(state, redirectURI string) string {
+	q := url.Values{}
+	q.Set("appid", w.appID)
+	q.Set("redirect_uri", redirectURI)
+	q.Set("response_type", "code")
+	q.Set("scope", "snsapi_login")
+	q.Set("state", state)
+	return wechatAuthorizeURL + "?" + q.Encode() + "#wechat_redirect"
+}
+
+func (w *wechat) Exchange(ctx context.Context, code, _ string) (*Identity, error) {
+	q := url.Values{}
+	q.Set("appid", w.appID)
+	q.Set("secret", w.appSecret)
+	q.Set("code", code)
+	q.Set("grant_type", "authorization_code")
+
+	var token wechatAccessToken
+	if err := w.get(ctx, wechatAccessTokenURL+"?"+q.Encode(), &token, &token.wechatError); err != nil {
+		return nil, errors.Wrap(err, "failed to get wechat access token")
+	}
+
+	q = url.Values{}
+	q.Set("access_token", token.AccessToken)
+	q.Set("openid", token.OpenID)
+
+	var info wechatUserInfo
+	if err := w.get(ctx, wechatUserInfoURL+"?"+q.Encode(), &info, &info.wechatError); err != nil {
+		return nil, errors.Wrap(err, "failed to get wechat user info")
+	}
+
+	identity := &Identity{Subject: token.OpenID, Nickname: info.Nickname}
+	switch {
+	case info.UnionID != "":
+		identity.Subject = info.UnionID
+	case token.UnionID != "":
+		identity.Subject = token.UnionID
+	}
+
+	return identity, nil
+}
+
+// get 请求微信接口并解析响应，微信接口出错时同样返回 200，需要检查响应中的 errcode。
+func (w *wechat) get(ctx context.Context, rawURL string, v any, e *wechatError) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	//goland:noinspection GoUnhandledErrorResult
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return err
+	}
+
+	if e.ErrCode != 0 {
+		return errors.Errorf("wechat error, code: %d, message: %s", e.ErrCode, e.ErrMsg)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package keyset
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/eachinchung/errors"
+)
+
+// Key 是一个 jwt 签名密钥。
+type Key struct {
+	ID         string
+	Method     jwt.SigningMethod
+	ActivateAt time.Time
+
+	private any
+	public  any
+}
+
+// Public 返回用于校验签名的公钥，对称密钥返回密钥本身。
+func (k *Key) Public() any {
+	return k.public
+}
+
+// Symmetric 返回密钥是否为对称密钥，对称密钥不会发布到 JWKS。
+func (k *Key) Symmetric() bool {
+	_, ok := k.public.([]byte)
+	return ok
+}
+
+func newHMACKey(secret string) *Key {
+	return &Key{
+		Method:  jwt.SigningMethodHS512,
+		private: []byte(secret),
+		public:  []byte(secret),
+	}
+}
+
+// loadKey 从 PEM 文件加载私钥，并根据私钥类型推断签名算法。
+func loadKey(id, file string, activateAt time.Time) (*Key, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read key file %s", file)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.Errorf("no pem data found in key file %s", file)
+	}
+
+	var private any
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		private, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse key file %s", file)
+	}
+
+	method, err := signingMethod(private)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unsupported key file %s", file)
+	}
+
+	return &Key{
+		ID:         id,
+		Method:     method,
+		ActivateAt: activateAt,
+		private:    private,
+		public:     private.(crypto.Signer).Public(),
+	}, nil
+}
+
+func signingMethod(private any) (jwt.SigningMethod, error) {
+	switch k := private.(type) {
+	case *rsa.PrivateKey:
+		return jwt.SigningMethodRS256, nil
+	case *ecdsa.PrivateKey:
+		switch k.Curve {
+		case elliptic.P256():
+			return jwt.SigningMethodES256, nil
+		case elliptic.P384():
+			return jwt.SigningMethodES384, nil
+		case elliptic.P521():
+			return jwt.SigningMethodES512, nil
+		}
+		return nil, errors.Errorf("unsupported curve %s", k.Curve.Params().Name)
+	case ed25519.PrivateKey:
+		return jwt.SigningMethodEdDSA, nil
+	}
+
+	return nil, errors.Errorf("unsupported key type %T", private)
+}
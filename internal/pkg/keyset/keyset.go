@@ -0,0 +1,193 @@
+package keyset
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/eachinchung/errors"
+
+	"github.com/eachinchung/e-service/internal/pkg/options"
+)
+
+var (
+	// ErrUnknownKey 表示 token 使用的密钥不存在或已退役。
+	ErrUnknownKey = errors.New("unknown signing key")
+
+	// ErrInvalidSigningAlgorithm 表示 token 的签名算法与密钥不匹配。
+	ErrInvalidSigningAlgorithm = errors.New("invalid signing algorithm")
+)
+
+// KeySet 管理 jwt 的签名密钥。
+// 已启用的密钥中启用时间最晚的用于签名，旧密钥在下一个密钥启用 grace 时长后退役，
+// 退役前旧密钥仍然可以用于校验，尚未启用的密钥会提前发布到 JWKS 以便其它服务缓存。
+type KeySet struct {
+	keys  []*Key
+	grace time.Duration
+	now   func() time.Time
+}
+
+var (
+	ks   *KeySet
+	once sync.Once
+)
+
+// GetKeySetOr 根据配置创建 KeySet，没有配置非对称密钥时使用 secret 以 HS512 签名。
+func GetKeySetOr(opts *options.JWTKeyOptions, secret string) (*KeySet, error) {
+	var err error
+
+	once.Do(func() {
+		ks, err = New(opts, secret)
+	})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "获取 jwt 密钥失败")
+	}
+
+	return ks, nil
+}
+
+// Client 返回 KeySet 实例。
+func Client() *KeySet {
+	if ks == nil {
+		panic("jwt key set is not set")
+	}
+	return ks
+}
+
+// New 根据配置创建 KeySet。
+func New(opts *options.JWTKeyOptions, secret string) (*KeySet, error) {
+	specs, err := opts.Specs()
+	if err != nil {
+		return nil, err
+	}
+
+	set := &KeySet{grace: opts.GracePeriod, now: time.Now}
+	if len(specs) == 0 {
+		set.keys = []*Key{newHMACKey(secret)}
+		return set, nil
+	}
+
+	for _, spec := range specs {
+		key, err := loadKey(spec.ID, spec.File, spec.ActivateAt)
+		if err != nil {
+			return nil, err
+		}
+		set.keys = append(set.keys, key)
+	}
+
+	sort.SliceStable(set.keys, func(i, j int) bool {
+		return set.keys[i].ActivateAt.Before(set.keys[j].ActivateAt)
+	})
+
+	return set, nil
+}
+
+// SigningKey 返回当前用于签名的密钥。
+func (s *KeySet) SigningKey() (*Key, error) {
+	now := s.now()
+
+	for i := len(s.keys) - 1; i >= 0; i-- {
+		if !s.keys[i].ActivateAt.After(now) {
+			return s.keys[i], nil
+		}
+	}
+
+	return nil, errors.New("no active signing key")
+}
+
+// Sign 使用当前的签名密钥签发 token。
+func (s *KeySet) Sign(claims jwt.MapClaims) (string, error) {
+	key, err := s.SigningKey()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	if key.ID != "" {
+		token.Header["kid"] = key.ID
+	}
+
+	return token.SignedString(key.private)
+}
+
+// Parse 解析并校验 token，token 必须由未退役的密钥签发。
+func (s *KeySet) Parse(tokenString string) (*jwt.Token, error) {
+	return jwt.Parse(tokenString, s.keyFunc)
+}
+
+// JWKS 返回所有未退役的非对称密钥的公钥。
+func (s *KeySet) JWKS() *JWKS {
+	jwks := &JWKS{Keys: []JWK{}}
+
+	for _, key := range s.validKeys() {
+		if key.Symmetric() {
+			continue
+		}
+		jwks.Keys = append(jwks.Keys, newJWK(key))
+	}
+
+	return jwks
+}
+
+// Algorithms 返回所有未退役的密钥使用的签名算法。
+func (s *KeySet) Algorithms() []string {
+	var algs []string
+
+	for _, key := range s.validKeys() {
+		alg := key.Method.Alg()
+		if !contains(algs, alg) {
+			algs = append(algs, alg)
+		}
+	}
+
+	return algs
+}
+
+func (s *KeySet) keyFunc(t *jwt.Token) (any, error) {
+	kid, _ := t.Header["kid"].(string)
+
+	for _, key := range s.validKeys() {
+		if key.ID != kid {
+			continue
+		}
+
+		if key.ActivateAt.After(s.now()) {
+			return nil, ErrUnknownKey
+		}
+
+		if t.Method.Alg() != key.Method.Alg() {
+			return nil, ErrInvalidSigningAlgorithm
+		}
+
+		return key.public, nil
+	}
+
+	return nil, ErrUnknownKey
+}
+
+// validKeys 返回未退役的密钥，当下一个密钥已经启用超过 grace 时长时，密钥退役。
+func (s *KeySet) validKeys() []*Key {
+	now := s.now()
+	keys := make([]*Key, 0, len(s.keys))
+
+	for i, key := range s.keys {
+		if i+1 < len(s.keys) && !s.keys[i+1].ActivateAt.Add(s.grace).After(now) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+)
+
+// logSender 不会真正发送邮件，只把邮件内容写入日志或文件，用于开发与测试。
+type logSender struct {
+	mu   sync.Mutex
+	file string
+}
+
+var _ Sender = &logSender{}
+
+func newLogSender(file string) *logSender {
+	return &logSender{file: file}
+}
+
+func (s *logSender) Send(ctx context.Context, to, subject, body string) error {
+	if s.file == "" {
+		log.L(ctx).Infof("[MAIL] to: %s, subject: %s, body: %s", to, subject, body)
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return errors.Wrap(err, "failed to open mail log file")
+	}
+	//goland:noinspection GoUnhandledErrorResult
+	defer f.Close()
+
+	content := fmt.Sprintf("Date: %s\nTo: %s\nSubject: %s\n\n%s\n\n", time.Now().Format(time.RFC3339), to, subject, body)
+	if _, err := f.WriteString(content); err != nil {
+		return errors.Wrap(err, "failed to write mail log file")
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package mail
+
+import (
+	"context"
+	"sync"
+
+	"github.com/eachinchung/errors"
+
+	"github.com/eachinchung/e-service/internal/pkg/options"
+)
+
+// Sender 邮件发送器。
+type Sender interface {
+	// Send 向 to 发送一封纯文本邮件。
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+var (
+	sender Sender
+	once   sync.Once
+)
+
+// GetSenderOr 根据配置创建邮件发送器。
+func GetSenderOr(opts *options.MailOptions) (Sender, error) {
+	var err error
+
+	once.Do(func() {
+		switch opts.Driver {
+		case "smtp":
+			sender = newSMTPSender(opts)
+		case "log":
+			sender = newLogSender(opts.LogFile)
+		default:
+			err = errors.Errorf("unsupported mail driver: %s", opts.Driver)
+		}
+	})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "获取邮件发送器失败")
+	}
+
+	return sender, nil
+}
+
+// Client 返回邮件发送器实例。
+func Client() Sender {
+	if sender == nil {
+		panic("mail sender is not set")
+	}
+	return sender
+}
@@ -0,0 +1,61 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eachinchung/errors"
+
+	"github.com/eachinchung/e-service/internal/pkg/options"
+)
+
+// smtpSender 通过 SMTP 服务器发送邮件，服务器支持 STARTTLS 时自动启用。
+type smtpSender struct {
+	opts *options.MailOptions
+	addr string
+}
+
+var _ Sender = &smtpSender{}
+
+func newSMTPSender(opts *options.MailOptions) *smtpSender {
+	return &smtpSender{
+		opts: opts,
+		addr: net.JoinHostPort(opts.Host, strconv.Itoa(opts.Port)),
+	}
+}
+
+func (s *smtpSender) Send(_ context.Context, to, subject, body string) error {
+	if strings.ContainsAny(to, "\r\n") {
+		return errors.Errorf("invalid mail recipient: %q", to)
+	}
+
+	var auth smtp.Auth
+	if s.opts.Username != "" {
+		auth = smtp.PlainAuth("", s.opts.Username, s.opts.Password, s.opts.Host)
+	}
+
+	if err := smtp.SendMail(s.addr, auth, s.opts.From, []string{to}, s.message(to, subject, body)); err != nil {
+		return errors.Wrap(err, "failed to send mail")
+	}
+	return nil
+}
+
+// message 构造 RFC 5322 格式的邮件内容。
+func (s *smtpSender) message(to, subject, body string) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", s.opts.From)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return []byte(b.String())
+}
@@ -0,0 +1,50 @@
+package options
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// APIKeyOptions API key 配置选项
+type APIKeyOptions struct {
+	MaxKeysPerUser int64         `json:"max-keys-per-user" mapstructure:"max-keys-per-user"`
+	MaxExpire      time.Duration `json:"max-expire"        mapstructure:"max-expire"`
+	TouchInterval  time.Duration `json:"touch-interval"    mapstructure:"touch-interval"`
+}
+
+// NewAPIKeyOptions 创建一个带有默认参数的 APIKeyOptions 对象。
+func NewAPIKeyOptions() *APIKeyOptions {
+	return &APIKeyOptions{
+		MaxKeysPerUser: 20,
+		MaxExpire:      365 * 24 * time.Hour,
+		TouchInterval:  time.Minute,
+	}
+}
+
+// Validate 验证选项字段。
+func (s *APIKeyOptions) Validate() []error {
+	var errs []error
+
+	if s.MaxKeysPerUser < 1 {
+		errs = append(errs, fmt.Errorf("--api-key.max-keys-per-user 必须大于 0"))
+	}
+
+	if s.MaxExpire <= 0 {
+		errs = append(errs, fmt.Errorf("--api-key.max-expire 必须大于 0"))
+	}
+
+	return errs
+}
+
+// AddFlags 将 api-key 的各个字段追加到传入的 pflag.FlagSet 变量中。
+func (s *APIKeyOptions) AddFlags(fs *pflag.FlagSet) {
+	if fs == nil {
+		return
+	}
+
+	fs.Int64Var(&s.MaxKeysPerUser, "api-key.max-keys-per-user", s.MaxKeysPerUser, "每个用户最多可以拥有的有效 API key 数量")
+	fs.DurationVar(&s.MaxExpire, "api-key.max-expire", s.MaxExpire, "API key 的最长有效期")
+	fs.DurationVar(&s.TouchInterval, "api-key.touch-interval", s.TouchInterval, "更新 API key 最近使用时间的最小间隔")
+}
@@ -0,0 +1,62 @@
+package options
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// IDPOptions 第三方登录配置选项
+type IDPOptions struct {
+	RedirectURI     string        `json:"redirect-uri"      mapstructure:"redirect-uri"`
+	StateExpire     time.Duration `json:"state-expire"      mapstructure:"state-expire"`
+	TicketExpire    time.Duration `json:"ticket-expire"     mapstructure:"ticket-expire"`
+	Fake            bool          `json:"fake"              mapstructure:"fake"`
+	WeChatAppID     string        `json:"wechat-app-id"     mapstructure:"wechat-app-id"`
+	WeChatAppSecret string        `json:"wechat-app-secret" mapstructure:"wechat-app-secret"`
+}
+
+// NewIDPOptions 创建一个带有默认参数的 IDPOptions 对象。
+func NewIDPOptions() *IDPOptions {
+	return &IDPOptions{
+		RedirectURI:     "",
+		StateExpire:     10 * time.Minute,
+		TicketExpire:    10 * time.Minute,
+		Fake:            false,
+		WeChatAppID:     "",
+		WeChatAppSecret: "",
+	}
+}
+
+// Validate 验证选项字段。
+func (i *IDPOptions) Validate() []error {
+	var errs []error
+
+	if i.RedirectURI != "" {
+		if u, err := url.Parse(i.RedirectURI); err != nil || !u.IsAbs() {
+			errs = append(errs, fmt.Errorf("--idp.redirect-uri 必须是绝对地址, 当前为 %s", i.RedirectURI))
+		}
+	}
+
+	if (i.WeChatAppID == "") != (i.WeChatAppSecret == "") {
+		errs = append(errs, fmt.Errorf("--idp.wechat-app-id 与 --idp.wechat-app-secret 必须同时设置"))
+	}
+
+	return errs
+}
+
+// AddFlags 将 idp 的各个字段追加到传入的 pflag.FlagSet 变量中。
+func (i *IDPOptions) AddFlags(fs *pflag.FlagSet) {
+	if fs == nil {
+		return
+	}
+
+	fs.StringVar(&i.RedirectURI, "idp.redirect-uri", i.RedirectURI, "第三方登录完成后跳转回本站的地址，由前端页面把 code 与 state 提交给服务端")
+	fs.DurationVar(&i.StateExpire, "idp.state-expire", i.StateExpire, "第三方登录 state 的有效期")
+	fs.DurationVar(&i.TicketExpire, "idp.ticket-expire", i.TicketExpire, "第三方账号首次登录时，绑定或注册账号的凭证有效期")
+	fs.BoolVar(&i.Fake, "idp.fake", i.Fake, "启用 fake 第三方登录，code 即为第三方用户 ID，只能用于开发与测试")
+	fs.StringVar(&i.WeChatAppID, "idp.wechat-app-id", i.WeChatAppID, "微信开放平台网站应用的 AppID，为空时不启用微信登录")
+	fs.StringVar(&i.WeChatAppSecret, "idp.wechat-app-secret", i.WeChatAppSecret, "微信开放平台网站应用的 AppSecret")
+}
@@ -0,0 +1,40 @@
+package options
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// ImpersonationOptions 模拟登录配置选项
+type ImpersonationOptions struct {
+	Timeout time.Duration `json:"timeout" mapstructure:"timeout"`
+}
+
+// NewImpersonationOptions 创建一个带有默认参数的 ImpersonationOptions 对象。
+func NewImpersonationOptions() *ImpersonationOptions {
+	return &ImpersonationOptions{
+		Timeout: 15 * time.Minute,
+	}
+}
+
+// Validate 验证选项字段。
+func (i *ImpersonationOptions) Validate() []error {
+	var errs []error
+
+	if i.Timeout <= 0 || i.Timeout > time.Hour {
+		errs = append(errs, fmt.Errorf("--impersonation.timeout 必须大于 0 且不超过 1 小时"))
+	}
+
+	return errs
+}
+
+// AddFlags 将 impersonation 的各个字段追加到传入的 pflag.FlagSet 变量中。
+func (i *ImpersonationOptions) AddFlags(fs *pflag.FlagSet) {
+	if fs == nil {
+		return
+	}
+
+	fs.DurationVar(&i.Timeout, "impersonation.timeout", i.Timeout, "模拟登录 token 的有效期，模拟登录的 token 不能刷新")
+}
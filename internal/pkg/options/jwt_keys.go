@@ -0,0 +1,101 @@
+package options
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// JWTKeyOptions jwt 非对称签名密钥配置选项
+type JWTKeyOptions struct {
+	Keys        []string      `json:"keys"         mapstructure:"keys"`
+	GracePeriod time.Duration `json:"grace-period" mapstructure:"grace-period"`
+}
+
+// JWTKeySpec 描述一个签名密钥。
+type JWTKeySpec struct {
+	ID         string
+	File       string
+	ActivateAt time.Time
+}
+
+// NewJWTKeyOptions 创建一个带有默认参数的 JWTKeyOptions 对象。
+func NewJWTKeyOptions() *JWTKeyOptions {
+	return &JWTKeyOptions{
+		Keys:        []string{},
+		GracePeriod: 24 * time.Hour,
+	}
+}
+
+// Specs 解析签名密钥配置，格式为 kid:file[:activate-at]，activate-at 为 RFC3339 格式的启用时间。
+func (s *JWTKeyOptions) Specs() ([]JWTKeySpec, error) {
+	specs := make([]JWTKeySpec, 0, len(s.Keys))
+
+	for _, key := range s.Keys {
+		parts := strings.SplitN(key, ":", 3)
+		if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("--jwt-keys.keys %s 格式错误, 应为 kid:file[:activate-at]", key)
+		}
+
+		spec := JWTKeySpec{ID: parts[0], File: parts[1]}
+		if len(parts) == 3 {
+			activateAt, err := time.Parse(time.RFC3339, parts[2])
+			if err != nil {
+				return nil, fmt.Errorf("--jwt-keys.keys %s 的启用时间必须为 RFC3339 格式", key)
+			}
+			spec.ActivateAt = activateAt
+		}
+
+		specs = append(specs, spec)
+	}
+
+	return specs, nil
+}
+
+// Validate 验证选项字段。
+func (s *JWTKeyOptions) Validate() []error {
+	var errs []error
+
+	specs, err := s.Specs()
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	ids := map[string]bool{}
+	for _, spec := range specs {
+		if ids[spec.ID] {
+			errs = append(errs, fmt.Errorf("--jwt-keys.keys 存在重复的 kid: %s", spec.ID))
+		}
+		ids[spec.ID] = true
+	}
+
+	if s.GracePeriod < 0 {
+		errs = append(errs, fmt.Errorf("--jwt-keys.grace-period 不能小于 0"))
+	}
+
+	return errs
+}
+
+// AddFlags 将 jwt-keys 的各个字段追加到传入的 pflag.FlagSet 变量中。
+func (s *JWTKeyOptions) AddFlags(fs *pflag.FlagSet) {
+	if fs == nil {
+		return
+	}
+
+	fs.StringSliceVar(
+		&s.Keys,
+		"jwt-keys.keys",
+		s.Keys,
+		"jwt 签名私钥列表，格式为 kid:file[:activate-at]，支持 RSA、ECDSA 与 Ed25519 的 PEM 私钥，"+
+			"已启用的密钥中启用时间最晚的用于签名。为空时使用 --jwt.key 以 HS512 签名",
+	)
+
+	fs.DurationVar(
+		&s.GracePeriod,
+		"jwt-keys.grace-period",
+		s.GracePeriod,
+		"新密钥启用后，旧密钥继续用于校验的时长，应不小于 token 的有效期",
+	)
+}
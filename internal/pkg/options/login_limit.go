@@ -0,0 +1,59 @@
+package options
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// LoginLimitOptions 登录失败限制配置选项
+type LoginLimitOptions struct {
+	MaxUserFailures int64         `json:"max-user-failures" mapstructure:"max-user-failures"`
+	MaxIPFailures   int64         `json:"max-ip-failures"   mapstructure:"max-ip-failures"`
+	FailureWindow   time.Duration `json:"failure-window"    mapstructure:"failure-window"`
+	LockoutDuration time.Duration `json:"lockout-duration"  mapstructure:"lockout-duration"`
+	DelayStep       time.Duration `json:"delay-step"        mapstructure:"delay-step"`
+	MaxDelay        time.Duration `json:"max-delay"         mapstructure:"max-delay"`
+}
+
+// NewLoginLimitOptions 创建一个带有默认参数的 LoginLimitOptions 对象。
+func NewLoginLimitOptions() *LoginLimitOptions {
+	return &LoginLimitOptions{
+		MaxUserFailures: 5,
+		MaxIPFailures:   20,
+		FailureWindow:   15 * time.Minute,
+		LockoutDuration: 15 * time.Minute,
+		DelayStep:       500 * time.Millisecond,
+		MaxDelay:        5 * time.Second,
+	}
+}
+
+// Validate 验证选项字段。
+func (s *LoginLimitOptions) Validate() []error {
+	var errs []error
+
+	if s.MaxUserFailures < 1 {
+		errs = append(errs, fmt.Errorf("--login-limit.max-user-failures 必须大于 0"))
+	}
+
+	if s.MaxIPFailures < 1 {
+		errs = append(errs, fmt.Errorf("--login-limit.max-ip-failures 必须大于 0"))
+	}
+
+	return errs
+}
+
+// AddFlags 将 login-limit 的各个字段追加到传入的 pflag.FlagSet 变量中。
+func (s *LoginLimitOptions) AddFlags(fs *pflag.FlagSet) {
+	if fs == nil {
+		return
+	}
+
+	fs.Int64Var(&s.MaxUserFailures, "login-limit.max-user-failures", s.MaxUserFailures, "同一账号连续登录失败多少次后锁定")
+	fs.Int64Var(&s.MaxIPFailures, "login-limit.max-ip-failures", s.MaxIPFailures, "同一 IP 连续登录失败多少次后锁定")
+	fs.DurationVar(&s.FailureWindow, "login-limit.failure-window", s.FailureWindow, "登录失败次数的统计窗口")
+	fs.DurationVar(&s.LockoutDuration, "login-limit.lockout-duration", s.LockoutDuration, "账号或 IP 被锁定的时长")
+	fs.DurationVar(&s.DelayStep, "login-limit.delay-step", s.DelayStep, "每次登录失败后，下一次登录增加的延迟")
+	fs.DurationVar(&s.MaxDelay, "login-limit.max-delay", s.MaxDelay, "登录延迟的上限")
+}
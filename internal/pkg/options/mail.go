@@ -0,0 +1,80 @@
+package options
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// MailOptions 邮件验证码配置选项
+type MailOptions struct {
+	Driver            string        `json:"driver"              mapstructure:"driver"`
+	LogFile           string        `json:"log-file"            mapstructure:"log-file"`
+	Host              string        `json:"host"                mapstructure:"host"`
+	Port              int           `json:"port"                mapstructure:"port"`
+	Username          string        `json:"username"            mapstructure:"username"`
+	Password          string        `json:"password"            mapstructure:"password"`
+	From              string        `json:"from"                mapstructure:"from"`
+	CodeExpire        time.Duration `json:"code-expire"         mapstructure:"code-expire"`
+	SendInterval      time.Duration `json:"send-interval"       mapstructure:"send-interval"`
+	IPHourlyLimit     int64         `json:"ip-hourly-limit"     mapstructure:"ip-hourly-limit"`
+	MaxVerifyAttempts int64         `json:"max-verify-attempts" mapstructure:"max-verify-attempts"`
+}
+
+// NewMailOptions 创建一个带有默认参数的 MailOptions 对象。
+func NewMailOptions() *MailOptions {
+	return &MailOptions{
+		Driver:            "log",
+		LogFile:           "",
+		Host:              "127.0.0.1",
+		Port:              25,
+		Username:          "",
+		Password:          "",
+		From:              "",
+		CodeExpire:        30 * time.Minute,
+		SendInterval:      time.Minute,
+		IPHourlyLimit:     30,
+		MaxVerifyAttempts: 5,
+	}
+}
+
+// Validate 验证选项字段。
+func (m *MailOptions) Validate() []error {
+	var errs []error
+
+	switch m.Driver {
+	case "log":
+	case "smtp":
+		if m.Host == "" || m.From == "" {
+			errs = append(errs, fmt.Errorf("使用 smtp 发送邮件时 --mail.host 与 --mail.from 不能为空"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("--mail.driver 只支持 smtp 和 log, 当前为 %s", m.Driver))
+	}
+
+	if m.MaxVerifyAttempts < 1 {
+		errs = append(errs, fmt.Errorf("--mail.max-verify-attempts 必须大于 0"))
+	}
+
+	return errs
+}
+
+// AddFlags 将 mail 的各个字段追加到传入的 pflag.FlagSet 变量中。
+func (m *MailOptions) AddFlags(fs *pflag.FlagSet) {
+	if fs == nil {
+		return
+	}
+
+	fs.StringVar(&m.Driver, "mail.driver", m.Driver, "邮件发送器，支持: smtp, log。log 只会把邮件写入日志或文件，用于开发与测试")
+	fs.StringVar(&m.LogFile, "mail.log-file", m.LogFile, "log 发送器写入邮件的文件，为空时写入日志")
+	fs.StringVar(&m.Host, "mail.host", m.Host, "SMTP 服务器地址")
+	fs.IntVar(&m.Port, "mail.port", m.Port, "SMTP 服务器端口")
+	fs.StringVar(&m.Username, "mail.username", m.Username, "SMTP 用户名，为空时不进行认证")
+	fs.StringVar(&m.Password, "mail.password", m.Password, "SMTP 密码")
+	fs.StringVar(&m.From, "mail.from", m.From, "发件人地址")
+	fs.DurationVar(&m.CodeExpire, "mail.code-expire", m.CodeExpire, "邮件验证码有效期")
+	fs.DurationVar(&m.SendInterval, "mail.send-interval", m.SendInterval, "同一邮箱两次发送验证码的最短间隔")
+	fs.Int64Var(&m.IPHourlyLimit, "mail.ip-hourly-limit", m.IPHourlyLimit, "同一 IP 每小时最多发送的邮件验证码数量")
+	fs.Int64Var(&m.MaxVerifyAttempts, "mail.max-verify-attempts", m.MaxVerifyAttempts, "同一个邮件验证码最多允许校验的次数")
+}
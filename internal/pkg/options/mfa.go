@@ -0,0 +1,67 @@
+package options
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// MFAOptions 两步验证配置选项
+type MFAOptions struct {
+	Issuer               string        `json:"issuer"                 mapstructure:"issuer"`
+	Skew                 int           `json:"skew"                   mapstructure:"skew"`
+	RecoveryCodes        int           `json:"recovery-codes"         mapstructure:"recovery-codes"`
+	ChallengeExpire      time.Duration `json:"challenge-expire"       mapstructure:"challenge-expire"`
+	MaxChallengeAttempts int64         `json:"max-challenge-attempts" mapstructure:"max-challenge-attempts"`
+	EnforceSuperUsers    bool          `json:"enforce-super-users"    mapstructure:"enforce-super-users"`
+}
+
+// NewMFAOptions 创建一个带有默认参数的 MFAOptions 对象。
+func NewMFAOptions() *MFAOptions {
+	return &MFAOptions{
+		Issuer:               "Eachin",
+		Skew:                 1,
+		RecoveryCodes:        10,
+		ChallengeExpire:      5 * time.Minute,
+		MaxChallengeAttempts: 5,
+		EnforceSuperUsers:    false,
+	}
+}
+
+// Validate 验证选项字段。
+func (s *MFAOptions) Validate() []error {
+	var errs []error
+
+	if s.Issuer == "" {
+		errs = append(errs, fmt.Errorf("--mfa.issuer 不能为空"))
+	}
+
+	if s.Skew < 0 {
+		errs = append(errs, fmt.Errorf("--mfa.skew 不能小于 0"))
+	}
+
+	if s.RecoveryCodes < 1 {
+		errs = append(errs, fmt.Errorf("--mfa.recovery-codes 必须大于 0"))
+	}
+
+	if s.MaxChallengeAttempts < 1 {
+		errs = append(errs, fmt.Errorf("--mfa.max-challenge-attempts 必须大于 0"))
+	}
+
+	return errs
+}
+
+// AddFlags 将 mfa 的各个字段追加到传入的 pflag.FlagSet 变量中。
+func (s *MFAOptions) AddFlags(fs *pflag.FlagSet) {
+	if fs == nil {
+		return
+	}
+
+	fs.StringVar(&s.Issuer, "mfa.issuer", s.Issuer, "身份验证器中展示的发行方名称")
+	fs.IntVar(&s.Skew, "mfa.skew", s.Skew, "校验动态验证码时允许前后偏差的时间步数")
+	fs.IntVar(&s.RecoveryCodes, "mfa.recovery-codes", s.RecoveryCodes, "每次生成的恢复码数量")
+	fs.DurationVar(&s.ChallengeExpire, "mfa.challenge-expire", s.ChallengeExpire, "登录时两步验证挑战的有效期")
+	fs.Int64Var(&s.MaxChallengeAttempts, "mfa.max-challenge-attempts", s.MaxChallengeAttempts, "每个两步验证挑战允许的最大尝试次数")
+	fs.BoolVar(&s.EnforceSuperUsers, "mfa.enforce-super-users", s.EnforceSuperUsers, "是否强制超级管理员启用两步验证")
+}
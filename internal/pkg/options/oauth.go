@@ -0,0 +1,114 @@
+package options
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// OAuthOptions OAuth 2.0 授权服务配置选项
+type OAuthOptions struct {
+	Issuer                string        `json:"issuer"                  mapstructure:"issuer"`
+	AuthorizationEndpoint string        `json:"authorization-endpoint"  mapstructure:"authorization-endpoint"`
+	CodeExpire            time.Duration `json:"code-expire"             mapstructure:"code-expire"`
+	Scopes                []string      `json:"scopes"                  mapstructure:"scopes"`
+	IntrospectionCache    time.Duration `json:"introspection-cache"     mapstructure:"introspection-cache"`
+	DeviceClients         []string      `json:"device-clients"          mapstructure:"device-clients"`
+	DeviceCodeExpire      time.Duration `json:"device-code-expire"      mapstructure:"device-code-expire"`
+	DevicePollInterval    time.Duration `json:"device-poll-interval"    mapstructure:"device-poll-interval"`
+	DeviceVerificationURI string        `json:"device-verification-uri" mapstructure:"device-verification-uri"`
+}
+
+// NewOAuthOptions 创建一个带有默认参数的 OAuthOptions 对象。
+func NewOAuthOptions() *OAuthOptions {
+	return &OAuthOptions{
+		Issuer:                "",
+		AuthorizationEndpoint: "",
+		CodeExpire:            10 * time.Minute,
+		Scopes:                []string{"profile", "phone"},
+		IntrospectionCache:    10 * time.Second,
+		DeviceClients:         []string{},
+		DeviceCodeExpire:      10 * time.Minute,
+		DevicePollInterval:    5 * time.Second,
+		DeviceVerificationURI: "",
+	}
+}
+
+// OpenIDEnabled 返回是否启用了 OpenID Connect。
+func (s *OAuthOptions) OpenIDEnabled() bool {
+	for _, scope := range s.Scopes {
+		if scope == "openid" {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate 验证选项字段。
+func (s *OAuthOptions) Validate() []error {
+	var errs []error
+
+	if s.Issuer != "" && !strings.HasPrefix(s.Issuer, "https://") {
+		errs = append(errs, fmt.Errorf("--oauth.issuer 必须以 https:// 开头"))
+	}
+
+	if s.CodeExpire <= 0 || s.CodeExpire > 10*time.Minute {
+		errs = append(errs, fmt.Errorf("--oauth.code-expire 必须大于 0 且不超过 10 分钟"))
+	}
+
+	if len(s.Scopes) == 0 {
+		errs = append(errs, fmt.Errorf("--oauth.scopes 不能为空"))
+	}
+
+	if s.IntrospectionCache < 0 || s.IntrospectionCache > time.Minute {
+		errs = append(errs, fmt.Errorf("--oauth.introspection-cache 不能小于 0 且不超过 1 分钟"))
+	}
+
+	if s.DeviceCodeExpire <= 0 || s.DeviceCodeExpire > 30*time.Minute {
+		errs = append(errs, fmt.Errorf("--oauth.device-code-expire 必须大于 0 且不超过 30 分钟"))
+	}
+
+	if s.DevicePollInterval < time.Second || s.DevicePollInterval > time.Minute {
+		errs = append(errs, fmt.Errorf("--oauth.device-poll-interval 不能小于 1 秒且不超过 1 分钟"))
+	}
+
+	if s.DeviceVerificationURI != "" && !strings.HasPrefix(s.DeviceVerificationURI, "https://") {
+		errs = append(errs, fmt.Errorf("--oauth.device-verification-uri 必须以 https:// 开头"))
+	}
+
+	return errs
+}
+
+// AddFlags 将 oauth 的各个字段追加到传入的 pflag.FlagSet 变量中。
+func (s *OAuthOptions) AddFlags(fs *pflag.FlagSet) {
+	if fs == nil {
+		return
+	}
+
+	fs.StringVar(&s.Issuer, "oauth.issuer", s.Issuer, "OpenID Connect 的签发者地址，为空时使用 https:// 加上 api 的域名")
+	fs.StringVar(&s.AuthorizationEndpoint, "oauth.authorization-endpoint", s.AuthorizationEndpoint, "展示授权页面的前端地址，为空时使用签发者地址加上 /oauth/authorize")
+	fs.DurationVar(&s.CodeExpire, "oauth.code-expire", s.CodeExpire, "授权码的有效期")
+	fs.StringSliceVar(
+		&s.Scopes,
+		"oauth.scopes",
+		s.Scopes,
+		"第三方应用可以申请的权限，包含 openid 时启用 OpenID Connect，需要通过 --jwt-keys.keys 配置非对称签名密钥",
+	)
+	fs.DurationVar(
+		&s.IntrospectionCache,
+		"oauth.introspection-cache",
+		s.IntrospectionCache,
+		"token 自省结果的缓存时间，缓存期间 token 被吊销或用户被禁用不会立即生效，为 0 时不缓存",
+	)
+	fs.StringSliceVar(&s.DeviceClients, "oauth.device-clients", s.DeviceClients, "允许使用设备授权的自有应用，例如命令行工具与电视应用")
+	fs.DurationVar(&s.DeviceCodeExpire, "oauth.device-code-expire", s.DeviceCodeExpire, "设备码与用户码的有效期")
+	fs.DurationVar(&s.DevicePollInterval, "oauth.device-poll-interval", s.DevicePollInterval, "设备轮询 token 端点的最小间隔")
+	fs.StringVar(
+		&s.DeviceVerificationURI,
+		"oauth.device-verification-uri",
+		s.DeviceVerificationURI,
+		"用户输入用户码的前端地址，为空时使用签发者地址加上 /device",
+	)
+}
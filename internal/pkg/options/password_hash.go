@@ -0,0 +1,81 @@
+package options
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+)
+
+// PasswordHashOptions 密码哈希配置选项
+type PasswordHashOptions struct {
+	Algorithm         string `json:"algorithm"          mapstructure:"algorithm"`
+	BcryptCost        int    `json:"bcrypt-cost"        mapstructure:"bcrypt-cost"`
+	Argon2Memory      uint32 `json:"argon2-memory"      mapstructure:"argon2-memory"`
+	Argon2Iterations  uint32 `json:"argon2-iterations"  mapstructure:"argon2-iterations"`
+	Argon2Parallelism uint8  `json:"argon2-parallelism" mapstructure:"argon2-parallelism"`
+	Argon2SaltLength  uint32 `json:"argon2-salt-length" mapstructure:"argon2-salt-length"`
+	Argon2KeyLength   uint32 `json:"argon2-key-length"  mapstructure:"argon2-key-length"`
+}
+
+// NewPasswordHashOptions 创建一个带有默认参数的 PasswordHashOptions 对象。
+func NewPasswordHashOptions() *PasswordHashOptions {
+	return &PasswordHashOptions{
+		Algorithm:         "bcrypt",
+		BcryptCost:        10,
+		Argon2Memory:      64 * 1024,
+		Argon2Iterations:  3,
+		Argon2Parallelism: 2,
+		Argon2SaltLength:  16,
+		Argon2KeyLength:   32,
+	}
+}
+
+// Validate 验证选项字段。
+func (s *PasswordHashOptions) Validate() []error {
+	var errs []error
+
+	if s.Algorithm != "bcrypt" && s.Algorithm != "argon2id" {
+		errs = append(errs, fmt.Errorf("--password-hash.algorithm 只支持 bcrypt 和 argon2id, 当前为 %s", s.Algorithm))
+	}
+
+	if s.BcryptCost < 4 || s.BcryptCost > 31 {
+		errs = append(errs, fmt.Errorf("--password-hash.bcrypt-cost 必须在 4 到 31 之间"))
+	}
+
+	if s.Argon2Iterations < 1 {
+		errs = append(errs, fmt.Errorf("--password-hash.argon2-iterations 必须大于 0"))
+	}
+
+	if s.Argon2Parallelism < 1 {
+		errs = append(errs, fmt.Errorf("--password-hash.argon2-parallelism 必须大于 0"))
+	}
+
+	if s.Argon2Memory < 8*uint32(s.Argon2Parallelism) {
+		errs = append(errs, fmt.Errorf("--password-hash.argon2-memory 不能小于 argon2-parallelism 的 8 倍"))
+	}
+
+	if s.Argon2SaltLength < 8 {
+		errs = append(errs, fmt.Errorf("--password-hash.argon2-salt-length 不能小于 8"))
+	}
+
+	if s.Argon2KeyLength < 16 {
+		errs = append(errs, fmt.Errorf("--password-hash.argon2-key-length 不能小于 16"))
+	}
+
+	return errs
+}
+
+// AddFlags 将 password-hash 的各个字段追加到传入的 pflag.FlagSet 变量中。
+func (s *PasswordHashOptions) AddFlags(fs *pflag.FlagSet) {
+	if fs == nil {
+		return
+	}
+
+	fs.StringVar(&s.Algorithm, "password-hash.algorithm", s.Algorithm, "新密码使用的哈希算法，支持: bcrypt, argon2id。使用其它算法或参数的旧哈希会在登录时重新计算")
+	fs.IntVar(&s.BcryptCost, "password-hash.bcrypt-cost", s.BcryptCost, "bcrypt 的 cost")
+	fs.Uint32Var(&s.Argon2Memory, "password-hash.argon2-memory", s.Argon2Memory, "argon2id 使用的内存，单位 KiB")
+	fs.Uint32Var(&s.Argon2Iterations, "password-hash.argon2-iterations", s.Argon2Iterations, "argon2id 的迭代次数")
+	fs.Uint8Var(&s.Argon2Parallelism, "password-hash.argon2-parallelism", s.Argon2Parallelism, "argon2id 的并行度")
+	fs.Uint32Var(&s.Argon2SaltLength, "password-hash.argon2-salt-length", s.Argon2SaltLength, "argon2id 的盐长度，单位字节")
+	fs.Uint32Var(&s.Argon2KeyLength, "password-hash.argon2-key-length", s.Argon2KeyLength, "argon2id 的哈希长度，单位字节")
+}
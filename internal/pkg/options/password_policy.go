@@ -0,0 +1,64 @@
+package options
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// PasswordPolicyOptions 密码策略配置选项
+type PasswordPolicyOptions struct {
+	MinLength    int           `json:"min-length"    mapstructure:"min-length"`
+	MinStrength  int           `json:"min-strength"  mapstructure:"min-strength"`
+	BreachedList string        `json:"breached-list" mapstructure:"breached-list"`
+	History      int           `json:"history"       mapstructure:"history"`
+	MaxAge       time.Duration `json:"max-age"       mapstructure:"max-age"`
+}
+
+// NewPasswordPolicyOptions 创建一个带有默认参数的 PasswordPolicyOptions 对象。
+func NewPasswordPolicyOptions() *PasswordPolicyOptions {
+	return &PasswordPolicyOptions{
+		MinLength:    8,
+		MinStrength:  2,
+		BreachedList: "",
+		History:      5,
+		MaxAge:       0,
+	}
+}
+
+// Validate 验证选项字段。
+func (s *PasswordPolicyOptions) Validate() []error {
+	var errs []error
+
+	if s.MinLength < 6 {
+		errs = append(errs, fmt.Errorf("--password-policy.min-length 不能小于 6"))
+	}
+
+	if s.MinStrength < 0 || s.MinStrength > 4 {
+		errs = append(errs, fmt.Errorf("--password-policy.min-strength 必须在 0 到 4 之间"))
+	}
+
+	if s.History < 0 {
+		errs = append(errs, fmt.Errorf("--password-policy.history 不能小于 0"))
+	}
+
+	if s.MaxAge < 0 {
+		errs = append(errs, fmt.Errorf("--password-policy.max-age 不能小于 0"))
+	}
+
+	return errs
+}
+
+// AddFlags 将 password-policy 的各个字段追加到传入的 pflag.FlagSet 变量中。
+func (s *PasswordPolicyOptions) AddFlags(fs *pflag.FlagSet) {
+	if fs == nil {
+		return
+	}
+
+	fs.IntVar(&s.MinLength, "password-policy.min-length", s.MinLength, "密码的最小长度")
+	fs.IntVar(&s.MinStrength, "password-policy.min-strength", s.MinStrength, "密码的最低强度评分，取值 0 到 4")
+	fs.StringVar(&s.BreachedList, "password-policy.breached-list", s.BreachedList, "已泄露密码列表文件，每行一个密码，与内置的常见密码列表一起使用")
+	fs.IntVar(&s.History, "password-policy.history", s.History, "新密码不能与最近使用过的多少个密码相同，为 0 时不限制")
+	fs.DurationVar(&s.MaxAge, "password-policy.max-age", s.MaxAge, "密码的最长使用时间，超过后登录时必须修改密码，为 0 时不限制")
+}
@@ -0,0 +1,47 @@
+package options
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// PasswordResetOptions 找回密码配置选项
+type PasswordResetOptions struct {
+	TicketExpire  time.Duration `json:"ticket-expire"   mapstructure:"ticket-expire"`
+	IPHourlyLimit int64         `json:"ip-hourly-limit" mapstructure:"ip-hourly-limit"`
+}
+
+// NewPasswordResetOptions 创建一个带有默认参数的 PasswordResetOptions 对象。
+func NewPasswordResetOptions() *PasswordResetOptions {
+	return &PasswordResetOptions{
+		TicketExpire:  10 * time.Minute,
+		IPHourlyLimit: 20,
+	}
+}
+
+// Validate 验证选项字段。
+func (s *PasswordResetOptions) Validate() []error {
+	var errs []error
+
+	if s.TicketExpire <= 0 {
+		errs = append(errs, fmt.Errorf("--password-reset.ticket-expire 必须大于 0"))
+	}
+
+	if s.IPHourlyLimit < 1 {
+		errs = append(errs, fmt.Errorf("--password-reset.ip-hourly-limit 必须大于 0"))
+	}
+
+	return errs
+}
+
+// AddFlags 将 password-reset 的各个字段追加到传入的 pflag.FlagSet 变量中。
+func (s *PasswordResetOptions) AddFlags(fs *pflag.FlagSet) {
+	if fs == nil {
+		return
+	}
+
+	fs.DurationVar(&s.TicketExpire, "password-reset.ticket-expire", s.TicketExpire, "重置密码凭证的有效期")
+	fs.Int64Var(&s.IPHourlyLimit, "password-reset.ip-hourly-limit", s.IPHourlyLimit, "同一 IP 每小时最多校验找回密码验证码与重置密码的次数")
+}
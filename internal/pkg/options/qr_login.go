@@ -0,0 +1,54 @@
+package options
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// QRLoginOptions 扫码登录配置选项
+type QRLoginOptions struct {
+	Expire       time.Duration `json:"expire"        mapstructure:"expire"`
+	PollTimeout  time.Duration `json:"poll-timeout"  mapstructure:"poll-timeout"`
+	PollInterval time.Duration `json:"poll-interval" mapstructure:"poll-interval"`
+}
+
+// NewQRLoginOptions 创建一个带有默认参数的 QRLoginOptions 对象。
+func NewQRLoginOptions() *QRLoginOptions {
+	return &QRLoginOptions{
+		Expire:       2 * time.Minute,
+		PollTimeout:  25 * time.Second,
+		PollInterval: 500 * time.Millisecond,
+	}
+}
+
+// Validate 验证选项字段。
+func (q *QRLoginOptions) Validate() []error {
+	var errs []error
+
+	if q.Expire <= 0 {
+		errs = append(errs, fmt.Errorf("--qr-login.expire 必须大于 0"))
+	}
+
+	if q.PollTimeout <= 0 || q.PollTimeout > time.Minute {
+		errs = append(errs, fmt.Errorf("--qr-login.poll-timeout 必须大于 0 且不超过 1 分钟"))
+	}
+
+	if q.PollInterval < 100*time.Millisecond || q.PollInterval > q.PollTimeout {
+		errs = append(errs, fmt.Errorf("--qr-login.poll-interval 不能小于 100ms 且不能超过 --qr-login.poll-timeout"))
+	}
+
+	return errs
+}
+
+// AddFlags 将 qr-login 的各个字段追加到传入的 pflag.FlagSet 变量中。
+func (q *QRLoginOptions) AddFlags(fs *pflag.FlagSet) {
+	if fs == nil {
+		return
+	}
+
+	fs.DurationVar(&q.Expire, "qr-login.expire", q.Expire, "登录二维码的有效期")
+	fs.DurationVar(&q.PollTimeout, "qr-login.poll-timeout", q.PollTimeout, "浏览器查询二维码状态时，状态没有变化的最长等待时间")
+	fs.DurationVar(&q.PollInterval, "qr-login.poll-interval", q.PollInterval, "等待期间检查二维码状态的间隔")
+}
@@ -0,0 +1,62 @@
+package options
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// SMSOptions 短信验证码配置选项
+type SMSOptions struct {
+	Driver            string        `json:"driver"              mapstructure:"driver"`
+	LogFile           string        `json:"log-file"            mapstructure:"log-file"`
+	CodeExpire        time.Duration `json:"code-expire"         mapstructure:"code-expire"`
+	SendInterval      time.Duration `json:"send-interval"       mapstructure:"send-interval"`
+	PhoneDailyLimit   int64         `json:"phone-daily-limit"   mapstructure:"phone-daily-limit"`
+	IPHourlyLimit     int64         `json:"ip-hourly-limit"     mapstructure:"ip-hourly-limit"`
+	MaxVerifyAttempts int64         `json:"max-verify-attempts" mapstructure:"max-verify-attempts"`
+}
+
+// NewSMSOptions 创建一个带有默认参数的 SMSOptions 对象。
+func NewSMSOptions() *SMSOptions {
+	return &SMSOptions{
+		Driver:            "log",
+		LogFile:           "",
+		CodeExpire:        5 * time.Minute,
+		SendInterval:      time.Minute,
+		PhoneDailyLimit:   10,
+		IPHourlyLimit:     30,
+		MaxVerifyAttempts: 5,
+	}
+}
+
+// Validate 验证选项字段。
+func (s *SMSOptions) Validate() []error {
+	var errs []error
+
+	if s.Driver != "tencent" && s.Driver != "log" {
+		errs = append(errs, fmt.Errorf("--sms.driver 只支持 tencent 和 log, 当前为 %s", s.Driver))
+	}
+
+	if s.MaxVerifyAttempts < 1 {
+		errs = append(errs, fmt.Errorf("--sms.max-verify-attempts 必须大于 0"))
+	}
+
+	return errs
+}
+
+// AddFlags 将 sms 的各个字段追加到传入的 pflag.FlagSet 变量中。
+func (s *SMSOptions) AddFlags(fs *pflag.FlagSet) {
+	if fs == nil {
+		return
+	}
+
+	fs.StringVar(&s.Driver, "sms.driver", s.Driver, "短信发送器，支持: tencent, log。log 只会把短信写入日志或文件，用于开发与测试")
+	fs.StringVar(&s.LogFile, "sms.log-file", s.LogFile, "log 发送器写入短信的文件，为空时写入日志")
+	fs.DurationVar(&s.CodeExpire, "sms.code-expire", s.CodeExpire, "短信验证码有效期")
+	fs.DurationVar(&s.SendInterval, "sms.send-interval", s.SendInterval, "同一手机号两次发送验证码的最短间隔")
+	fs.Int64Var(&s.PhoneDailyLimit, "sms.phone-daily-limit", s.PhoneDailyLimit, "同一手机号每天最多发送的验证码数量")
+	fs.Int64Var(&s.IPHourlyLimit, "sms.ip-hourly-limit", s.IPHourlyLimit, "同一 IP 每小时最多发送的验证码数量")
+	fs.Int64Var(&s.MaxVerifyAttempts, "sms.max-verify-attempts", s.MaxVerifyAttempts, "同一个验证码最多允许校验的次数")
+}
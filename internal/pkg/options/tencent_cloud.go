@@ -4,10 +4,16 @@ import (
 	"github.com/spf13/pflag"
 )
 
-// TencentCloudOptions jwt 配置选项
+// TencentCloudOptions 腾讯云配置选项
 type TencentCloudOptions struct {
 	CaptchaAppID        string `json:"captcha-app-id"          mapstructure:"captcha-app-id"`
 	CaptchaAppSecretKey string `json:"captcha-app-secret-key"  mapstructure:"captcha-app-secret-key"`
+	SecretID            string `json:"secret-id"               mapstructure:"secret-id"`
+	SecretKey           string `json:"secret-key"              mapstructure:"secret-key"`
+	SMSRegion           string `json:"sms-region"              mapstructure:"sms-region"`
+	SMSSdkAppID         string `json:"sms-sdk-app-id"          mapstructure:"sms-sdk-app-id"`
+	SMSSignName         string `json:"sms-sign-name"           mapstructure:"sms-sign-name"`
+	SMSCodeTemplateID   string `json:"sms-code-template-id"    mapstructure:"sms-code-template-id"`
 }
 
 // NewTencentCloudOptions 创建一个带有默认参数的 TencentCloudOptions 对象。
@@ -15,6 +21,7 @@ func NewTencentCloudOptions() *TencentCloudOptions {
 	return &TencentCloudOptions{
 		CaptchaAppID:        "CaptchaAppID",
 		CaptchaAppSecretKey: "CaptchaAppSecretKey",
+		SMSRegion:           "ap-guangzhou",
 	}
 }
 
@@ -41,4 +48,15 @@ func (s *TencentCloudOptions) AddFlags(fs *pflag.FlagSet) {
 		s.CaptchaAppSecretKey,
 		"验证码应用密钥",
 	)
+	fs.StringVar(&s.SecretID, "tencent-cloud.secret-id", s.SecretID, "腾讯云 API 密钥 ID")
+	fs.StringVar(&s.SecretKey, "tencent-cloud.secret-key", s.SecretKey, "腾讯云 API 密钥")
+	fs.StringVar(&s.SMSRegion, "tencent-cloud.sms-region", s.SMSRegion, "短信服务地域")
+	fs.StringVar(&s.SMSSdkAppID, "tencent-cloud.sms-sdk-app-id", s.SMSSdkAppID, "短信应用ID")
+	fs.StringVar(&s.SMSSignName, "tencent-cloud.sms-sign-name", s.SMSSignName, "短信签名内容")
+	fs.StringVar(
+		&s.SMSCodeTemplateID,
+		"tencent-cloud.sms-code-template-id",
+		s.SMSCodeTemplateID,
+		"验证码短信模板ID，模板参数依次为验证码与有效分钟数",
+	)
 }
@@ -0,0 +1,159 @@
+package pwdpolicy
+
+import (
+	"bufio"
+	_ "embed"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/eachinchung/component-base/verification"
+	"github.com/eachinchung/errors"
+
+	"github.com/eachinchung/e-service/internal/pkg/options"
+)
+
+// 违反密码策略的规则，同时也是校验失败时提示信息的翻译键。
+const (
+	RuleMinLength = "password_min_length"
+	RulePower     = "password"
+	RuleBreached  = "password_breached"
+	RuleStrength  = "password_strength"
+	RuleReused    = "password_reused"
+)
+
+//go:embed common.txt
+var commonPasswords string
+
+// Violation 表示密码违反了密码策略，Param 为提示信息中的参数。
+type Violation struct {
+	Rule  string
+	Param string
+}
+
+func (v *Violation) Error() string {
+	return "password violates rule " + v.Rule
+}
+
+// Policy 密码策略，校验密码长度、字符种类、强度评分，并拒绝常见或已泄露的密码。
+// 密码历史与密码有效期依赖用户数据，由调用方使用 History 与 Expired 实现。
+type Policy struct {
+	minLength   int
+	minStrength int
+	breached    map[string]struct{}
+	history     int
+	maxAge      time.Duration
+}
+
+var (
+	p    *Policy
+	once sync.Once
+)
+
+// GetPolicyOr 根据配置创建密码策略。
+func GetPolicyOr(opts *options.PasswordPolicyOptions) (*Policy, error) {
+	var err error
+
+	once.Do(func() {
+		p, err = New(opts)
+	})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "获取密码策略失败")
+	}
+
+	return p, nil
+}
+
+// Client 返回密码策略实例。
+func Client() *Policy {
+	if p == nil {
+		panic("password policy is not set")
+	}
+	return p
+}
+
+// New 根据配置创建密码策略，breached list 与内置的常见密码列表合并使用。
+func New(opts *options.PasswordPolicyOptions) (*Policy, error) {
+	policy := &Policy{
+		minLength:   opts.MinLength,
+		minStrength: opts.MinStrength,
+		breached:    map[string]struct{}{},
+		history:     opts.History,
+		maxAge:      opts.MaxAge,
+	}
+
+	if err := policy.loadList(strings.NewReader(commonPasswords)); err != nil {
+		return nil, err
+	}
+
+	if opts.BreachedList != "" {
+		f, err := os.Open(opts.BreachedList)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to open breached password list")
+		}
+		defer f.Close()
+
+		if err := policy.loadList(f); err != nil {
+			return nil, err
+		}
+	}
+
+	return policy, nil
+}
+
+// Check 校验密码是否符合密码策略，不符合时返回违反的第一条规则。
+func (p *Policy) Check(password string) *Violation {
+	if utf8.RuneCountInString(password) < p.minLength {
+		return &Violation{Rule: RuleMinLength, Param: strconv.Itoa(p.minLength)}
+	}
+
+	if !verification.PasswordPower(password) {
+		return &Violation{Rule: RulePower}
+	}
+
+	if _, ok := p.breached[strings.ToLower(password)]; ok {
+		return &Violation{Rule: RuleBreached}
+	}
+
+	if Strength(password) < p.minStrength {
+		return &Violation{Rule: RuleStrength}
+	}
+
+	return nil
+}
+
+// History 返回新密码不能与之相同的最近使用过的密码数量，为 0 时不限制。
+func (p *Policy) History() int {
+	return p.history
+}
+
+// Expired 判断在 changedAt 设置的密码是否已经超过最长使用时间，没有记录设置时间时不视为过期。
+func (p *Policy) Expired(changedAt time.Time) bool {
+	return p.maxAge > 0 && !changedAt.IsZero() && time.Since(changedAt) > p.maxAge
+}
+
+// Reused 返回新密码与最近使用过的密码相同时的违规信息。
+func (p *Policy) Reused() *Violation {
+	return &Violation{Rule: RuleReused, Param: strconv.Itoa(p.history)}
+}
+
+func (p *Policy) loadList(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p.breached[strings.ToLower(line)] = struct{}{}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return errors.Wrap(err, "failed to read password list")
+	}
+	return nil
+}
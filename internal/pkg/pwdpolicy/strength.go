@@ -0,0 +1,69 @@
+package pwdpolicy
+
+import (
+	"math"
+	"unicode"
+)
+
+// 强度评分对应的熵阈值，单位 bit。
+var strengthThresholds = []float64{28, 36, 60, 128}
+
+// Strength 根据密码的估算熵返回 0 到 4 的强度评分。
+// 熵按字符集大小与有效长度估算，连续重复或连续递增、递减的字符只计一半长度。
+func Strength(password string) int {
+	entropy := Entropy(password)
+
+	score := 0
+	for _, threshold := range strengthThresholds {
+		if entropy < threshold {
+			break
+		}
+		score++
+	}
+	return score
+}
+
+// Entropy 估算密码的熵。
+func Entropy(password string) float64 {
+	runes := []rune(password)
+	if len(runes) == 0 {
+		return 0
+	}
+
+	var lower, upper, digit, symbol, other bool
+	for _, r := range runes {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		case r < unicode.MaxASCII:
+			symbol = true
+		default:
+			other = true
+		}
+	}
+
+	pool := 0
+	for _, c := range []struct {
+		used bool
+		size int
+	}{{lower, 26}, {upper, 26}, {digit, 10}, {symbol, 33}, {other, 100}} {
+		if c.used {
+			pool += c.size
+		}
+	}
+
+	length := 1.0
+	for i := 1; i < len(runes); i++ {
+		if diff := runes[i] - runes[i-1]; diff >= -1 && diff <= 1 {
+			length += 0.5
+			continue
+		}
+		length++
+	}
+
+	return length * math.Log2(float64(pool))
+}
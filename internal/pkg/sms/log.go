@@ -0,0 +1,47 @@
+package sms
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/eachinchung/errors"
+	"github.com/eachinchung/log"
+)
+
+// logSender 不会真正发送短信，只把短信内容写入日志或文件，用于开发与测试。
+type logSender struct {
+	mu   sync.Mutex
+	file string
+}
+
+var _ Sender = &logSender{}
+
+func newLogSender(file string) *logSender {
+	return &logSender{file: file}
+}
+
+func (s *logSender) SendCode(ctx context.Context, phone, code string, expire time.Duration) error {
+	if s.file == "" {
+		log.L(ctx).Infof("[SMS] phone: %s, code: %s, expire: %s", phone, code, expire)
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return errors.Wrap(err, "failed to open sms log file")
+	}
+	//goland:noinspection GoUnhandledErrorResult
+	defer f.Close()
+
+	line := fmt.Sprintf("%s\t%s\t%s\t%s\n", time.Now().Format(time.RFC3339), phone, code, expire)
+	if _, err := f.WriteString(line); err != nil {
+		return errors.Wrap(err, "failed to write sms log file")
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package sms
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/eachinchung/errors"
+
+	"github.com/eachinchung/e-service/internal/pkg/options"
+)
+
+// Sender 短信发送器。
+type Sender interface {
+	// SendCode 向手机号发送验证码短信，expire 为验证码的有效期。
+	SendCode(ctx context.Context, phone, code string, expire time.Duration) error
+}
+
+var (
+	sender Sender
+	once   sync.Once
+)
+
+// GetSenderOr 根据配置创建短信发送器。
+func GetSenderOr(opts *options.SMSOptions, tc *options.TencentCloudOptions) (Sender, error) {
+	var err error
+
+	once.Do(func() {
+		switch opts.Driver {
+		case "tencent":
+			sender = newTencentSender(tc)
+		case "log":
+			sender = newLogSender(opts.LogFile)
+		default:
+			err = errors.Errorf("unsupported sms driver: %s", opts.Driver)
+		}
+	})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "获取短信发送器失败")
+	}
+
+	return sender, nil
+}
+
+// Client 返回短信发送器实例。
+func Client() Sender {
+	if sender == nil {
+		panic("sms sender is not set")
+	}
+	return sender
+}
@@ -0,0 +1,159 @@
+package sms
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/eachinchung/errors"
+
+	"github.com/eachinchung/e-service/internal/pkg/options"
+)
+
+const (
+	tencentSMSHost    = "sms.tencentcloudapi.com"
+	tencentSMSService = "sms"
+	tencentSMSVersion = "2021-01-11"
+	tencentSMSAction  = "SendSms"
+	tencentAlgorithm  = "TC3-HMAC-SHA256"
+)
+
+// tencentSender 通过腾讯云短信 API 发送短信。
+type tencentSender struct {
+	opts   *options.TencentCloudOptions
+	client *http.Client
+}
+
+var _ Sender = &tencentSender{}
+
+func newTencentSender(opts *options.TencentCloudOptions) *tencentSender {
+	return &tencentSender{
+		opts:   opts,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type sendSmsRequest struct {
+	PhoneNumberSet   []string `json:"PhoneNumberSet"`
+	SmsSdkAppID      string   `json:"SmsSdkAppId"`
+	SignName         string   `json:"SignName"`
+	TemplateID       string   `json:"TemplateId"`
+	TemplateParamSet []string `json:"TemplateParamSet"`
+}
+
+type sendSmsResponse struct {
+	Response struct {
+		SendStatusSet []struct {
+			Code    string `json:"Code"`
+			Message string `json:"Message"`
+		} `json:"SendStatusSet"`
+		Error *struct {
+			Code    string `json:"Code"`
+			Message string `json:"Message"`
+		} `json:"Error"`
+		RequestID string `json:"RequestId"`
+	} `json:"Response"`
+}
+
+func (s *tencentSender) SendCode(ctx context.Context, phone, code string, expire time.Duration) error {
+	payload, err := json.Marshal(sendSmsRequest{
+		PhoneNumberSet:   []string{"+86" + phone},
+		SmsSdkAppID:      s.opts.SMSSdkAppID,
+		SignName:         s.opts.SMSSignName,
+		TemplateID:       s.opts.SMSCodeTemplateID,
+		TemplateParamSet: []string{code, strconv.Itoa(int(expire.Minutes()))},
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal sms request")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://"+tencentSMSHost, bytes.NewReader(payload))
+	if err != nil {
+		return errors.Wrap(err, "failed to create sms request")
+	}
+	s.sign(req, payload, time.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to send sms request")
+	}
+	//goland:noinspection GoUnhandledErrorResult
+	defer resp.Body.Close()
+
+	var result sendSmsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return errors.Wrap(err, "failed to decode sms response")
+	}
+
+	if result.Response.Error != nil {
+		return errors.Errorf(
+			"send sms failed, request id: %s, code: %s, message: %s",
+			result.Response.RequestID, result.Response.Error.Code, result.Response.Error.Message,
+		)
+	}
+
+	for _, status := range result.Response.SendStatusSet {
+		if status.Code != "Ok" {
+			return errors.Errorf(
+				"send sms failed, request id: %s, code: %s, message: %s",
+				result.Response.RequestID, status.Code, status.Message,
+			)
+		}
+	}
+
+	return nil
+}
+
+// sign 使用 TC3-HMAC-SHA256 签名方法为请求添加签名，
+// 详见 https://cloud.tencent.com/document/api/382/52071
+func (s *tencentSender) sign(req *http.Request, payload []byte, now time.Time) {
+	const contentType = "application/json; charset=utf-8"
+
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	date := now.UTC().Format("2006-01-02")
+
+	canonicalRequest := fmt.Sprintf(
+		"POST\n/\n\ncontent-type:%s\nhost:%s\n\ncontent-type;host\n%s",
+		contentType, tencentSMSHost, sha256Hex(payload),
+	)
+
+	credentialScope := fmt.Sprintf("%s/%s/tc3_request", date, tencentSMSService)
+	stringToSign := fmt.Sprintf(
+		"%s\n%s\n%s\n%s",
+		tencentAlgorithm, timestamp, credentialScope, sha256Hex([]byte(canonicalRequest)),
+	)
+
+	secretDate := hmacSHA256([]byte("TC3"+s.opts.SecretKey), date)
+	secretService := hmacSHA256(secretDate, tencentSMSService)
+	secretSigning := hmacSHA256(secretService, "tc3_request")
+	signature := hex.EncodeToString(hmacSHA256(secretSigning, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"%s Credential=%s/%s, SignedHeaders=content-type;host, Signature=%s",
+		tencentAlgorithm, s.opts.SecretID, credentialScope, signature,
+	))
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Host", tencentSMSHost)
+	req.Header.Set("X-TC-Action", tencentSMSAction)
+	req.Header.Set("X-TC-Timestamp", timestamp)
+	req.Header.Set("X-TC-Version", tencentSMSVersion)
+	req.Header.Set("X-TC-Region", s.opts.SMSRegion)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
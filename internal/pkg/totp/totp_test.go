@@ -0,0 +1,93 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret 是 RFC 6238 附录 B 中 SHA1 测试向量使用的密钥 "12345678901234567890"。
+var rfc6238Secret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+// rfc6238Vectors 来自 RFC 6238 附录 B 的 SHA1 测试向量，取 8 位验证码的后 6 位。
+var rfc6238Vectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "287082"},
+	{1111111109, "081804"},
+	{1111111111, "050471"},
+	{1234567890, "005924"},
+	{2000000000, "279037"},
+	{20000000000, "353130"},
+}
+
+func TestGenerate(t *testing.T) {
+	for _, v := range rfc6238Vectors {
+		got, err := Generate(rfc6238Secret, Step(time.Unix(v.unix, 0)))
+		if err != nil {
+			t.Fatalf("Generate(%d) error: %v", v.unix, err)
+		}
+		if got != v.code {
+			t.Errorf("Generate(%d) = %s, want %s", v.unix, got, v.code)
+		}
+	}
+}
+
+func TestGenerateLowercaseSecret(t *testing.T) {
+	got, err := Generate(rfc6238Secret, Step(time.Unix(59, 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lower, err := Generate(strings.ToLower(rfc6238Secret), Step(time.Unix(59, 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != lower {
+		t.Errorf("lowercase secret = %s, want %s", lower, got)
+	}
+}
+
+func TestGenerateInvalidSecret(t *testing.T) {
+	if _, err := Generate("not a base32 secret!", 1); err == nil {
+		t.Error("Generate with invalid secret should fail")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+
+	for _, tt := range []struct {
+		name string
+		code string
+		skew int
+		want bool
+	}{
+		{"current step", "050471", 0, true},
+		{"previous step within skew", "081804", 1, true},
+		{"previous step without skew", "081804", 0, false},
+		{"wrong code", "000000", 1, false},
+		{"wrong length", "0504710", 1, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ok := Validate(rfc6238Secret, tt.code, now, tt.skew)
+			if ok != tt.want {
+				t.Errorf("Validate(%s) = %v, want %v", tt.code, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateReturnsMatchedStep(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+
+	step, ok := Validate(rfc6238Secret, "081804", now, 1)
+	if !ok {
+		t.Fatal("Validate should accept the code of the previous step")
+	}
+	if want := Step(time.Unix(1111111109, 0)); step != want {
+		t.Errorf("Validate step = %d, want %d", step, want)
+	}
+}
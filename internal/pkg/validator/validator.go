@@ -3,11 +3,15 @@ package validator
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net"
+	"net/url"
 	"reflect"
 	"regexp"
 	"strings"
 
 	"github.com/eachinchung/e-service/internal/pkg/casbin"
+	"github.com/eachinchung/e-service/internal/pkg/pwdpolicy"
 
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/locales/zh"
@@ -21,10 +25,13 @@ import (
 var Trans ut.Translator
 
 const (
-	password  = "password"
-	phone     = "phone"
-	eid       = "eid"
-	isNotRole = "is_not_role"
+	password    = "password"
+	phone       = "phone"
+	eid         = "eid"
+	isNotRole   = "is_not_role"
+	regex       = "regexp"
+	role        = "role"
+	redirectURI = "redirect_uri"
 )
 
 func InitValidator() error {
@@ -49,6 +56,15 @@ func InitValidator() error {
 		if err := v.RegisterValidation(isNotRole, isNotRoleValidation); err != nil {
 			return err
 		}
+		if err := v.RegisterValidation(regex, regexValidation); err != nil {
+			return err
+		}
+		if err := v.RegisterValidation(role, roleValidation); err != nil {
+			return err
+		}
+		if err := v.RegisterValidation(redirectURI, redirectURIValidation); err != nil {
+			return err
+		}
 
 		zhT := zh.New()
 		uni := ut.New(zhT, zhT)
@@ -72,8 +88,8 @@ func InitValidator() error {
 		if err := v.RegisterTranslation(
 			password,
 			Trans,
-			registerTranslator(password, "密码必须存在特殊字符、大小写字母和数字"),
-			translate,
+			registerPasswordTranslator(),
+			translatePassword,
 		); err != nil {
 			return err
 		}
@@ -93,6 +109,30 @@ func InitValidator() error {
 		); err != nil {
 			return err
 		}
+		if err := v.RegisterTranslation(
+			regex,
+			Trans,
+			registerTranslator(regex, "必须是合法的正则表达式"),
+			translate,
+		); err != nil {
+			return err
+		}
+		if err := v.RegisterTranslation(
+			role,
+			Trans,
+			registerTranslator(role, "角色名必须以字母开头，可以使用2-64位字母、数字、下划线或减号组合而成"),
+			translate,
+		); err != nil {
+			return err
+		}
+		if err := v.RegisterTranslation(
+			redirectURI,
+			Trans,
+			registerTranslator(redirectURI, "回调地址必须使用 https、本机 http 或反向域名形式的自定义协议，并且不能包含片段"),
+			translate,
+		); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -103,6 +143,12 @@ func ParseValidationError(err error) map[string]string {
 		return removeTopStruct(errs.Translate(Trans))
 	}
 
+	// 密码历史等依赖用户数据的密码策略在 service 中校验
+	var violation *pwdpolicy.Violation
+	if errors.As(err, &violation) {
+		return map[string]string{password: translateViolation(violation)}
+	}
+
 	return nil
 }
 
@@ -134,10 +180,47 @@ func translate(trans ut.Translator, fe validator.FieldError) string {
 	return msg
 }
 
-// passwordValidation 密码校验
+// registerPasswordTranslator 为密码策略的每条规则添加翻译
+func registerPasswordTranslator() validator.RegisterTranslationsFunc {
+	return func(trans ut.Translator) error {
+		msgs := map[string]string{
+			pwdpolicy.RuleMinLength: "密码长度不能少于{0}位",
+			pwdpolicy.RulePower:     "密码必须存在特殊字符、大小写字母和数字",
+			pwdpolicy.RuleBreached:  "密码过于常见或已经泄露，请更换密码",
+			pwdpolicy.RuleStrength:  "密码强度不足，请使用更长或更复杂的密码",
+			pwdpolicy.RuleReused:    "不能使用最近{0}次使用过的密码",
+		}
+		for rule, msg := range msgs {
+			if err := trans.Add(rule, msg, false); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// translatePassword 根据密码违反的规则翻译提示信息
+func translatePassword(_ ut.Translator, fe validator.FieldError) string {
+	violation := pwdpolicy.Client().Check(fmt.Sprint(fe.Value()))
+	if violation == nil {
+		violation = &pwdpolicy.Violation{Rule: pwdpolicy.RulePower}
+	}
+	return translateViolation(violation)
+}
+
+// translateViolation 翻译违反密码策略的提示信息
+func translateViolation(v *pwdpolicy.Violation) string {
+	msg, err := Trans.T(v.Rule, v.Param)
+	if err != nil {
+		panic(err)
+	}
+	return msg
+}
+
+// passwordValidation 密码校验，密码需要符合密码策略
 func passwordValidation(fl validator.FieldLevel) bool {
 	val := fl.Field().String()
-	return verification.PasswordPower(val)
+	return pwdpolicy.Client().Check(val) == nil
 }
 
 // phoneValidation 手机号校验
@@ -153,9 +236,46 @@ func eidValidation(fl validator.FieldLevel) bool {
 	return rgx.MatchString(val)
 }
 
-// isNotRoleValidation 角色名校验，用户名不能为 rbac 的角色名
+// isNotRoleValidation 角色名校验，用户名不能为 rbac 的角色名，也不能与已拥有策略的未加前缀的主体同名
 func isNotRoleValidation(fl validator.FieldLevel) bool {
 	val := fl.Field().String()
-	permissions := casbin.GetPermissionsForUser(context.Background(), val)
-	return len(permissions) == 0
+	ctx := context.Background()
+	return !casbin.IsRole(ctx, val) && !casbin.HasPolicy(ctx, val)
+}
+
+// regexValidation 正则表达式校验
+func regexValidation(fl validator.FieldLevel) bool {
+	_, err := regexp.Compile(fl.Field().String())
+	return err == nil
+}
+
+// roleValidation 角色名校验
+func roleValidation(fl validator.FieldLevel) bool {
+	val := fl.Field().String()
+	rgx := regexp.MustCompile(`^[a-zA-Z][a-zA-Z\d_-]{1,63}$`)
+	return rgx.MatchString(val)
+}
+
+// redirectURIValidation OAuth 回调地址校验，参考 RFC 8252 只允许以下形式：
+// https 地址；指向本机回环地址的 http 地址；反向域名形式的自定义协议，例如 com.example.app:/callback。
+// javascript:、data: 等协议不包含 "."，不会被当作自定义协议接受。
+func redirectURIValidation(fl validator.FieldLevel) bool {
+	u, err := url.Parse(fl.Field().String())
+	if err != nil || u.Fragment != "" || u.User != nil {
+		return false
+	}
+
+	switch u.Scheme {
+	case "https":
+		return u.Host != ""
+	case "http":
+		host := u.Hostname()
+		if host == "localhost" {
+			return true
+		}
+		ip := net.ParseIP(host)
+		return ip != nil && ip.IsLoopback()
+	default:
+		return strings.Contains(u.Scheme, ".") && u.Opaque == ""
+	}
 }